@@ -0,0 +1,151 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+type Config struct {
+	// Azure AD tenant ID.
+	TenantID string
+	// Client ID of the application registration or user assigned managed identity.
+	ClientID string
+	// Client secret of the application registration.
+	ClientSecret string
+	// Path to a federated token file used for workload identity.
+	FederatedTokenFile string
+	// Should workload identity be included in the chain.
+	EnableWorkloadIdentity bool
+	// Should client secret authentication be included in the chain.
+	EnableClientSecret bool
+	// Should managed identity be included in the chain.
+	EnableManagedIdentity bool
+	// Should the Azure CLI be included in the chain.
+	EnableAzureCLI bool
+	// Duration before expiry at which cached tokens are refreshed.
+	RefreshBefore time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TenantID:               "",
+		ClientID:               "",
+		ClientSecret:           "",
+		FederatedTokenFile:     "",
+		EnableWorkloadIdentity: true,
+		EnableClientSecret:     true,
+		EnableManagedIdentity:  true,
+		EnableAzureCLI:         true,
+		RefreshBefore:          5 * time.Minute,
+	}
+}
+
+// ConfigFromEnv returns the default config populated from the environment variables used by
+// the Azure SDKs and the workload identity webhook.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.TenantID = os.Getenv("AZURE_TENANT_ID")
+	cfg.ClientID = os.Getenv("AZURE_CLIENT_ID")
+	cfg.ClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+	cfg.FederatedTokenFile = os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+	return cfg
+}
+
+// NewTokenCredential returns a cached credential which tries workload identity, client secret,
+// managed identity and the Azure CLI in order. Credentials which lack the required configuration
+// are left out of the chain.
+func NewTokenCredential(cfg Config) (azcore.TokenCredential, error) {
+	creds := []azcore.TokenCredential{}
+	if cfg.EnableWorkloadIdentity && cfg.FederatedTokenFile != "" && cfg.TenantID != "" && cfg.ClientID != "" {
+		getAssertion := func(context.Context) (string, error) {
+			b, err := os.ReadFile(cfg.FederatedTokenFile)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(b)), nil
+		}
+		cred, err := azidentity.NewClientAssertionCredential(cfg.TenantID, cfg.ClientID, getAssertion, nil)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	if cfg.EnableClientSecret && cfg.ClientSecret != "" && cfg.TenantID != "" && cfg.ClientID != "" {
+		cred, err := azidentity.NewClientSecretCredential(cfg.TenantID, cfg.ClientID, cfg.ClientSecret, nil)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	if cfg.EnableManagedIdentity {
+		opts := &azidentity.ManagedIdentityCredentialOptions{}
+		if cfg.ClientID != "" {
+			opts.ID = azidentity.ClientID(cfg.ClientID)
+		}
+		cred, err := azidentity.NewManagedIdentityCredential(opts)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	if cfg.EnableAzureCLI {
+		cred, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: cfg.TenantID})
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	if len(creds) == 0 {
+		return nil, errors.New("no credentials are enabled")
+	}
+	chain, err := azidentity.NewChainedTokenCredential(creds, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewCachedTokenCredential(chain, cfg.RefreshBefore), nil
+}
+
+type cachedTokenCredential struct {
+	cred          azcore.TokenCredential
+	refreshBefore time.Duration
+	now           func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]azcore.AccessToken
+}
+
+// NewCachedTokenCredential wraps cred so that tokens are cached per set of scopes until
+// refreshBefore ahead of their expiry.
+func NewCachedTokenCredential(cred azcore.TokenCredential, refreshBefore time.Duration) azcore.TokenCredential {
+	return &cachedTokenCredential{
+		cred:          cred,
+		refreshBefore: refreshBefore,
+		now:           time.Now,
+		tokens:        map[string]azcore.AccessToken{},
+	}
+}
+
+func (c *cachedTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	key := strings.Join(opts.Scopes, " ")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, ok := c.tokens[key]
+	if ok && c.now().Add(c.refreshBefore).Before(token.ExpiresOn) {
+		return token, nil
+	}
+	token, err := c.cred.GetToken(ctx, opts)
+	if err != nil {
+		return azcore.AccessToken{}, err
+	}
+	c.tokens[key] = token
+	return token, nil
+}
@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredential struct {
+	calls int
+}
+
+func (f *fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.calls++
+	return azcore.AccessToken{Token: opts.Scopes[0], ExpiresOn: time.Now().Add(10 * time.Minute)}, nil
+}
+
+func TestCachedTokenCredential(t *testing.T) {
+	fake := &fakeCredential{}
+	cred := NewCachedTokenCredential(fake, 5*time.Minute).(*cachedTokenCredential)
+	now := time.Now()
+	cred.now = func() time.Time {
+		return now
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"foo"}})
+		require.NoError(t, err)
+		require.Equal(t, "foo", token.Token)
+	}
+	require.Equal(t, 1, fake.calls)
+
+	_, err := cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"bar"}})
+	require.NoError(t, err)
+	require.Equal(t, 2, fake.calls)
+
+	now = now.Add(6 * time.Minute)
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"foo"}})
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.calls)
+}
+
+func TestNewTokenCredentialNoneEnabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableManagedIdentity = false
+	cfg.EnableAzureCLI = false
+	_, err := NewTokenCredential(cfg)
+	require.EqualError(t, err, "no credentials are enabled")
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(&fakeCredential{}, []string{"https://example.com/.default"}, nil)}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer https://example.com/.default", string(b))
+}
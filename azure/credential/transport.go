@@ -0,0 +1,37 @@
+package credential
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type bearerTransport struct {
+	cred   azcore.TokenCredential
+	scopes []string
+	base   http.RoundTripper
+}
+
+// NewTransport returns a round tripper which attaches a bearer token for the given scopes
+// to every outgoing request. The default transport is used if base is nil.
+func NewTransport(cred azcore.TokenCredential, scopes []string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &bearerTransport{
+		cred:   cred,
+		scopes: scopes,
+		base:   base,
+	}
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.cred.GetToken(req.Context(), policy.TokenRequestOptions{Scopes: t.scopes})
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Config struct {
+	// Duration entries are kept after being set, zero disables expiry.
+	TTL time.Duration
+	// Maximum number of entries, the least recently used entry is evicted when exceeded. Zero disables the limit.
+	MaxEntries int
+	// Name of the cache, used as label in metrics.
+	Name string
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TTL:        5 * time.Minute,
+		MaxEntries: 1000,
+		Name:       "",
+		Registerer: nil,
+	}
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is an in-memory cache with per entry expiry and LRU eviction. It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	cfg     Config
+	now     func() time.Time
+	metrics *metrics
+
+	mu      sync.Mutex
+	items   map[K]*list.Element
+	lru     *list.List
+	loading map[K]*call[V]
+}
+
+// New returns an empty cache. An error is returned if the metrics cannot be registered with
+// cfg.Registerer.
+func New[K comparable, V any](cfg Config) (*Cache[K, V], error) {
+	c := &Cache[K, V]{
+		cfg:     cfg,
+		now:     time.Now,
+		items:   map[K]*list.Element{},
+		lru:     list.New(),
+		loading: map[K]*call[V]{},
+	}
+	if cfg.Registerer != nil {
+		m, err := newMetrics(cfg.Registerer, cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		c.metrics = m
+	}
+	return c, nil
+}
+
+// Get returns the value for the key, false is returned if the key does not exist or has expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// Set adds or replaces the value for the key using the configured TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.cfg.TTL)
+}
+
+// SetWithTTL adds or replaces the value for the key, a zero ttl means the entry does not expire.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns the number of entries in the cache, including expired entries which have not yet been removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge removes all entries from the cache.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = map[K]*list.Element{}
+	c.lru.Init()
+}
+
+// GetOrLoad returns the cached value for the key or calls loader to load it. Concurrent calls for
+// the same key share a single call to loader, which is passed the context of the first caller.
+// Errors returned by loader are not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+	cl, ok := c.loading[key]
+	if !ok {
+		cl = &call[V]{done: make(chan struct{})}
+		c.loading[key] = cl
+		go c.load(ctx, key, cl, loader)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], loader func(ctx context.Context) (V, error)) {
+	defer close(cl.done)
+	value, err := loader(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.loading, key)
+	cl.value, cl.err = value, err
+	if err == nil {
+		c.set(key, value, c.cfg.TTL)
+	}
+}
+
+// get has to be called with the lock held.
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		c.observe(false)
+		var zero V
+		return zero, false
+	}
+	e := elem.Value.(*entry[K, V])
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		c.remove(elem)
+		c.observe(false)
+		var zero V
+		return zero, false
+	}
+	c.lru.MoveToFront(elem)
+	c.observe(true)
+	return e.value, true
+}
+
+// set has to be called with the lock held.
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	expiresAt := time.Time{}
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.cfg.MaxEntries > 0 && c.lru.Len() > c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+		if c.metrics != nil {
+			c.metrics.evictions.Inc()
+		}
+	}
+}
+
+// remove has to be called with the lock held.
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
+
+func (c *Cache[K, V]) observe(hit bool) {
+	if c.metrics == nil {
+		return
+	}
+	if hit {
+		c.metrics.hits.Inc()
+		return
+	}
+	c.metrics.misses.Inc()
+}
@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTL(t *testing.T) {
+	now := time.Now()
+	cfg := DefaultConfig()
+	cfg.TTL = time.Minute
+	c, err := New[string, int](cfg)
+	require.NoError(t, err)
+	c.now = func() time.Time {
+		return now
+	}
+
+	c.Set("foo", 1)
+	c.SetWithTTL("bar", 2, 0)
+	value, ok := c.Get("foo")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("foo")
+	require.False(t, ok)
+	value, ok = c.Get("bar")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+	require.Equal(t, 1, c.Len())
+
+	c.Delete("bar")
+	require.Equal(t, 0, c.Len())
+}
+
+func TestLRUEviction(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultConfig()
+	cfg.MaxEntries = 2
+	cfg.Name = "test"
+	cfg.Registerer = reg
+	c, err := New[string, int](cfg)
+	require.NoError(t, err)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	c.Set("c", 3)
+
+	_, ok = c.Get("b")
+	require.False(t, ok)
+	_, ok = c.Get("a")
+	require.True(t, ok)
+	_, ok = c.Get("c")
+	require.True(t, ok)
+
+	m, err := newMetrics(reg, "test")
+	require.NoError(t, err)
+	require.Equal(t, float64(3), testutil.ToFloat64(m.hits))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.misses))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.evictions))
+
+	c.Purge()
+	require.Equal(t, 0, c.Len())
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c, err := New[string, int](DefaultConfig())
+	require.NoError(t, err)
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.GetOrLoad(context.Background(), "foo", loader)
+			require.NoError(t, err)
+			require.Equal(t, 42, value)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	value, err := c.GetOrLoad(context.Background(), "foo", loader)
+	require.NoError(t, err)
+	require.Equal(t, 42, value)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	c, err := New[string, int](DefaultConfig())
+	require.NoError(t, err)
+	_, err = c.GetOrLoad(context.Background(), "foo", func(ctx context.Context) (int, error) {
+		return 0, errors.New("load failed")
+	})
+	require.EqualError(t, err, "load failed")
+	_, ok := c.Get("foo")
+	require.False(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetOrLoad(ctx, "bar", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewMetricsRegistrationError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "cache_hits_total", Help: "Other metric."}))
+	cfg := DefaultConfig()
+	cfg.Registerer = reg
+	_, err := New[string, int](cfg)
+	require.ErrorContains(t, err, "could not register metrics")
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer, name string) (*metrics, error) {
+	hits, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Total number of cache lookups which found a value.",
+	}, []string{"cache"}))
+	if err != nil {
+		return nil, err
+	}
+	misses, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Total number of cache lookups which did not find a value.",
+	}, []string{"cache"}))
+	if err != nil {
+		return nil, err
+	}
+	evictions, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_evictions_total",
+		Help: "Total number of entries evicted because the cache was full.",
+	}, []string{"cache"}))
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{
+		hits:      hits.WithLabelValues(name),
+		misses:    misses.WithLabelValues(name),
+		evictions: evictions.WithLabelValues(name),
+	}, nil
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered. Other registration errors, such as a collector with the same name
+// but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
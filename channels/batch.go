@@ -0,0 +1,65 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// Batch groups values from in into slices which are emitted when maxSize values
+// have been collected or when maxWait has passed since the first value in the batch
+// was received. A maxSize or maxWait of zero disables that trigger. Any partial batch
+// is flushed when in is closed.
+func Batch[T any](ctx context.Context, in <-chan T, maxSize int, maxWait time.Duration) <-chan []T {
+	out := make(chan []T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		var batch []T
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer = nil
+				timeout = nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeout = timer.C
+				}
+				if maxSize > 0 && len(batch) >= maxSize {
+					if !flush() {
+						return
+					}
+				}
+			case <-timeout:
+				if !flush() {
+					return
+				}
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchMaxSize(t *testing.T) {
+	in := make(chan int)
+	out := Batch(context.Background(), in, 2, time.Hour)
+	go func() {
+		for i := 0; i < 5; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	batches := [][]int{}
+	for b := range out {
+		batches = append(batches, b)
+	}
+	require.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, batches)
+}
+
+func TestBatchMaxWait(t *testing.T) {
+	in := make(chan int)
+	out := Batch(context.Background(), in, 10, 10*time.Millisecond)
+	in <- 1
+	in <- 2
+	require.Equal(t, []int{1, 2}, <-out)
+	close(in)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Batch(ctx, in, 10, time.Hour)
+	in <- 1
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestDebounce(t *testing.T) {
+	in := make(chan int)
+	out := Debounce(context.Background(), in, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	in <- 3
+	require.Equal(t, 3, <-out)
+	in <- 4
+	close(in)
+	require.Equal(t, 4, <-out)
+	_, ok := <-out
+	require.False(t, ok)
+}
@@ -1,6 +1,7 @@
 package channels
 
 import (
+	"context"
 	"sync"
 )
 
@@ -26,12 +27,75 @@ func Merge[T any](cs ...<-chan T) <-chan T {
 	return out
 }
 
+// Map applies mapFunc to every value from in and closes the output channel when in is closed.
 func Map[T any, U any](in <-chan T, mapFunc func(T) U) <-chan U {
 	out := make(chan U)
 	go func() {
+		defer close(out)
 		for v := range in {
 			out <- mapFunc(v)
 		}
 	}()
 	return out
 }
+
+// MergeContext is like Merge but stops when ctx is cancelled, and its goroutines are tracked by
+// the group of ctx.
+func MergeContext[T any](ctx context.Context, cs ...<-chan T) <-chan T {
+	var wg sync.WaitGroup
+	out := make(chan T)
+
+	wg.Add(len(cs))
+	for _, c := range cs {
+		c := c
+		spawn(ctx, func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case v, ok := <-c:
+					if !ok {
+						return
+					}
+					select {
+					case out <- v:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		})
+	}
+
+	spawn(ctx, func() {
+		wg.Wait()
+		close(out)
+	})
+	return out
+}
+
+// MapContext is like Map but stops when ctx is cancelled, and its goroutine is tracked by the
+// group of ctx.
+func MapContext[T any, U any](ctx context.Context, in <-chan T, mapFunc func(T) U) <-chan U {
+	out := make(chan U)
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- mapFunc(v):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
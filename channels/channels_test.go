@@ -0,0 +1,47 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	ctx := context.Background()
+	out := Map(FromSlice(ctx, []int{1, 2, 3}), func(v int) int { return v * 2 })
+	require.Equal(t, []int{2, 4, 6}, ToSlice(ctx, out))
+}
+
+func TestMergeContext(t *testing.T) {
+	g, ctx := WithGroup(context.Background())
+	out := MergeContext(ctx, FromSlice(ctx, []int{1, 2}), FromSlice(ctx, []int{3}))
+	require.ElementsMatch(t, []int{1, 2, 3}, ToSlice(ctx, out))
+	require.NoError(t, waitGroup(t, g))
+
+	// Merging inputs which are never closed is stopped by cancelling the group.
+	g, ctx = WithGroup(context.Background())
+	out = MergeContext(ctx, Repeat(ctx, 1), Repeat(ctx, 2))
+	<-out
+	g.Cancel()
+	require.NoError(t, waitGroup(t, g))
+}
+
+func TestMapContext(t *testing.T) {
+	g, ctx := WithGroup(context.Background())
+	out := MapContext(ctx, FromSlice(ctx, []int{1, 2, 3}), func(v int) int { return v * 2 })
+	require.Equal(t, []int{2, 4, 6}, ToSlice(ctx, out))
+	require.NoError(t, waitGroup(t, g))
+
+	g, ctx = WithGroup(context.Background())
+	out = MapContext(ctx, make(chan int), func(v int) int { return v })
+	g.Cancel()
+	require.NoError(t, waitGroup(t, g))
+	select {
+	case _, ok := <-out:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("output was not closed")
+	}
+}
@@ -0,0 +1,165 @@
+package channelstest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Record is a value received from the channel and the time it was received.
+type Record[T any] struct {
+	Value T
+	At    time.Time
+}
+
+// Recorder reads all values from a channel in the background, allowing tests to assert on them
+// with timeouts instead of hand written select loops. Assertions which wait for values consume
+// them, so consecutive assertions continue where the previous one stopped.
+type Recorder[T any] struct {
+	t testing.TB
+
+	mu      sync.Mutex
+	records []Record[T]
+	closed  bool
+	changed chan struct{}
+	cursor  int
+}
+
+// NewRecorder starts recording the values from in until it is closed.
+func NewRecorder[T any](t testing.TB, in <-chan T) *Recorder[T] {
+	r := &Recorder[T]{
+		t:       t,
+		changed: make(chan struct{}),
+	}
+	go func() {
+		for v := range in {
+			r.mu.Lock()
+			r.records = append(r.records, Record[T]{Value: v, At: time.Now()})
+			r.notify()
+			r.mu.Unlock()
+		}
+		r.mu.Lock()
+		r.closed = true
+		r.notify()
+		r.mu.Unlock()
+	}()
+	return r
+}
+
+// notify wakes up waiting assertions, it has to be called with the lock held.
+func (r *Recorder[T]) notify() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Records returns all values received so far with the time they were received.
+func (r *Recorder[T]) Records() []Record[T] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record[T]{}, r.records...)
+}
+
+// Values returns all values received so far.
+func (r *Recorder[T]) Values() []T {
+	values := []T{}
+	for _, record := range r.Records() {
+		values = append(values, record.Value)
+	}
+	return values
+}
+
+// Replay returns a channel which sends the values received so far and is then closed.
+func (r *Recorder[T]) Replay(ctx context.Context) <-chan T {
+	values := r.Values()
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, v := range values {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// wait blocks until cond returns true or the timeout has passed, cond is called with the lock held.
+func (r *Recorder[T]) wait(timeout time.Duration, cond func() bool) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		r.mu.Lock()
+		if cond() {
+			r.mu.Unlock()
+			return true
+		}
+		changed := r.changed
+		r.mu.Unlock()
+		select {
+		case <-changed:
+		case <-deadline.C:
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return cond()
+		}
+	}
+}
+
+// RequireNext waits for the next n values and returns them, failing the test if they are not
+// received within the timeout.
+func (r *Recorder[T]) RequireNext(n int, timeout time.Duration) []T {
+	r.t.Helper()
+	ok := r.wait(timeout, func() bool {
+		return len(r.records)-r.cursor >= n
+	})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !ok {
+		r.t.Fatalf("expected %d values within %s, received %d", n, timeout, len(r.records)-r.cursor)
+		return nil
+	}
+	values := []T{}
+	for _, record := range r.records[r.cursor : r.cursor+n] {
+		values = append(values, record.Value)
+	}
+	r.cursor += n
+	return values
+}
+
+// RequireReceived waits for the next values and fails the test unless they equal the expected
+// values in order.
+func (r *Recorder[T]) RequireReceived(timeout time.Duration, expected ...T) {
+	r.t.Helper()
+	values := r.RequireNext(len(expected), timeout)
+	require.Equal(r.t, expected, values)
+}
+
+// RequireNoMore fails the test if any value beyond those consumed by previous assertions is
+// received within the duration.
+func (r *Recorder[T]) RequireNoMore(d time.Duration) {
+	r.t.Helper()
+	received := r.wait(d, func() bool {
+		return len(r.records) > r.cursor
+	})
+	if received {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.t.Fatalf("expected no more values, received %v", r.records[r.cursor].Value)
+	}
+}
+
+// RequireClosed fails the test unless the channel is closed within the timeout.
+func (r *Recorder[T]) RequireClosed(timeout time.Duration) {
+	r.t.Helper()
+	ok := r.wait(timeout, func() bool {
+		return r.closed
+	})
+	if !ok {
+		r.t.Fatalf("expected channel to be closed within %s", timeout)
+	}
+}
@@ -0,0 +1,67 @@
+package channelstest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/channels"
+)
+
+func TestRecorder(t *testing.T) {
+	in := make(chan int)
+	r := NewRecorder(t, in)
+	go func() {
+		in <- 1
+		in <- 2
+		time.Sleep(10 * time.Millisecond)
+		in <- 3
+		close(in)
+	}()
+
+	r.RequireReceived(time.Second, 1, 2)
+	require.Equal(t, []int{3}, r.RequireNext(1, time.Second))
+	r.RequireClosed(time.Second)
+	r.RequireNoMore(10 * time.Millisecond)
+
+	records := r.Records()
+	require.Len(t, records, 3)
+	require.False(t, records[2].At.Before(records[1].At))
+	require.Equal(t, []int{1, 2, 3}, r.Values())
+}
+
+func TestRecorderReplay(t *testing.T) {
+	ctx := context.Background()
+	r := NewRecorder(t, channels.FromSlice(ctx, []string{"a", "b"}))
+	r.RequireClosed(time.Second)
+
+	replay := NewRecorder(t, r.Replay(ctx))
+	replay.RequireReceived(time.Second, "a", "b")
+	replay.RequireClosed(time.Second)
+}
+
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.failed = true
+}
+
+func TestRecorderFailures(t *testing.T) {
+	in := make(chan int, 1)
+	in <- 1
+
+	ft := &fakeT{TB: t}
+	r := NewRecorder[int](ft, in)
+	r.RequireNoMore(time.Second)
+	require.True(t, ft.failed)
+
+	ft.failed = false
+	r.RequireClosed(10 * time.Millisecond)
+	require.True(t, ft.failed)
+}
@@ -0,0 +1,42 @@
+package channels
+
+import "context"
+
+// Conflate forwards values from in, dropping intermediate values while the consumer
+// is slow so that only the most recent value is delivered. A pending value is
+// flushed when in is closed.
+func Conflate[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		var latest T
+		var pending bool
+		for {
+			// Sending is only enabled while a value is pending.
+			var send chan<- T
+			if pending {
+				send = out
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						select {
+						case out <- latest:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				latest = v
+				pending = true
+			case send <- latest:
+				pending = false
+			}
+		}
+	})
+	return out
+}
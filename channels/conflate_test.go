@@ -0,0 +1,46 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConflate(t *testing.T) {
+	in := make(chan int)
+	out := Conflate(context.Background(), in)
+
+	in <- 1
+	require.Equal(t, 1, <-out)
+
+	// Values sent while the consumer is not reading are replaced by the latest.
+	for i := 2; i <= 5; i++ {
+		in <- i
+	}
+	require.Equal(t, 5, <-out)
+
+	in <- 6
+	close(in)
+	require.Equal(t, 6, <-out)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestConflateCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Conflate(ctx, in)
+	in <- 1
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			_, ok = <-out
+		}
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("expected output to be closed")
+	}
+}
@@ -0,0 +1,58 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// Debounce emits the latest value received from in once no new value has been
+// received for the duration of wait. A pending value is flushed when in is closed.
+func Debounce[T any](ctx context.Context, in <-chan T, wait time.Duration) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		var latest T
+		var pending bool
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					if pending {
+						select {
+						case out <- latest:
+						case <-ctx.Done():
+						}
+					}
+					return
+				}
+				latest = v
+				pending = true
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.NewTimer(wait)
+				timeout = timer.C
+			case <-timeout:
+				timeout = nil
+				pending = false
+				select {
+				case out <- latest:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
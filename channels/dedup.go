@@ -0,0 +1,76 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// Dedup forwards values from in, dropping values whose key has already been seen within ttl.
+// The ttl starts when a key is first forwarded and is not extended by dropped duplicates.
+func Dedup[T any, K comparable](ctx context.Context, in <-chan T, key func(T) K, ttl time.Duration) <-chan T {
+	return dedup(ctx, in, key, ttl, time.Now)
+}
+
+func dedup[T any, K comparable](ctx context.Context, in <-chan T, key func(T) K, ttl time.Duration, now func() time.Time) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		seen := newExpiringSet[K](now)
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			if !seen.add(key(v), ttl) {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+type expiringEntry[K comparable] struct {
+	key       K
+	expiresAt time.Time
+}
+
+// expiringSet is a set where keys expire after a ttl. Keys are kept in insertion order, which is
+// also expiry order as all keys share the same ttl, so expired keys are removed from the front.
+type expiringSet[K comparable] struct {
+	now   func() time.Time
+	keys  map[K]time.Time
+	order []expiringEntry[K]
+}
+
+func newExpiringSet[K comparable](now func() time.Time) *expiringSet[K] {
+	return &expiringSet[K]{
+		now:  now,
+		keys: map[K]time.Time{},
+	}
+}
+
+// add adds the key and returns true if it was not already in the set.
+func (s *expiringSet[K]) add(key K, ttl time.Duration) bool {
+	now := s.now()
+	s.expire(now)
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+	expiresAt := now.Add(ttl)
+	s.keys[key] = expiresAt
+	s.order = append(s.order, expiringEntry[K]{key: key, expiresAt: expiresAt})
+	return true
+}
+
+func (s *expiringSet[K]) expire(now time.Time) {
+	i := 0
+	for ; i < len(s.order) && !now.Before(s.order[i].expiresAt); i++ {
+		delete(s.keys, s.order[i].key)
+	}
+	s.order = s.order[i:]
+}
@@ -0,0 +1,49 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	now := time.Now()
+	in := make(chan string)
+	out := dedup(context.Background(), in, func(s string) string { return s }, time.Minute, func() time.Time { return now })
+
+	in <- "foo"
+	require.Equal(t, "foo", <-out)
+	in <- "foo"
+	in <- "bar"
+	require.Equal(t, "bar", <-out)
+
+	now = now.Add(time.Minute)
+	in <- "foo"
+	require.Equal(t, "foo", <-out)
+	close(in)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestDedupKey(t *testing.T) {
+	type event struct {
+		Reason string
+		Count  int
+	}
+	in := FromSlice(context.Background(), []event{{"a", 1}, {"a", 2}, {"b", 1}, {"a", 3}})
+	out := Dedup(context.Background(), in, func(e event) string { return e.Reason }, time.Hour)
+	require.Equal(t, []event{{"a", 1}, {"b", 1}}, collect(out))
+}
+
+func TestDedupCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := Dedup(ctx, in, func(i int) int { return i }, time.Hour)
+	in <- 1
+	cancel()
+	<-out
+	_, ok := <-out
+	require.False(t, ok)
+}
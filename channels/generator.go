@@ -0,0 +1,79 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// FromSlice emits all values in order and then closes the output channel.
+func FromSlice[T any](ctx context.Context, values []T) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		for _, v := range values {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// ToSlice reads values from in until it is closed or ctx is cancelled.
+func ToSlice[T any](ctx context.Context, in <-chan T) []T {
+	values := []T{}
+	for {
+		v, err := First(ctx, in)
+		if err != nil {
+			return values
+		}
+		values = append(values, v)
+	}
+}
+
+// Repeat emits the given values in order over and over until ctx is cancelled.
+func Repeat[T any](ctx context.Context, values ...T) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		if len(values) == 0 {
+			return
+		}
+		for {
+			for _, v := range values {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
+
+// Interval emits the current time every d until ctx is cancelled. Ticks are dropped
+// if the consumer is slow.
+func Interval(ctx context.Context, d time.Duration) <-chan time.Time {
+	out := make(chan time.Time)
+	spawn(ctx, func() {
+		defer close(out)
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				select {
+				case out <- t:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,38 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSliceToSlice(t *testing.T) {
+	values := ToSlice(context.Background(), FromSlice(context.Background(), []string{"foo", "bar"}))
+	require.Equal(t, []string{"foo", "bar"}, values)
+}
+
+func TestToSliceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Empty(t, ToSlice(ctx, make(chan int)))
+}
+
+func TestRepeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	values := ToSlice(context.Background(), Take(ctx, Repeat(ctx, 1, 2), 5))
+	require.Equal(t, []int{1, 2, 1, 2, 1}, values)
+	cancel()
+}
+
+func TestInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Interval(ctx, time.Millisecond)
+	first := <-out
+	second := <-out
+	require.True(t, second.After(first))
+	cancel()
+	for range out {
+	}
+}
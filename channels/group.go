@@ -0,0 +1,77 @@
+package channels
+
+import (
+	"context"
+	"sync"
+)
+
+type groupKey struct{}
+
+// Group is an error group which also tracks the goroutines started by operators within its
+// scope. Operators which take a context derived from the context returned by WithGroup add their
+// goroutines to the group, so that Wait returns once every stage has exited. Merge, Map,
+// MergeSorted and InstrumentHook do not take a context and are not tracked, use MergeContext and
+// MapContext instead.
+type Group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// WithGroup returns a group and a context scoping the operators to the group. The context is
+// cancelled when a function started with Go returns an error, when Cancel or Wait is called or
+// when the parent context is cancelled.
+func WithGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{cancel: cancel}
+	g.ctx = context.WithValue(ctx, groupKey{}, g)
+	return g, g.ctx
+}
+
+// Go runs fn in a goroutine tracked by the group. The first error returned cancels the context
+// of the group and is returned by Wait.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	g.spawn(func() {
+		err := fn(g.ctx)
+		if err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	})
+}
+
+// Cancel cancels the context of the group, which stops all operators in the group.
+func (g *Group) Cancel() {
+	g.cancel()
+}
+
+// Wait blocks until all goroutines in the group have exited and returns the first error returned
+// by a function started with Go. Operators exit when their input is closed and drained or when
+// the context is cancelled. The context of the group is cancelled when Wait returns.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func (g *Group) spawn(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+}
+
+// spawn runs fn in a goroutine, tracked by the group of ctx if there is one.
+func spawn(ctx context.Context, fn func()) {
+	g, ok := ctx.Value(groupKey{}).(*Group)
+	if !ok {
+		go fn()
+		return
+	}
+	g.spawn(fn)
+}
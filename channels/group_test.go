@@ -0,0 +1,76 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func waitGroup(t *testing.T, g *Group) error {
+	t.Helper()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- g.Wait()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("group goroutines did not exit")
+		return nil
+	}
+}
+
+func TestGroupCancel(t *testing.T) {
+	g, ctx := WithGroup(context.Background())
+	out := Skip(ctx, Dedup(ctx, Repeat(ctx, 1, 2, 3), func(v int) int { return v }, time.Minute), 1)
+	require.Equal(t, 2, <-out)
+
+	// Stages blocked on sending are stopped by cancelling the group.
+	g.Cancel()
+	require.NoError(t, waitGroup(t, g))
+}
+
+func TestGroupDrained(t *testing.T) {
+	g, ctx := WithGroup(context.Background())
+	out := Batch(ctx, FromSlice(ctx, []int{1, 2, 3, 4, 5}), 2, time.Minute)
+	require.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, ToSlice(ctx, out))
+	require.NoError(t, waitGroup(t, g))
+}
+
+func TestGroupGo(t *testing.T) {
+	g, ctx := WithGroup(context.Background())
+	exited := make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(exited)
+		return nil
+	})
+	g.Cancel()
+	require.NoError(t, waitGroup(t, g))
+	select {
+	case <-exited:
+	default:
+		t.Fatal("goroutine did not exit before wait returned")
+	}
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestGroupError(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := WithGroup(context.Background())
+	// The operators and the other function are stopped by the first error.
+	out := MapContext(ctx, Repeat(ctx, 1), func(v int) int { return v * 2 })
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("second")
+	})
+	g.Go(func(ctx context.Context) error {
+		<-out
+		return errFirst
+	})
+	require.ErrorIs(t, waitGroup(t, g), errFirst)
+}
@@ -0,0 +1,117 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StageHook receives notifications about values passing through an instrumented stage.
+type StageHook interface {
+	// Received is called when a value has been received from the stage input.
+	Received()
+	// Sent is called when a value has been accepted downstream, stall is the time spent
+	// waiting for the consumer to receive it.
+	Sent(stall time.Duration)
+}
+
+// InstrumentHook forwards all values from in while notifying hook about their progress.
+func InstrumentHook[T any](in <-chan T, hook StageHook) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range in {
+			hook.Received()
+			start := time.Now()
+			out <- v
+			hook.Sent(time.Since(start))
+		}
+	}()
+	return out
+}
+
+// Instrument forwards all values from in while recording throughput, in flight values and
+// stall time for the named stage in the Prometheus registry. An error is returned if the metrics
+// cannot be registered, in which case in is not consumed.
+func Instrument[T any](in <-chan T, name string, reg prometheus.Registerer) (<-chan T, error) {
+	hook, err := newPrometheusHook(name, reg)
+	if err != nil {
+		return nil, err
+	}
+	return InstrumentHook(in, hook), nil
+}
+
+type prometheusHook struct {
+	received prometheus.Counter
+	sent     prometheus.Counter
+	inFlight prometheus.Gauge
+	stall    prometheus.Counter
+}
+
+func newPrometheusHook(name string, reg prometheus.Registerer) (*prometheusHook, error) {
+	received, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "channels_stage_received_total",
+		Help: "Total number of values received by the stage.",
+	}, []string{"stage"}))
+	if err != nil {
+		return nil, err
+	}
+	sent, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "channels_stage_sent_total",
+		Help: "Total number of values sent downstream by the stage.",
+	}, []string{"stage"}))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "channels_stage_in_flight",
+		Help: "Number of values received by the stage that have not been sent downstream.",
+	}, []string{"stage"}))
+	if err != nil {
+		return nil, err
+	}
+	stall, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "channels_stage_stall_seconds_total",
+		Help: "Total time the stage has spent waiting for downstream consumers.",
+	}, []string{"stage"}))
+	if err != nil {
+		return nil, err
+	}
+	return &prometheusHook{
+		received: received.WithLabelValues(name),
+		sent:     sent.WithLabelValues(name),
+		inFlight: inFlight.WithLabelValues(name),
+		stall:    stall.WithLabelValues(name),
+	}, nil
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered. Other registration errors, such as a collector with the same name
+// but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
+
+func (h *prometheusHook) Received() {
+	h.received.Inc()
+	h.inFlight.Inc()
+}
+
+func (h *prometheusHook) Sent(stall time.Duration) {
+	h.sent.Inc()
+	h.inFlight.Dec()
+	h.stall.Add(stall.Seconds())
+}
@@ -0,0 +1,54 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrument(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	foo, err := Instrument(generate(3), "foo", reg)
+	require.NoError(t, err)
+	bar, err := Instrument(generate(2), "bar", reg)
+	require.NoError(t, err)
+	require.Len(t, collect(foo), 3)
+	require.Len(t, collect(bar), 2)
+
+	expected := `
+# HELP channels_stage_in_flight Number of values received by the stage that have not been sent downstream.
+# TYPE channels_stage_in_flight gauge
+channels_stage_in_flight{stage="bar"} 0
+channels_stage_in_flight{stage="foo"} 0
+# HELP channels_stage_received_total Total number of values received by the stage.
+# TYPE channels_stage_received_total counter
+channels_stage_received_total{stage="bar"} 2
+channels_stage_received_total{stage="foo"} 3
+# HELP channels_stage_sent_total Total number of values sent downstream by the stage.
+# TYPE channels_stage_sent_total counter
+channels_stage_sent_total{stage="bar"} 2
+channels_stage_sent_total{stage="foo"} 3
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "channels_stage_in_flight", "channels_stage_received_total", "channels_stage_sent_total")
+	require.NoError(t, err)
+}
+
+func TestInstrumentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := Instrument(OrDone(ctx, make(chan int)), "foo", prometheus.NewRegistry())
+	require.NoError(t, err)
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestInstrumentRegistrationError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "channels_stage_sent_total", Help: "Other metric."}))
+	_, err := Instrument(make(chan int), "foo", reg)
+	require.ErrorContains(t, err, "could not register metrics")
+}
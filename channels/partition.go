@@ -0,0 +1,62 @@
+package channels
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// Partition distributes the values from in over n outputs by hashing the key of each value, so
+// that values with the same key are always sent to the same output in the order they were
+// received. The hash is stable across processes. A slow consumer blocks all outputs, as values
+// are distributed by a single goroutine to preserve ordering. All outputs are closed when in is
+// closed or ctx is cancelled.
+func Partition[T any, K comparable](ctx context.Context, in <-chan T, key func(T) K, n int) []<-chan T {
+	if n < 1 {
+		n = 1
+	}
+	outs := make([]chan T, n)
+	result := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		result[i] = outs[i]
+	}
+	spawn(ctx, func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			out := outs[hashKey(key(v))%uint64(n)]
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return result
+}
+
+func hashKey(key interface{}) uint64 {
+	h := fnv.New64a()
+	switch k := key.(type) {
+	case string:
+		h.Write([]byte(k))
+	case int:
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(k)))
+	case int64:
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(k)))
+	case uint64:
+		h.Write(binary.BigEndian.AppendUint64(nil, k))
+	default:
+		fmt.Fprintf(h, "%#v", key)
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,73 @@
+package channels
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartition(t *testing.T) {
+	type event struct {
+		Entity string
+		Seq    int
+	}
+	events := []event{}
+	for seq := 0; seq < 20; seq++ {
+		for _, entity := range []string{"a", "b", "c", "d", "e"} {
+			events = append(events, event{Entity: entity, Seq: seq})
+		}
+	}
+	outs := Partition(context.Background(), FromSlice(context.Background(), events), func(e event) string { return e.Entity }, 3)
+	require.Len(t, outs, 3)
+
+	mu := sync.Mutex{}
+	received := map[string][]int{}
+	partitions := map[string]int{}
+	wg := sync.WaitGroup{}
+	for i, out := range outs {
+		wg.Add(1)
+		go func(i int, out <-chan event) {
+			defer wg.Done()
+			for e := range out {
+				mu.Lock()
+				received[e.Entity] = append(received[e.Entity], e.Seq)
+				if p, ok := partitions[e.Entity]; ok && p != i {
+					t.Errorf("entity %s received on partitions %d and %d", e.Entity, p, i)
+				}
+				partitions[e.Entity] = i
+				mu.Unlock()
+			}
+		}(i, out)
+	}
+	wg.Wait()
+
+	for _, seqs := range received {
+		require.Len(t, seqs, 20)
+		for i, seq := range seqs {
+			require.Equal(t, i, seq)
+		}
+	}
+}
+
+func TestPartitionStable(t *testing.T) {
+	require.Equal(t, hashKey("foo"), hashKey("foo"))
+	require.Equal(t, hashKey(42), hashKey(42))
+	type key struct {
+		A string
+		B int
+	}
+	require.Equal(t, hashKey(key{"a", 1}), hashKey(key{"a", 1}))
+	require.NotEqual(t, hashKey(key{"a", 1}), hashKey(key{"a", 2}))
+}
+
+func TestPartitionCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	outs := Partition(ctx, make(chan int), func(v int) int { return v }, 2)
+	cancel()
+	for _, out := range outs {
+		_, ok := <-out
+		require.False(t, ok)
+	}
+}
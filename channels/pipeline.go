@@ -0,0 +1,223 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Pipeline owns the goroutines of a set of connected stages. Stages are added with Source,
+// MapStage, FilterStage, BatchStage, FanOutStage and Sink before calling Run. Draining the
+// pipeline stops the sources from reading input, after which every stage flushes the values
+// it has received and closes its output, so that no value which has been read is lost.
+type Pipeline struct {
+	mu       sync.Mutex
+	stages   []func(ctx context.Context) error
+	started  bool
+	draining chan struct{}
+	drain    sync.Once
+	done     chan struct{}
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		draining: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *Pipeline) addStage(stage func(ctx context.Context) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		panic("stages cannot be added to a pipeline which has already been started")
+	}
+	p.stages = append(p.stages, stage)
+}
+
+// Run starts all stages and blocks until they have completed. The first error returned by a
+// stage cancels the remaining stages and is returned. Cancelling ctx stops all stages without
+// flushing.
+func (p *Pipeline) Run(ctx context.Context) error {
+	p.mu.Lock()
+	if p.started {
+		p.mu.Unlock()
+		return errors.New("pipeline has already been started")
+	}
+	p.started = true
+	stages := p.stages
+	p.mu.Unlock()
+	defer close(p.done)
+
+	g, _ := WithGroup(ctx)
+	for _, stage := range stages {
+		g.Go(stage)
+	}
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Drain stops the sources from reading input and waits for all values to flow through the
+// pipeline. Drain returns when Run has returned or when ctx is cancelled.
+func (p *Pipeline) Drain(ctx context.Context) error {
+	p.drain.Do(func() {
+		close(p.draining)
+	})
+	p.mu.Lock()
+	started := p.started
+	p.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start is an alias for Run, so that a pipeline can be used like the service components.
+func (p *Pipeline) Start(ctx context.Context) error {
+	return p.Run(ctx)
+}
+
+// Stop is an alias for Drain, so that a pipeline can be used like the service components.
+func (p *Pipeline) Stop(ctx context.Context) error {
+	return p.Drain(ctx)
+}
+
+// Source reads values from in until it is closed or the pipeline is drained.
+func Source[T any](p *Pipeline, in <-chan T) <-chan T {
+	out := make(chan T)
+	p.addStage(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			select {
+			case <-p.draining:
+				return nil
+			case <-ctx.Done():
+				return nil
+			case v, ok := <-in:
+				if !ok {
+					return nil
+				}
+				if !send(ctx, out, v) {
+					return nil
+				}
+			}
+		}
+	})
+	return out
+}
+
+// MapStage applies mapFunc to every value using the given number of workers. The order of
+// values is only preserved with a single worker. An error from mapFunc fails the pipeline.
+func MapStage[T any, U any](p *Pipeline, in <-chan T, workers int, mapFunc func(context.Context, T) (U, error)) <-chan U {
+	if workers < 1 {
+		workers = 1
+	}
+	out := make(chan U)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		p.addStage(func(ctx context.Context) error {
+			defer wg.Done()
+			for v := range in {
+				u, err := mapFunc(ctx, v)
+				if err != nil {
+					return err
+				}
+				if !send(ctx, out, u) {
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	p.addStage(func(ctx context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// FilterStage forwards the values for which filterFunc returns true.
+func FilterStage[T any](p *Pipeline, in <-chan T, filterFunc func(T) bool) <-chan T {
+	out := make(chan T)
+	p.addStage(func(ctx context.Context) error {
+		defer close(out)
+		for v := range in {
+			if !filterFunc(v) {
+				continue
+			}
+			if !send(ctx, out, v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// BatchStage groups values the same way as Batch, flushing the partial batch when draining.
+func BatchStage[T any](p *Pipeline, in <-chan T, maxSize int, maxWait time.Duration) <-chan []T {
+	out := make(chan []T)
+	p.addStage(func(ctx context.Context) error {
+		defer close(out)
+		for batch := range Batch(ctx, in, maxSize, maxWait) {
+			if !send(ctx, out, batch) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// FanOutStage distributes the values over n outputs, each value is sent to one output.
+func FanOutStage[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, n)
+	for i := 0; i < n; i++ {
+		out := make(chan T)
+		outs[i] = out
+		p.addStage(func(ctx context.Context) error {
+			defer close(out)
+			for v := range in {
+				if !send(ctx, out, v) {
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	return outs
+}
+
+// Sink calls sinkFunc for every value, an error from sinkFunc fails the pipeline.
+func Sink[T any](p *Pipeline, in <-chan T, sinkFunc func(context.Context, T) error) {
+	p.addStage(func(ctx context.Context) error {
+		for v := range in {
+			err := sinkFunc(ctx, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
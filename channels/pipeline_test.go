@@ -0,0 +1,125 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	p := NewPipeline()
+	in := Source(p, FromSlice(ctx, []int{1, 2, 3, 4, 5, 6}))
+	even := FilterStage(p, in, func(v int) bool {
+		return v%2 == 0
+	})
+	strs := MapStage(p, even, 2, func(ctx context.Context, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	})
+	mu := sync.Mutex{}
+	result := []string{}
+	for _, out := range FanOutStage(p, strs, 2) {
+		Sink(p, out, func(ctx context.Context, v string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, v)
+			return nil
+		})
+	}
+
+	err := p.Run(ctx)
+	require.NoError(t, err)
+	sort.Strings(result)
+	require.Equal(t, []string{"2", "4", "6"}, result)
+}
+
+func TestPipelineDrain(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan int)
+	p := NewPipeline()
+	batches := BatchStage(p, Source(p, in), 10, time.Hour)
+	result := make(chan []int, 1)
+	Sink(p, batches, func(ctx context.Context, v []int) error {
+		result <- v
+		return nil
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- p.Run(ctx)
+	}()
+	in <- 1
+	in <- 2
+	// The partial batch is flushed even though the input is never closed.
+	err := p.Drain(ctx)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, []int{1, 2}, <-result)
+}
+
+func TestPipelineError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := NewPipeline()
+	in := Source(p, Repeat(ctx, 1))
+	Sink(p, in, func(ctx context.Context, v int) error {
+		return errors.New("sink failed")
+	})
+
+	err := p.Run(ctx)
+	require.EqualError(t, err, "sink failed")
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPipeline()
+	in := Source(p, make(chan int))
+	Sink(p, in, func(ctx context.Context, v int) error {
+		return nil
+	})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- p.Run(ctx)
+	}()
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestPipelineDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	p := NewPipeline()
+	in := make(chan int, 1)
+	in <- 1
+	Sink(p, Source(p, in), func(ctx context.Context, v int) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go p.Run(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := p.Drain(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPipelineRunTwice(t *testing.T) {
+	p := NewPipeline()
+	err := p.Run(context.Background())
+	require.NoError(t, err)
+	err = p.Run(context.Background())
+	require.EqualError(t, err, "pipeline has already been started")
+	require.Panics(t, func() {
+		Source(p, make(chan int))
+	})
+}
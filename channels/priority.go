@@ -0,0 +1,75 @@
+package channels
+
+import (
+	"context"
+	"reflect"
+)
+
+// MergePriority merges values from multiple channels into one, where channels are passed in
+// order of descending priority. When multiple channels have values ready the one with the
+// highest priority is always received from first, so low priority channels can not starve
+// high priority ones.
+func MergePriority[T any](ctx context.Context, cs ...<-chan T) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		cs := append([]<-chan T{}, cs...)
+		open := len(cs)
+		cases := make([]reflect.SelectCase, len(cs)+1)
+		cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+		for i, c := range cs {
+			cases[i+1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)}
+		}
+
+		receive := func() (T, bool) {
+			// Check each channel in priority order without blocking.
+			for i, c := range cs {
+				if c == nil {
+					continue
+				}
+				select {
+				case v, ok := <-c:
+					if !ok {
+						cs[i] = nil
+						cases[i+1].Chan = reflect.Value{}
+						open--
+						continue
+					}
+					return v, true
+				default:
+				}
+			}
+
+			// Block until any of the channels is ready.
+			for open > 0 {
+				chosen, recv, ok := reflect.Select(cases)
+				if chosen == 0 {
+					break
+				}
+				if !ok {
+					cs[chosen-1] = nil
+					cases[chosen].Chan = reflect.Value{}
+					open--
+					continue
+				}
+				return recv.Interface().(T), true
+			}
+			var zero T
+			return zero, false
+		}
+
+		for open > 0 {
+			v, ok := receive()
+			if !ok {
+				return
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,44 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePriority(t *testing.T) {
+	high := make(chan string, 3)
+	low := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		low <- "low"
+		high <- "high"
+	}
+	close(high)
+	close(low)
+	values := collect(MergePriority(context.Background(), high, low))
+	require.Equal(t, []string{"high", "high", "high", "low", "low", "low"}, values)
+}
+
+func TestMergePriorityBlocking(t *testing.T) {
+	high := make(chan string)
+	low := make(chan string)
+	out := MergePriority(context.Background(), high, low)
+	go func() {
+		low <- "low"
+		time.Sleep(10 * time.Millisecond)
+		high <- "high"
+		close(low)
+		close(high)
+	}()
+	require.Equal(t, []string{"low", "high"}, collect(out))
+}
+
+func TestMergePriorityCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := MergePriority(ctx, make(chan int), make(chan int))
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
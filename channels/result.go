@@ -0,0 +1,110 @@
+package channels
+
+import (
+	"context"
+	"errors"
+)
+
+// Result carries either a value or the error produced while computing it.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// MapErr applies mapFunc to every value from in and emits the outcome as a Result.
+func MapErr[T any, U any](ctx context.Context, in <-chan T, mapFunc func(T) (U, error)) <-chan Result[U] {
+	out := make(chan Result[U])
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			u, err := mapFunc(v)
+			select {
+			case out <- Result[U]{Value: u, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// FilterErr forwards values for which filterFunc returns true. Errors returned by
+// filterFunc are forwarded as a Result together with the value that caused them.
+func FilterErr[T any](ctx context.Context, in <-chan T, filterFunc func(T) (bool, error)) <-chan Result[T] {
+	out := make(chan Result[T])
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			ok, err := filterFunc(v)
+			if !ok && err == nil {
+				continue
+			}
+			select {
+			case out <- Result[T]{Value: v, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// CollectResults reads all results from in until it is closed, returning the successful
+// values and all errors joined together.
+func CollectResults[T any](ctx context.Context, in <-chan Result[T]) ([]T, error) {
+	values := []T{}
+	errs := []error{}
+	for {
+		r, err := First(ctx, in)
+		if errors.Is(err, ErrClosed) {
+			return values, errors.Join(errs...)
+		}
+		if err != nil {
+			return values, errors.Join(append(errs, err)...)
+		}
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+			continue
+		}
+		values = append(values, r.Value)
+	}
+}
+
+// SplitErrors splits in into a value and an error channel. Both channels have to be
+// consumed as a send on one blocks the other.
+func SplitErrors[T any](ctx context.Context, in <-chan Result[T]) (<-chan T, <-chan error) {
+	valueOut := make(chan T)
+	errOut := make(chan error)
+	spawn(ctx, func() {
+		defer close(valueOut)
+		defer close(errOut)
+		for {
+			r, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			if r.Err != nil {
+				select {
+				case errOut <- r.Err:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			select {
+			case valueOut <- r.Value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return valueOut, errOut
+}
@@ -0,0 +1,55 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapErrCollectResults(t *testing.T) {
+	results := MapErr(context.Background(), generate(4), func(v int) (string, error) {
+		if v%2 == 1 {
+			return "", fmt.Errorf("odd value %d", v)
+		}
+		return fmt.Sprint(v), nil
+	})
+	values, err := CollectResults(context.Background(), results)
+	require.Equal(t, []string{"0", "2"}, values)
+	require.EqualError(t, err, "odd value 1\nodd value 3")
+}
+
+func TestFilterErr(t *testing.T) {
+	results := FilterErr(context.Background(), generate(6), func(v int) (bool, error) {
+		if v == 5 {
+			return false, fmt.Errorf("unexpected value")
+		}
+		return v%2 == 0, nil
+	})
+	require.Equal(t, []Result[int]{{Value: 0}, {Value: 2}, {Value: 4}, {Value: 5, Err: fmt.Errorf("unexpected value")}}, collect(results))
+}
+
+func TestSplitErrors(t *testing.T) {
+	in := make(chan Result[int])
+	go func() {
+		defer close(in)
+		in <- Result[int]{Value: 1}
+		in <- Result[int]{Err: fmt.Errorf("foo")}
+		in <- Result[int]{Value: 2}
+	}()
+	valueCh, errCh := SplitErrors(context.Background(), in)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	errs := []error{}
+	go func() {
+		defer wg.Done()
+		errs = collect(errCh)
+	}()
+	values := collect(valueCh)
+	wg.Wait()
+	require.Equal(t, []int{1, 2}, values)
+	require.Equal(t, []error{fmt.Errorf("foo")}, errs)
+}
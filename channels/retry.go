@@ -0,0 +1,45 @@
+package channels
+
+import (
+	"context"
+
+	"github.com/xenitab/pkg/retry"
+)
+
+// RetryMap applies mapFunc to every value from in, retrying failures according to policy.
+// Errors which are not retryable or remain after all attempts are sent to the error channel.
+// Both channels have to be consumed as a send on one blocks the other.
+func RetryMap[T any, U any](ctx context.Context, in <-chan T, mapFunc func(context.Context, T) (U, error), policy retry.Policy) (<-chan U, <-chan error) {
+	out := make(chan U)
+	errOut := make(chan error)
+	spawn(ctx, func() {
+		defer close(out)
+		defer close(errOut)
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			u, err := retry.DoValue(ctx, policy, func(ctx context.Context) (U, error) {
+				return mapFunc(ctx, v)
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case errOut <- err:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			select {
+			case out <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out, errOut
+}
@@ -0,0 +1,57 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/retry"
+)
+
+func TestRetryMap(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	attempts := map[int]int{}
+	policy := retry.Policy{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		Multiplier:      2,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, errPermanent)
+		},
+	}
+	mapFunc := func(_ context.Context, v int) (string, error) {
+		attempts[v]++
+		switch v {
+		case 0:
+			if attempts[v] < 3 {
+				return "", fmt.Errorf("transient")
+			}
+			return "zero", nil
+		case 1:
+			return "", fmt.Errorf("always")
+		default:
+			return "", errPermanent
+		}
+	}
+	out, errCh := RetryMap(context.Background(), generate(3), mapFunc, policy)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	errs := []error{}
+	go func() {
+		defer wg.Done()
+		errs = collect(errCh)
+	}()
+	values := collect(out)
+	wg.Wait()
+
+	require.Equal(t, []string{"zero"}, values)
+	require.Len(t, errs, 2)
+	require.EqualError(t, errs[0], "always")
+	require.ErrorIs(t, errs[1], errPermanent)
+	require.Equal(t, map[int]int{0: 3, 1: 3, 2: 1}, attempts)
+}
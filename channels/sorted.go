@@ -0,0 +1,67 @@
+package channels
+
+import (
+	"container/heap"
+)
+
+// MergeSorted merges channels whose values are each sorted according to less into a single
+// sorted channel. A value is only emitted once every open input has a value available, as any
+// of them could hold the next smallest value, so a slow input holds back the output.
+func MergeSorted[T any](less func(a, b T) bool, cs ...<-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		h := &sortedHeap[T]{less: less}
+		for _, c := range cs {
+			if v, ok := <-c; ok {
+				h.items = append(h.items, sortedItem[T]{value: v, c: c})
+			}
+		}
+		heap.Init(h)
+		for h.Len() > 0 {
+			item := h.items[0]
+			out <- item.value
+			v, ok := <-item.c
+			if !ok {
+				heap.Pop(h)
+				continue
+			}
+			h.items[0].value = v
+			heap.Fix(h, 0)
+		}
+	}()
+	return out
+}
+
+type sortedItem[T any] struct {
+	value T
+	c     <-chan T
+}
+
+type sortedHeap[T any] struct {
+	items []sortedItem[T]
+	less  func(a, b T) bool
+}
+
+func (h *sortedHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *sortedHeap[T]) Less(i, j int) bool {
+	return h.less(h.items[i].value, h.items[j].value)
+}
+
+func (h *sortedHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *sortedHeap[T]) Push(x any) {
+	h.items = append(h.items, x.(sortedItem[T]))
+}
+
+func (h *sortedHeap[T]) Pop() any {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items = h.items[:n-1]
+	return item
+}
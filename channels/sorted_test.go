@@ -0,0 +1,50 @@
+package channels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSorted(t *testing.T) {
+	ctx := context.Background()
+	less := func(a, b int) bool {
+		return a < b
+	}
+	out := MergeSorted(less,
+		FromSlice(ctx, []int{1, 4, 7, 10}),
+		FromSlice(ctx, []int{2, 5, 8}),
+		FromSlice(ctx, []int{}),
+		FromSlice(ctx, []int{0, 3, 6, 9, 11, 12}),
+	)
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, ToSlice(ctx, out))
+}
+
+func TestMergeSortedEqualKeys(t *testing.T) {
+	type event struct {
+		time      int
+		partition string
+	}
+	ctx := context.Background()
+	less := func(a, b event) bool {
+		return a.time < b.time
+	}
+	out := MergeSorted(less,
+		FromSlice(ctx, []event{{1, "a"}, {3, "a"}}),
+		FromSlice(ctx, []event{{2, "b"}, {3, "b"}}),
+	)
+	result := ToSlice(ctx, out)
+	require.Len(t, result, 4)
+	for i := 1; i < len(result); i++ {
+		require.LessOrEqual(t, result[i-1].time, result[i].time)
+	}
+}
+
+func TestMergeSortedNoInputs(t *testing.T) {
+	out := MergeSorted(func(a, b int) bool {
+		return a < b
+	})
+	_, ok := <-out
+	require.False(t, ok)
+}
@@ -0,0 +1,112 @@
+package channels
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed is returned when a channel is closed before a value could be received.
+var ErrClosed = errors.New("channel closed")
+
+// OrDone forwards values from in until either in is closed or ctx is cancelled.
+func OrDone[T any](ctx context.Context, in <-chan T) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+	return out
+}
+
+// Take forwards the first n values from in and then closes the output channel.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		for i := 0; i < n; i++ {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// TakeWhile forwards values from in for as long as predicate returns true.
+func TakeWhile[T any](ctx context.Context, in <-chan T, predicate func(T) bool) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			v, err := First(ctx, in)
+			if err != nil || !predicate(v) {
+				return
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// Skip discards the first n values from in and forwards the rest.
+func Skip[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		for i := 0; ; i++ {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+			if i < n {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// First returns the next value received from in. ErrClosed is returned if in is
+// closed before a value is received.
+func First[T any](ctx context.Context, in <-chan T) (T, error) {
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case v, ok := <-in:
+		if !ok {
+			var zero T
+			return zero, ErrClosed
+		}
+		return v, nil
+	}
+}
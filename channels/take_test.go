@@ -0,0 +1,56 @@
+package channels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func generate(n int) <-chan int {
+	values := []int{}
+	for i := 0; i < n; i++ {
+		values = append(values, i)
+	}
+	return FromSlice(context.Background(), values)
+}
+
+func collect[T any](in <-chan T) []T {
+	return ToSlice(context.Background(), in)
+}
+
+func TestOrDone(t *testing.T) {
+	require.Equal(t, []int{0, 1, 2}, collect(OrDone(context.Background(), generate(3))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Empty(t, collect(OrDone(ctx, make(chan int))))
+}
+
+func TestTake(t *testing.T) {
+	require.Equal(t, []int{0, 1}, collect(Take(context.Background(), generate(5), 2)))
+	require.Equal(t, []int{0, 1}, collect(Take(context.Background(), generate(2), 5)))
+}
+
+func TestTakeWhile(t *testing.T) {
+	out := TakeWhile(context.Background(), generate(5), func(v int) bool { return v < 3 })
+	require.Equal(t, []int{0, 1, 2}, collect(out))
+}
+
+func TestSkip(t *testing.T) {
+	require.Equal(t, []int{3, 4}, collect(Skip(context.Background(), generate(5), 3)))
+}
+
+func TestFirst(t *testing.T) {
+	v, err := First(context.Background(), generate(5))
+	require.NoError(t, err)
+	require.Equal(t, 0, v)
+
+	_, err = First(context.Background(), generate(0))
+	require.ErrorIs(t, err, ErrClosed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = First(ctx, make(chan int))
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,106 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// Throttle forwards values from in at a rate of at most rate values per duration,
+// spacing them evenly over the period.
+func Throttle[T any](ctx context.Context, in <-chan T, rate int, per time.Duration) <-chan T {
+	return ThrottleBurst(ctx, in, rate, per, 1)
+}
+
+// ThrottleBurst forwards values from in using a token bucket which is refilled with rate
+// tokens per duration and holds at most burst tokens. A non positive rate disables throttling.
+func ThrottleBurst[T any](ctx context.Context, in <-chan T, rate int, per time.Duration, burst int) <-chan T {
+	var interval time.Duration
+	if rate > 0 {
+		interval = per / time.Duration(rate)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		// Theoretical arrival time of the next value, values are allowed to arrive
+		// up to burst intervals early.
+		tat := time.Now()
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+
+			now := time.Now()
+			if tat.Before(now) {
+				tat = now
+			}
+			wait := tat.Add(-time.Duration(burst-1) * interval).Sub(now)
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+			}
+			tat = tat.Add(interval)
+
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// Waiter blocks until an event for the key is allowed or ctx is cancelled, such as a limiter of
+// the ratelimit module shared with other pipelines or replicas.
+type Waiter interface {
+	Wait(ctx context.Context, key string) error
+}
+
+// WaiterFunc allows a function to be used as a Waiter, such as one calling ratelimit.Wait.
+type WaiterFunc func(ctx context.Context, key string) error
+
+func (f WaiterFunc) Wait(ctx context.Context, key string) error {
+	return f(ctx, key)
+}
+
+// ThrottleLimiter forwards values from in once the waiter allows an event for the key, so that
+// a limit can be shared with other pipelines or replicas. Values are forwarded without waiting
+// if the waiter returns an error, so that an unavailable limiter does not stop the pipeline. A
+// nil waiter disables throttling.
+func ThrottleLimiter[T any](ctx context.Context, in <-chan T, waiter Waiter, key string) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				return
+			}
+
+			if waiter != nil {
+				if err := waiter.Wait(ctx, key); err != nil && ctx.Err() != nil {
+					return
+				}
+			}
+
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
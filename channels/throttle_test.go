@@ -0,0 +1,99 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	start := time.Now()
+	values := collect(Throttle(context.Background(), generate(5), 10, 200*time.Millisecond))
+	require.Equal(t, []int{0, 1, 2, 3, 4}, values)
+	require.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestThrottleBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := ThrottleBurst(ctx, generate(10), 1, time.Hour, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.Equal(t, i, <-out)
+	}
+	require.Less(t, time.Since(start), time.Second)
+
+	select {
+	case <-out:
+		t.Fatal("expected value to be throttled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+// budgetWaiter allows a fixed number of events and then blocks until ctx is cancelled.
+type budgetWaiter struct {
+	mu     sync.Mutex
+	budget int
+	keys   []string
+}
+
+func (w *budgetWaiter) Wait(ctx context.Context, key string) error {
+	w.mu.Lock()
+	w.keys = append(w.keys, key)
+	if w.budget > 0 {
+		w.budget--
+		w.mu.Unlock()
+		return nil
+	}
+	w.mu.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestThrottleLimiter(t *testing.T) {
+	waiter := &budgetWaiter{budget: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Both pipelines share the budget of the waiter, which pipeline gets it is not deterministic.
+	first := ThrottleLimiter(ctx, generate(10), waiter, "shared")
+	second := ThrottleLimiter(ctx, generate(10), waiter, "shared")
+	for i := 0; i < 2; i++ {
+		select {
+		case <-first:
+		case <-second:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected value to be forwarded")
+		}
+	}
+
+	select {
+	case <-first:
+		t.Fatal("expected value to be throttled")
+	case <-second:
+		t.Fatal("expected value to be throttled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	for range first {
+	}
+	for range second {
+	}
+	for _, key := range waiter.keys {
+		require.Equal(t, "shared", key)
+	}
+}
+
+func TestThrottleLimiterError(t *testing.T) {
+	waiter := WaiterFunc(func(context.Context, string) error {
+		return errors.New("unavailable")
+	})
+	values := collect(ThrottleLimiter(context.Background(), generate(3), waiter, ""))
+	require.Equal(t, []int{0, 1, 2}, values)
+}
@@ -0,0 +1,89 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout is emitted by WithTimeoutErr when no value is received within the timeout.
+var ErrTimeout = errors.New("no value received within timeout")
+
+// WithTimeout forwards values from in and calls onTimeout every time no value has been
+// received for the duration d, for example to detect a stalled producer. The timeout
+// is restarted after each value has been forwarded and after each call to onTimeout.
+func WithTimeout[T any](ctx context.Context, in <-chan T, d time.Duration, onTimeout func()) <-chan T {
+	out := make(chan T)
+	spawn(ctx, func() {
+		defer close(out)
+		forwardWithTimeout(ctx, in, d, func(v T) bool {
+			select {
+			case out <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}, func() bool {
+			if onTimeout != nil {
+				onTimeout()
+			}
+			return true
+		})
+	})
+	return out
+}
+
+// WithTimeoutErr forwards values from in as results and emits a result with ErrTimeout
+// every time no value has been received for the duration d.
+func WithTimeoutErr[T any](ctx context.Context, in <-chan T, d time.Duration) <-chan Result[T] {
+	out := make(chan Result[T])
+	spawn(ctx, func() {
+		defer close(out)
+		send := func(r Result[T]) bool {
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		forwardWithTimeout(ctx, in, d, func(v T) bool {
+			return send(Result[T]{Value: v})
+		}, func() bool {
+			return send(Result[T]{Err: ErrTimeout})
+		})
+	})
+	return out
+}
+
+// forwardWithTimeout reads from in until it is closed, ctx is cancelled or one of the
+// callbacks returns false.
+func forwardWithTimeout[T any](ctx context.Context, in <-chan T, d time.Duration, onValue func(T) bool, onTimeout func() bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			if !onValue(v) {
+				return
+			}
+		case <-timer.C:
+			if !onTimeout() {
+				return
+			}
+		}
+		// The timer has either fired or has to be stopped and drained before it is reset.
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d)
+	}
+}
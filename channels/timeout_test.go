@@ -0,0 +1,53 @@
+package channels
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTimeout(t *testing.T) {
+	in := make(chan int)
+	timeouts := int32(0)
+	out := WithTimeout(context.Background(), in, 20*time.Millisecond, func() {
+		atomic.AddInt32(&timeouts, 1)
+	})
+
+	in <- 1
+	require.Equal(t, 1, <-out)
+	require.Equal(t, int32(0), atomic.LoadInt32(&timeouts))
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&timeouts) >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	in <- 2
+	require.Equal(t, 2, <-out)
+	close(in)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestWithTimeoutErr(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan int)
+	out := WithTimeoutErr(ctx, in, 10*time.Millisecond)
+
+	go func() {
+		in <- 1
+	}()
+	r := <-out
+	require.NoError(t, r.Err)
+	require.Equal(t, 1, r.Value)
+
+	r = <-out
+	require.ErrorIs(t, r.Err, ErrTimeout)
+
+	cancel()
+	for range out {
+	}
+}
@@ -0,0 +1,102 @@
+package channels
+
+import (
+	"context"
+	"time"
+)
+
+// TumblingWindow groups values from in into consecutive non-overlapping windows of duration d.
+// The values received during a window are emitted when it ends, windows without values are not
+// emitted. Any partial window is flushed when in is closed.
+func TumblingWindow[T any](ctx context.Context, in <-chan T, d time.Duration) <-chan []T {
+	out := make(chan []T)
+	spawn(ctx, func() {
+		defer close(out)
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		var window []T
+		flush := func() bool {
+			if len(window) == 0 {
+				return true
+			}
+			select {
+			case out <- window:
+				window = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				window = append(window, v)
+			case <-ticker.C:
+				if !flush() {
+					return
+				}
+			}
+		}
+	})
+	return out
+}
+
+// SlidingWindow emits the last size values from in every step values, once size values have been
+// received. Windows overlap when step is smaller than size and values are skipped when step is
+// larger. When in is closed the last window is emitted if values have been received since the
+// previous window, which may contain fewer than size values.
+func SlidingWindow[T any](ctx context.Context, in <-chan T, size, step int) <-chan []T {
+	out := make(chan []T)
+	spawn(ctx, func() {
+		defer close(out)
+		if size < 1 || step < 1 {
+			return
+		}
+
+		window := make([]T, 0, size)
+		// pending is the number of values received since the last emitted window.
+		pending := 0
+		emitted := false
+		emit := func() bool {
+			w := make([]T, len(window))
+			copy(w, window)
+			select {
+			case out <- w:
+				pending = 0
+				emitted = true
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			v, err := First(ctx, in)
+			if err != nil {
+				if ctx.Err() == nil && pending > 0 {
+					emit()
+				}
+				return
+			}
+			if len(window) == size {
+				window = append(window[:0], window[1:]...)
+			}
+			window = append(window, v)
+			pending++
+			if len(window) == size && (!emitted || pending >= step) {
+				if !emit() {
+					return
+				}
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,99 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTumblingWindow(t *testing.T) {
+	in := make(chan int, 2)
+	out := TumblingWindow(context.Background(), in, 20*time.Millisecond)
+	in <- 1
+	in <- 2
+	// The window may end between the two values.
+	values := <-out
+	if len(values) == 1 {
+		values = append(values, <-out...)
+	}
+	require.Equal(t, []int{1, 2}, values)
+	in <- 3
+	close(in)
+	require.Equal(t, []int{3}, <-out)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestTumblingWindowCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := TumblingWindow(ctx, in, time.Hour)
+	in <- 1
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		size     int
+		step     int
+		expected [][]int
+	}{
+		{
+			name:     "overlapping",
+			n:        5,
+			size:     3,
+			step:     1,
+			expected: [][]int{{0, 1, 2}, {1, 2, 3}, {2, 3, 4}},
+		},
+		{
+			name:     "flush remaining",
+			n:        6,
+			size:     3,
+			step:     2,
+			expected: [][]int{{0, 1, 2}, {2, 3, 4}, {3, 4, 5}},
+		},
+		{
+			name:     "skipping",
+			n:        7,
+			size:     2,
+			step:     3,
+			expected: [][]int{{0, 1}, {3, 4}, {5, 6}},
+		},
+		{
+			name:     "fewer than size",
+			n:        2,
+			size:     3,
+			step:     1,
+			expected: [][]int{{0, 1}},
+		},
+		{
+			name:     "empty",
+			n:        0,
+			size:     3,
+			step:     1,
+			expected: [][]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := SlidingWindow(context.Background(), generate(tt.n), tt.size, tt.step)
+			require.Equal(t, tt.expected, collect(out))
+		})
+	}
+}
+
+func TestSlidingWindowCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := SlidingWindow(ctx, in, 3, 1)
+	in <- 1
+	cancel()
+	_, ok := <-out
+	require.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package channels
+
+import (
+	"context"
+)
+
+type Pair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip pairs up values from a and b in the order they are received. The output channel
+// is closed as soon as either of the inputs is closed.
+func Zip[A any, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	spawn(ctx, func() {
+		defer close(out)
+		for {
+			av, err := First(ctx, a)
+			if err != nil {
+				return
+			}
+			bv, err := First(ctx, b)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- Pair[A, B]{First: av, Second: bv}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
+
+// CombineLatest emits the latest values from a and b every time either of them receives
+// a value, once both have received at least one value. The output channel is closed when
+// both inputs are closed or when an input is closed without having received any value.
+func CombineLatest[A any, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	spawn(ctx, func() {
+		defer close(out)
+
+		var latest Pair[A, B]
+		var hasA, hasB bool
+		for a != nil || b != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-a:
+				if !ok {
+					if !hasA {
+						return
+					}
+					a = nil
+					continue
+				}
+				latest.First = v
+				hasA = true
+			case v, ok := <-b:
+				if !ok {
+					if !hasB {
+						return
+					}
+					b = nil
+					continue
+				}
+				latest.Second = v
+				hasB = true
+			}
+			if !hasA || !hasB {
+				continue
+			}
+			select {
+			case out <- latest:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,44 @@
+package channels
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZip(t *testing.T) {
+	a := FromSlice(context.Background(), []int{1, 2, 3})
+	b := FromSlice(context.Background(), []string{"foo", "bar"})
+	pairs := collect(Zip(context.Background(), a, b))
+	require.Equal(t, []Pair[int, string]{{1, "foo"}, {2, "bar"}}, pairs)
+}
+
+func TestCombineLatest(t *testing.T) {
+	a := make(chan int)
+	b := make(chan string)
+	out := CombineLatest(context.Background(), a, b)
+
+	a <- 1
+	a <- 2
+	b <- "foo"
+	require.Equal(t, Pair[int, string]{2, "foo"}, <-out)
+	b <- "bar"
+	require.Equal(t, Pair[int, string]{2, "bar"}, <-out)
+	close(b)
+	a <- 3
+	require.Equal(t, Pair[int, string]{3, "bar"}, <-out)
+	close(a)
+	_, ok := <-out
+	require.False(t, ok)
+}
+
+func TestCombineLatestClosedWithoutValue(t *testing.T) {
+	a := make(chan int)
+	b := make(chan string)
+	out := CombineLatest(context.Background(), a, b)
+	a <- 1
+	close(b)
+	_, ok := <-out
+	require.False(t, ok)
+}
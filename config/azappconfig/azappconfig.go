@@ -0,0 +1,252 @@
+package azappconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const (
+	apiVersion = "1.0"
+	// NoLabel filters key-values without a label.
+	NoLabel = "\x00"
+	// KeyVaultRefContentType is the content type of key-values referencing a Key Vault secret.
+	KeyVaultRefContentType = "application/vnd.microsoft.appconfig.keyvaultref+json"
+)
+
+// KeyVaultResolver returns the value of a Key Vault secret, it is implemented by secrets.KeyVault.
+type KeyVaultResolver interface {
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// KeyVaultResolverFunc allows a function to be used as a KeyVaultResolver.
+type KeyVaultResolverFunc func(ctx context.Context, path string) (string, error)
+
+func (f KeyVaultResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+type Config struct {
+	// Endpoint of the App Configuration store, for example https://my-store.azconfig.io.
+	Endpoint string
+	// Credential used to authenticate with App Configuration.
+	Credential azcore.TokenCredential
+	// Only keys starting with the prefix are read, the prefix is removed from the keys.
+	KeyPrefix string
+	// Separator of key segments, replaced with underscores so that App:Http:Port becomes app_http_port.
+	KeySeparator string
+	// Labels to read in order of increasing precedence, used to layer environment specific values
+	// on top of shared values, for example []string{NoLabel, "production"}.
+	Labels []string
+	// Resolves Key Vault references, where the path is vault/name or vault/name/version. Key
+	// Vault references cause an error if nil.
+	KeyVault KeyVaultResolver
+	// Transport used for requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Endpoint:     "",
+		Credential:   nil,
+		KeyPrefix:    "",
+		KeySeparator: ":",
+		Labels:       []string{NoLabel},
+		KeyVault:     nil,
+		Transport:    nil,
+	}
+}
+
+// Provider reads configuration values from Azure App Configuration and implements config.Provider.
+// Changes are picked up by the config.Watcher, which polls all providers at its interval.
+type Provider struct {
+	cfg      Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewProvider(cfg Config) (*Provider, error) {
+	if cfg.Credential == nil {
+		return nil, errors.New("credential cannot be nil")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint %s has to be an absolute url", cfg.Endpoint)
+	}
+	if len(cfg.Labels) == 0 {
+		return nil, errors.New("labels cannot be empty")
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Provider{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client: &http.Client{
+			Transport: &bearerTransport{
+				cred:  cfg.Credential,
+				scope: fmt.Sprintf("%s://%s/.default", endpoint.Scheme, endpoint.Host),
+				base:  transport,
+			},
+		},
+	}, nil
+}
+
+type keyValue struct {
+	Key         string `json:"key"`
+	Label       string `json:"label"`
+	Value       string `json:"value"`
+	ContentType string `json:"content_type"`
+}
+
+func (p *Provider) Values(ctx context.Context) (map[string]string, error) {
+	values := map[string]string{}
+	for _, label := range p.cfg.Labels {
+		kvs, err := p.list(ctx, label)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			value, err := p.value(ctx, kv)
+			if err != nil {
+				return nil, err
+			}
+			values[p.key(kv.Key)] = value
+		}
+	}
+	return values, nil
+}
+
+func (p *Provider) key(key string) string {
+	key = strings.TrimPrefix(key, p.cfg.KeyPrefix)
+	if p.cfg.KeySeparator != "" {
+		key = strings.ReplaceAll(key, p.cfg.KeySeparator, "_")
+	}
+	return strings.ToLower(key)
+}
+
+func (p *Provider) value(ctx context.Context, kv keyValue) (string, error) {
+	if !strings.HasPrefix(kv.ContentType, KeyVaultRefContentType) {
+		return kv.Value, nil
+	}
+	if p.cfg.KeyVault == nil {
+		return "", fmt.Errorf("key %s references key vault but no key vault resolver is configured", kv.Key)
+	}
+	ref := struct {
+		URI string `json:"uri"`
+	}{}
+	err := json.Unmarshal([]byte(kv.Value), &ref)
+	if err != nil {
+		return "", fmt.Errorf("could not decode key vault reference of key %s: %w", kv.Key, err)
+	}
+	path, err := keyVaultPath(ref.URI)
+	if err != nil {
+		return "", fmt.Errorf("invalid key vault reference of key %s: %w", kv.Key, err)
+	}
+	value, err := p.cfg.KeyVault.Resolve(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve key vault reference of key %s: %w", kv.Key, err)
+	}
+	return value, nil
+}
+
+// keyVaultPath converts a secret uri such as https://my-vault.vault.azure.net/secrets/name/version
+// to the path my-vault/name/version.
+func keyVaultPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	vault, _, _ := strings.Cut(u.Hostname(), ".")
+	name, ok := strings.CutPrefix(strings.Trim(u.Path, "/"), "secrets/")
+	if vault == "" || !ok || name == "" {
+		return "", fmt.Errorf("%s is not a key vault secret uri", uri)
+	}
+	return vault + "/" + name, nil
+}
+
+func (p *Provider) list(ctx context.Context, label string) ([]keyValue, error) {
+	query := url.Values{
+		"key":         []string{p.cfg.KeyPrefix + "*"},
+		"label":       []string{label},
+		"api-version": []string{apiVersion},
+	}
+	next := p.endpoint.JoinPath("kv").String() + "?" + query.Encode()
+	kvs := []keyValue{}
+	for next != "" {
+		body, err := p.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, body.Items...)
+		next = ""
+		if body.NextLink != "" {
+			u, err := p.endpoint.Parse(body.NextLink)
+			if err != nil {
+				return nil, fmt.Errorf("invalid next link: %w", err)
+			}
+			next = u.String()
+		}
+	}
+	return kvs, nil
+}
+
+type listResponse struct {
+	Items    []keyValue `json:"items"`
+	NextLink string     `json:"@nextLink"`
+}
+
+func (p *Provider) get(ctx context.Context, u string) (*listResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.microsoft.appconfig.kvset+json, application/problem+json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		problem := struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&problem)
+		return nil, fmt.Errorf("app configuration returned status code %d: %s %s", resp.StatusCode, problem.Title, problem.Detail)
+	}
+	body := &listResponse{}
+	err = json.NewDecoder(resp.Body).Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode app configuration response: %w", err)
+	}
+	return body, nil
+}
+
+// bearerTransport attaches a bearer token for the scope to every request.
+type bearerTransport struct {
+	cred  azcore.TokenCredential
+	scope string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.cred.GetToken(req.Context(), policy.TokenRequestOptions{Scopes: []string{t.scope}})
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,110 @@
+package azappconfig
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/config"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: opts.Scopes[0], ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer https://my-store.azconfig.io/.default", r.Header.Get("Authorization"))
+		require.Equal(t, "my-store.azconfig.io", r.Header.Get("X-Original-Host"))
+		require.Equal(t, "/kv", r.URL.Path)
+		require.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+		require.Equal(t, "App:*", r.URL.Query().Get("key"))
+		switch r.URL.Query().Get("label") {
+		case NoLabel:
+			if r.URL.Query().Get("after") == "" {
+				fmt.Fprint(w, `{"items":[{"key":"App:Name","value":"shared"}],"@nextLink":"/kv?key=App%3A%2A&label=%00&api-version=1.0&after=1"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items":[{"key":"App:Http:Port","value":"8080"}]}`)
+		case "production":
+			fmt.Fprintf(w, `{"items":[{"key":"App:Http:Port","value":"9090"},{"key":"App:Password","content_type":"%s;charset=utf-8","value":"{\"uri\":\"https://my-vault.vault.azure.net/secrets/password\"}"}]}`, KeyVaultRefContentType)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"title":"Invalid label","detail":"bad label"}`)
+		}
+	}))
+	defer srv.Close()
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	cfg.Endpoint = "https://my-store.azconfig.io"
+	cfg.Credential = fakeCredential{}
+	cfg.Transport = rewriteTransport{target: target}
+	cfg.KeyPrefix = "App:"
+	cfg.Labels = []string{NoLabel, "production"}
+	cfg.KeyVault = KeyVaultResolverFunc(func(ctx context.Context, path string) (string, error) {
+		require.Equal(t, "my-vault/password", path)
+		return "hunter2", nil
+	})
+	provider, err := NewProvider(cfg)
+	require.NoError(t, err)
+
+	values, err := provider.Values(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"name": "shared", "http_port": "9090", "password": "hunter2"}, values)
+
+	dst := struct {
+		Name     string
+		Password string `secret:"true"`
+		HTTP     struct {
+			Port int
+		} `config:"http"`
+	}{}
+	err = config.Load(&dst, config.Options{Providers: []config.Provider{provider}})
+	require.NoError(t, err)
+	require.Equal(t, "shared", dst.Name)
+	require.Equal(t, "hunter2", dst.Password)
+	require.Equal(t, 9090, dst.HTTP.Port)
+
+	cfg.KeyVault = nil
+	provider, err = NewProvider(cfg)
+	require.NoError(t, err)
+	_, err = provider.Values(context.Background())
+	require.EqualError(t, err, "key App:Password references key vault but no key vault resolver is configured")
+
+	cfg.Labels = []string{"unknown"}
+	provider, err = NewProvider(cfg)
+	require.NoError(t, err)
+	_, err = provider.Values(context.Background())
+	require.EqualError(t, err, "app configuration returned status code 400: Invalid label bad label")
+}
+
+func TestKeyVaultPath(t *testing.T) {
+	path, err := keyVaultPath("https://my-vault.vault.azure.net/secrets/name/abc")
+	require.NoError(t, err)
+	require.Equal(t, "my-vault/name/abc", path)
+	_, err = keyVaultPath("https://my-vault.vault.azure.net/keys/name")
+	require.EqualError(t, err, "https://my-vault.vault.azure.net/keys/name is not a key vault secret uri")
+}
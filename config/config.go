@@ -0,0 +1,421 @@
+package config
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+type Options struct {
+	// Path to a YAML or JSON file to load, no file is loaded if empty.
+	FilePath string
+	// Prefix added to environment variable names.
+	EnvPrefix string
+	// Command line arguments to parse as flags, such as os.Args[1:]. Flags are not parsed if nil,
+	// which leaves the command line to applications defining their own flags.
+	Args []string
+	// Providers of values from remote sources, later providers take precedence over earlier ones.
+	Providers []Provider
+}
+
+func DefaultOptions() Options {
+	return Options{
+		FilePath:  "",
+		EnvPrefix: "",
+		Args:      nil,
+		Providers: nil,
+	}
+}
+
+// Provider reads configuration values from a source other than the file, environment and flags,
+// such as a remote configuration service.
+type Provider interface {
+	// Values returns the values by key, where keys are the same as those of the file joined by
+	// underscores, for example http_port.
+	Values(ctx context.Context) (map[string]string, error)
+}
+
+// MissingKeysError lists all required keys which were not set by any source.
+type MissingKeysError struct {
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("missing required configuration keys: %s", strings.Join(e.Keys, ", "))
+}
+
+// Load populates the struct pointed to by dst from, in order of increasing precedence, default
+// tags, a YAML or JSON file, providers, environment variables and command line flags.
+//
+// Keys are derived from the `config` struct tag or the snake cased field name, and nested structs
+// are prefixed with the key of their parent. The key "http_port" is read as the file key http_port,
+// the environment variable HTTP_PORT and the flag --http-port. The `default`, `usage`, `required`
+// and `secret` tags further configure each field.
+func Load(dst interface{}, opts Options) error {
+	return LoadContext(context.Background(), dst, opts)
+}
+
+// LoadContext is like Load but passes ctx to the providers.
+func LoadContext(ctx context.Context, dst interface{}, opts Options) error {
+	var content []byte
+	if opts.FilePath != "" {
+		b, err := os.ReadFile(opts.FilePath)
+		if err != nil {
+			return err
+		}
+		content = b
+	}
+	values, err := providerValues(ctx, opts.Providers)
+	if err != nil {
+		return err
+	}
+	return load(dst, opts, content, values)
+}
+
+// providerValues merges the values of all providers, with later providers taking precedence.
+func providerValues(ctx context.Context, providers []Provider) (map[string]string, error) {
+	values := map[string]string{}
+	for i, p := range providers {
+		v, err := p.Values(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not read values from provider %d: %w", i, err)
+		}
+		for k, s := range v {
+			values[k] = s
+		}
+	}
+	return values, nil
+}
+
+func load(dst interface{}, opts Options, content []byte, values map[string]string) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("destination has to be a pointer to a struct")
+	}
+	fields := structFields(v.Elem(), nil)
+
+	set := map[string]bool{}
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		err := setString(f.value, f.def)
+		if err != nil {
+			return fmt.Errorf("could not parse default value for %s: %w", f.name(), err)
+		}
+	}
+	if opts.FilePath != "" {
+		err := loadFile(fields, opts.FilePath, content, set)
+		if err != nil {
+			return err
+		}
+	}
+	err := loadValues(fields, values, set)
+	if err != nil {
+		return err
+	}
+	err = loadEnv(fields, opts.EnvPrefix, set)
+	if err != nil {
+		return err
+	}
+	if opts.Args != nil {
+		err := loadFlags(fields, opts.Args, set)
+		if err != nil {
+			return err
+		}
+	}
+
+	missing := []string{}
+	for _, f := range fields {
+		if f.required && !set[f.name()] && f.value.IsZero() {
+			missing = append(missing, f.name())
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingKeysError{Keys: missing}
+	}
+	return nil
+}
+
+// String formats the struct with the values of fields tagged as secret redacted. It is meant
+// to be used when implementing fmt.Stringer for configuration structs.
+func String(src interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(src))
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprint(src)
+	}
+	parts := []string{}
+	for _, f := range structFields(v, nil) {
+		value := fmt.Sprint(f.value.Interface())
+		if f.secret && !f.value.IsZero() {
+			value = redacted
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", f.name(), value))
+	}
+	return strings.Join(parts, " ")
+}
+
+type field struct {
+	path     []string
+	value    reflect.Value
+	def      string
+	usage    string
+	required bool
+	secret   bool
+}
+
+func (f field) name() string {
+	return strings.Join(f.path, "_")
+}
+
+func (f field) envName(prefix string) string {
+	return strings.ToUpper(prefix + f.name())
+}
+
+func (f field) flagName() string {
+	return strings.ReplaceAll(f.name(), "_", "-")
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isTextUnmarshaler reports whether the value is parsed with its UnmarshalText method, such as
+// time.Time. These values are set as a whole instead of field by field.
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType)
+}
+
+func structFields(v reflect.Value, prefix []string) []field {
+	fields := []field{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		key := sf.Tag.Get("config")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = toSnakeCase(sf.Name)
+		}
+		path := append(append([]string{}, prefix...), key)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !isTextUnmarshaler(fv) {
+			fields = append(fields, structFields(fv, path)...)
+			continue
+		}
+		fields = append(fields, field{
+			path:     path,
+			value:    fv,
+			def:      sf.Tag.Get("default"),
+			usage:    sf.Tag.Get("usage"),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+		})
+	}
+	return fields
+}
+
+func loadFile(fields []field, path string, content []byte, set map[string]bool) error {
+	doc := &yaml.Node{}
+	err := yaml.Unmarshal(content, doc)
+	if err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	for _, f := range fields {
+		node := lookupNode(doc.Content[0], f.path)
+		if node == nil {
+			continue
+		}
+		err := decodeNode(node, f.value)
+		if err != nil {
+			return fmt.Errorf("could not parse %s from config file: %w", f.name(), err)
+		}
+		set[f.name()] = true
+	}
+	return nil
+}
+
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+func decodeNode(node *yaml.Node, v reflect.Value) error {
+	if node.Kind == yaml.ScalarNode {
+		return setString(v, node.Value)
+	}
+	return node.Decode(v.Addr().Interface())
+}
+
+func loadValues(fields []field, values map[string]string, set map[string]bool) error {
+	for _, f := range fields {
+		s, ok := values[f.name()]
+		if !ok {
+			continue
+		}
+		err := setString(f.value, s)
+		if err != nil {
+			return fmt.Errorf("could not parse %s from provider: %w", f.name(), err)
+		}
+		set[f.name()] = true
+	}
+	return nil
+}
+
+func loadEnv(fields []field, prefix string, set map[string]bool) error {
+	for _, f := range fields {
+		s, ok := os.LookupEnv(f.envName(prefix))
+		if !ok {
+			continue
+		}
+		err := setString(f.value, s)
+		if err != nil {
+			return fmt.Errorf("could not parse %s from environment variable %s: %w", f.name(), f.envName(prefix), err)
+		}
+		set[f.name()] = true
+	}
+	return nil
+}
+
+type flagValue struct {
+	f field
+}
+
+func (v *flagValue) String() string {
+	if !v.f.value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.f.value.Interface())
+}
+
+func (v *flagValue) Set(s string) error {
+	return setString(v.f.value, s)
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.f.value.Kind() == reflect.Bool
+}
+
+func loadFlags(fields []field, args []string, set map[string]bool) error {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	for _, f := range fields {
+		fs.Var(&flagValue{f: f}, f.flagName(), f.usage)
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	fs.Visit(func(fl *flag.Flag) {
+		set[fl.Value.(*flagValue).f.name()] = true
+	})
+	return nil
+}
+
+func setString(v reflect.Value, s string) error {
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := []string{}
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			err := setString(slice.Index(i), strings.TrimSpace(part))
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
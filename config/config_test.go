@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Address      string        `default:":8080" usage:"Address to listen on."`
+	Timeout      time.Duration `default:"5s"`
+	Debug        bool
+	Tags         []string
+	ClientSecret string `required:"true" secret:"true"`
+	Database     struct {
+		Host string `config:"hostname" required:"true"`
+		Port int    `default:"5432"`
+	}
+	Ignored string `config:"-"`
+}
+
+// level has no exported fields and is parsed with UnmarshalText.
+type level struct {
+	name string
+}
+
+func (l *level) UnmarshalText(b []byte) error {
+	l.name = strings.ToUpper(string(b))
+	return nil
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+address: ":9090"
+timeout: 10s
+tags: [foo, bar]
+database:
+  hostname: file
+  port: 5433
+`
+	err := os.WriteFile(path, []byte(content), 0o600)
+	require.NoError(t, err)
+	t.Setenv("APP_DATABASE_HOSTNAME", "env")
+	t.Setenv("APP_CLIENT_SECRET", "hunter2")
+
+	cfg := testConfig{}
+	opts := Options{
+		FilePath:  path,
+		EnvPrefix: "APP_",
+		Args:      []string{"--debug", "--database-hostname", "flag"},
+	}
+	err = Load(&cfg, opts)
+	require.NoError(t, err)
+	require.Equal(t, ":9090", cfg.Address)
+	require.Equal(t, 10*time.Second, cfg.Timeout)
+	require.True(t, cfg.Debug)
+	require.Equal(t, []string{"foo", "bar"}, cfg.Tags)
+	require.Equal(t, "hunter2", cfg.ClientSecret)
+	require.Equal(t, "flag", cfg.Database.Host)
+	require.Equal(t, 5433, cfg.Database.Port)
+}
+
+type providerFunc func(ctx context.Context) (map[string]string, error)
+
+func (f providerFunc) Values(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+func TestLoadProviders(t *testing.T) {
+	t.Setenv("PROVIDER_TEST_TIMEOUT", "20s")
+	first := providerFunc(func(ctx context.Context) (map[string]string, error) {
+		return map[string]string{"address": ":9090", "client_secret": "first", "database_port": "5433"}, nil
+	})
+	second := providerFunc(func(ctx context.Context) (map[string]string, error) {
+		return map[string]string{"client_secret": "second", "timeout": "10s", "database_hostname": "provider"}, nil
+	})
+	cfg := testConfig{}
+	opts := Options{
+		EnvPrefix: "PROVIDER_TEST_",
+		Args:      []string{"--database-port=5434"},
+		Providers: []Provider{first, second},
+	}
+	err := Load(&cfg, opts)
+	require.NoError(t, err)
+	require.Equal(t, ":9090", cfg.Address)
+	require.Equal(t, "second", cfg.ClientSecret)
+	require.Equal(t, 20*time.Second, cfg.Timeout)
+	require.Equal(t, "provider", cfg.Database.Host)
+	require.Equal(t, 5434, cfg.Database.Port)
+
+	failing := providerFunc(func(ctx context.Context) (map[string]string, error) {
+		return nil, errors.New("unavailable")
+	})
+	err = Load(&cfg, Options{Providers: []Provider{first, failing}})
+	require.EqualError(t, err, "could not read values from provider 1: unavailable")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg := testConfig{}
+	err := Load(&cfg, Options{Args: []string{"--client-secret=foo", "--database-hostname=bar"}})
+	require.NoError(t, err)
+	require.Equal(t, ":8080", cfg.Address)
+	require.Equal(t, 5*time.Second, cfg.Timeout)
+	require.Equal(t, 5432, cfg.Database.Port)
+}
+
+func TestLoadMissingKeys(t *testing.T) {
+	cfg := testConfig{}
+	err := Load(&cfg, Options{})
+	missingErr := &MissingKeysError{}
+	require.True(t, errors.As(err, &missingErr))
+	require.Equal(t, []string{"client_secret", "database_hostname"}, missingErr.Keys)
+	require.EqualError(t, err, "missing required configuration keys: client_secret, database_hostname")
+}
+
+func TestLoadInvalidValue(t *testing.T) {
+	t.Setenv("TIMEOUT", "foo")
+	cfg := testConfig{}
+	err := Load(&cfg, Options{})
+	require.EqualError(t, err, "could not parse timeout from environment variable TIMEOUT: time: invalid duration \"foo\"")
+}
+
+func TestLoadTextUnmarshaler(t *testing.T) {
+	cfg := struct {
+		Since time.Time
+		Level level
+		Dates []time.Time
+	}{}
+	t.Setenv("SINCE", "2023-04-01T12:00:00Z")
+	t.Setenv("LEVEL", "debug")
+	t.Setenv("DATES", "2023-01-01T00:00:00Z, 2023-02-01T00:00:00Z")
+	err := Load(&cfg, Options{})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), cfg.Since)
+	require.Equal(t, level{name: "DEBUG"}, cfg.Level)
+	require.Len(t, cfg.Dates, 2)
+
+	t.Setenv("SINCE", "yesterday")
+	err = Load(&cfg, Options{})
+	require.ErrorContains(t, err, "could not parse since from environment variable SINCE")
+}
+
+func TestDefaultOptions(t *testing.T) {
+	require.Nil(t, DefaultOptions().Args)
+}
+
+func TestString(t *testing.T) {
+	cfg := testConfig{Address: ":8080", ClientSecret: "hunter2"}
+	cfg.Database.Host = "localhost"
+	expected := "address=:8080 timeout=0s debug=false tags=[] client_secret=[REDACTED] database_hostname=localhost database_port=0"
+	require.Equal(t, expected, String(cfg))
+}
+
+func TestToSnakeCase(t *testing.T) {
+	require.Equal(t, "client_id", toSnakeCase("ClientID"))
+	require.Equal(t, "http_server_port", toSnakeCase("HTTPServerPort"))
+	require.Equal(t, "address", toSnakeCase("Address"))
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Validator can be implemented by configuration structs to validate values beyond required keys.
+type Validator interface {
+	Validate() error
+}
+
+// Reloader applies the latest configuration without restarting, it has the same method as
+// service.Reloader so that components implementing it can be passed directly.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+type WatchConfig struct {
+	// Interval between checks of the providers for changes, the configuration file is watched
+	// for events instead.
+	Interval time.Duration
+	// Called in order after the configuration has changed, so that components can read the new
+	// configuration with Get and apply it live. Errors are passed to OnError.
+	Reloaders []Reloader
+	// Called when a reload fails, the previous configuration is kept.
+	OnError func(error)
+}
+
+func DefaultWatchConfig() WatchConfig {
+	return WatchConfig{
+		Interval:  10 * time.Second,
+		Reloaders: nil,
+		OnError:   nil,
+	}
+}
+
+// Watcher reloads the configuration when the configuration file or the values of the providers
+// change and notifies subscribers and reloaders. The directory of the file is watched, as files
+// mounted from a ConfigMap are replaced by swapping a symlink. Events which do not change the
+// content of the file are ignored. Watcher implements service.Reloader, so that a reload can
+// also be triggered by a signal.
+type Watcher[T any] struct {
+	opts Options
+	cfg  WatchConfig
+
+	mu          sync.RWMutex
+	content     []byte
+	values      map[string]string
+	value       T
+	subscribers map[chan T]struct{}
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewWatcher loads the configuration and returns a watcher for the configuration file and
+// providers. An error is returned if the initial load fails.
+func NewWatcher[T any](opts Options, cfg WatchConfig) (*Watcher[T], error) {
+	if opts.FilePath == "" && len(opts.Providers) == 0 {
+		return nil, errors.New("file path cannot be empty without providers")
+	}
+	if len(opts.Providers) > 0 && cfg.Interval <= 0 {
+		return nil, errors.New("interval has to be larger than zero")
+	}
+	w := &Watcher[T]{
+		opts:        opts,
+		cfg:         cfg,
+		subscribers: map[chan T]struct{}{},
+		done:        make(chan struct{}),
+	}
+	_, err := w.reload(context.Background(), true)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Get returns the latest valid configuration.
+func (w *Watcher[T]) Get() T {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.value
+}
+
+// Subscribe returns a channel which receives the configuration every time it changes. Only the
+// latest configuration is buffered, so slow subscribers skip intermediate values. The channel is
+// closed when ctx is cancelled or the watcher is stopped.
+func (w *Watcher[T]) Subscribe(ctx context.Context) <-chan T {
+	ch := make(chan T, 1)
+	w.mu.Lock()
+	w.subscribers[ch] = struct{}{}
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.done:
+		}
+		w.mu.Lock()
+		delete(w.subscribers, ch)
+		w.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// Reload reads and validates the configuration and notifies subscribers and reloaders, regardless
+// of whether the file or provider values have changed. The previous configuration is kept if an
+// error is returned.
+func (w *Watcher[T]) Reload(ctx context.Context) error {
+	_, err := w.reload(ctx, true)
+	if err != nil {
+		return err
+	}
+	return w.apply(ctx)
+}
+
+// Start watches the configuration file and checks the providers for changes until Stop is
+// called or ctx is cancelled.
+func (w *Watcher[T]) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.mu.Unlock()
+		return errors.New("watcher has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.mu.Unlock()
+	defer close(w.done)
+
+	var events chan fsnotify.Event
+	var errs chan error
+	if w.opts.FilePath != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer watcher.Close()
+		err = watcher.Add(filepath.Dir(w.opts.FilePath))
+		if err != nil {
+			return err
+		}
+		events = watcher.Events
+		errs = watcher.Errors
+	}
+	var tick <-chan time.Time
+	if len(w.opts.Providers) > 0 {
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			w.reloadAndReport(ctx)
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			w.report(err)
+		case <-tick:
+			w.reloadAndReport(ctx)
+		}
+	}
+}
+
+func (w *Watcher[T]) reloadAndReport(ctx context.Context) {
+	changed, err := w.reload(ctx, false)
+	if err != nil {
+		w.report(err)
+		return
+	}
+	if !changed {
+		return
+	}
+	err = w.apply(ctx)
+	if err != nil {
+		w.report(err)
+	}
+}
+
+// apply calls all reloaders, an error from one reloader does not stop the others.
+func (w *Watcher[T]) apply(ctx context.Context) error {
+	errs := []error{}
+	for _, r := range w.cfg.Reloaders {
+		err := r.Reload(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not apply configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (w *Watcher[T]) report(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+// Stop stops checking the configuration file and providers for changes and closes all subscriber channels.
+func (w *Watcher[T]) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Watcher[T]) reload(ctx context.Context, force bool) (bool, error) {
+	var content []byte
+	if w.opts.FilePath != "" {
+		b, err := os.ReadFile(w.opts.FilePath)
+		if err != nil {
+			return false, err
+		}
+		content = b
+	}
+	values, err := providerValues(ctx, w.opts.Providers)
+	if err != nil {
+		return false, err
+	}
+	// The content is stored before parsing so that an invalid file is only reported once.
+	w.mu.Lock()
+	unchanged := bytes.Equal(content, w.content) && reflect.DeepEqual(values, w.values)
+	w.content = content
+	w.values = values
+	w.mu.Unlock()
+	if unchanged && !force {
+		return false, nil
+	}
+
+	var value T
+	err = load(&value, w.opts, content, values)
+	if err != nil {
+		return false, err
+	}
+	if v, ok := any(&value).(Validator); ok {
+		err := v.Validate()
+		if err != nil {
+			return false, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	w.mu.Lock()
+	w.value = value
+	for ch := range w.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- value
+	}
+	w.mu.Unlock()
+	return true, nil
+}
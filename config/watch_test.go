@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type watchConfig struct {
+	Name string `required:"true"`
+}
+
+func (c *watchConfig) Validate() error {
+	if c.Name == "invalid" {
+		return errors.New("name cannot be invalid")
+	}
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "name: foo")
+
+	errCh := make(chan error, 10)
+	cfg := DefaultWatchConfig()
+	cfg.Interval = 0
+	cfg.OnError = func(err error) {
+		errCh <- err
+	}
+	w, err := NewWatcher[watchConfig](Options{FilePath: path, EnvPrefix: "WATCH_TEST_"}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "foo", w.Get().Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := w.Subscribe(ctx)
+	go func() {
+		_ = w.Start(ctx)
+	}()
+	// Wait for the file to be watched.
+	time.Sleep(50 * time.Millisecond)
+
+	writeFile(t, path, "name: bar")
+	require.Equal(t, "bar", (<-sub).Name)
+	require.Equal(t, "bar", w.Get().Name)
+
+	writeFile(t, path, "name: invalid")
+	require.ErrorContains(t, <-errCh, "name cannot be invalid")
+	require.Equal(t, "bar", w.Get().Name)
+
+	writeFile(t, path, "name: baz")
+	require.Equal(t, "baz", (<-sub).Name)
+
+	err = w.Reload(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "baz", (<-sub).Name)
+
+	err = w.Stop(ctx)
+	require.NoError(t, err)
+	_, ok := <-sub
+	require.False(t, ok)
+	require.Empty(t, errCh)
+}
+
+func TestWatcherProvider(t *testing.T) {
+	var name atomic.Value
+	name.Store("foo")
+	provider := providerFunc(func(ctx context.Context) (map[string]string, error) {
+		return map[string]string{"name": name.Load().(string)}, nil
+	})
+	cfg := DefaultWatchConfig()
+	cfg.Interval = 10 * time.Millisecond
+	w, err := NewWatcher[watchConfig](Options{EnvPrefix: "WATCH_TEST_", Providers: []Provider{provider}}, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "foo", w.Get().Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := w.Subscribe(ctx)
+	go func() {
+		_ = w.Start(ctx)
+	}()
+
+	name.Store("bar")
+	require.Equal(t, "bar", (<-sub).Name)
+	require.Equal(t, "bar", w.Get().Name)
+	err = w.Stop(ctx)
+	require.NoError(t, err)
+}
+
+func TestWatcherReloaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "name: foo")
+
+	var w *Watcher[watchConfig]
+	nameCh := make(chan string, 10)
+	errCh := make(chan error, 10)
+	cfg := DefaultWatchConfig()
+	cfg.Reloaders = []Reloader{
+		reloaderFunc(func(ctx context.Context) error {
+			nameCh <- w.Get().Name
+			return nil
+		}),
+		reloaderFunc(func(ctx context.Context) error {
+			return errors.New("reload failed")
+		}),
+	}
+	cfg.OnError = func(err error) {
+		errCh <- err
+	}
+	w, err := NewWatcher[watchConfig](Options{FilePath: path, EnvPrefix: "WATCH_TEST_"}, cfg)
+	require.NoError(t, err)
+	require.Empty(t, nameCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = w.Start(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	writeFile(t, path, "name: bar")
+	require.Equal(t, "bar", <-nameCh)
+	require.ErrorContains(t, <-errCh, "reload failed")
+
+	err = w.Reload(ctx)
+	require.ErrorContains(t, err, "reload failed")
+	require.Equal(t, "bar", <-nameCh)
+
+	err = w.Stop(ctx)
+	require.NoError(t, err)
+}
+
+func TestWatcherInitialLoadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "foo: bar")
+
+	_, err := NewWatcher[watchConfig](Options{FilePath: path, EnvPrefix: "WATCH_TEST_"}, DefaultWatchConfig())
+	missingErr := &MissingKeysError{}
+	require.ErrorAs(t, err, &missingErr)
+	require.Equal(t, []string{"name"}, missingErr.Keys)
+}
+
+type reloaderFunc func(ctx context.Context) error
+
+func (f reloaderFunc) Reload(ctx context.Context) error {
+	return f(ctx)
+}
+
+// writeFile replaces the file atomically so that the watcher never reads a partial write.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, []byte(content), 0o600)
+	require.NoError(t, err)
+	err = os.Rename(tmp, path)
+	require.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package featureflags
+
+import (
+	"context"
+)
+
+type subjectKey struct{}
+
+type providerKey struct{}
+
+// ContextWithSubject returns a context with the subject used for percentage rollouts and
+// variants, usually the OIDC subject of the authenticated user.
+func ContextWithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext returns the subject in the context, empty if not set.
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// ContextWithProvider returns a context with the provider used by IsEnabled and Variant.
+func ContextWithProvider(ctx context.Context, provider Provider) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// ProviderFromContext returns the provider in the context, or a provider without any flags.
+func ProviderFromContext(ctx context.Context) Provider {
+	provider, ok := ctx.Value(providerKey{}).(Provider)
+	if !ok {
+		return Flags{}
+	}
+	return provider
+}
+
+// ContextFunc returns a function adding the provider, and the subject if not empty, to a context.
+// It is used by HTTP middleware which resolve the subject of each request.
+func ContextFunc(provider Provider) func(ctx context.Context, subject string) context.Context {
+	return func(ctx context.Context, subject string) context.Context {
+		ctx = ContextWithProvider(ctx, provider)
+		if subject != "" {
+			ctx = ContextWithSubject(ctx, subject)
+		}
+		return ctx
+	}
+}
+
+// IsEnabled evaluates the flag with the provider in the context.
+func IsEnabled(ctx context.Context, flag string, defaultValue bool) bool {
+	return ProviderFromContext(ctx).IsEnabled(ctx, flag, defaultValue)
+}
+
+// Variant evaluates the flag variant with the provider in the context.
+func Variant(ctx context.Context, flag string) string {
+	return ProviderFromContext(ctx).Variant(ctx, flag)
+}
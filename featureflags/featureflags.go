@@ -0,0 +1,95 @@
+package featureflags
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Provider evaluates feature flags for the subject in the context.
+type Provider interface {
+	// IsEnabled returns true if the flag is enabled, defaultValue is returned for unknown flags.
+	IsEnabled(ctx context.Context, flag string, defaultValue bool) bool
+	// Variant returns the variant selected for the flag, empty if the flag is unknown or disabled.
+	Variant(ctx context.Context, flag string) string
+}
+
+// Flag is the definition of a single feature flag.
+type Flag struct {
+	Enabled bool `yaml:"enabled"`
+	// Percentage of subjects between 0 and 100 the flag is enabled for, all subjects if nil.
+	// Requests without a subject are only included when the rollout is 100.
+	Rollout *int `yaml:"rollout"`
+	// Variants of an enabled flag, selected per subject in proportion to their weights.
+	Variants []FlagVariant `yaml:"variants"`
+}
+
+type FlagVariant struct {
+	Name   string `yaml:"name"`
+	Weight int    `yaml:"weight"`
+}
+
+// Flags maps flag names to their definitions.
+type Flags map[string]Flag
+
+// IsEnabled implements Provider.
+func (f Flags) IsEnabled(ctx context.Context, flag string, defaultValue bool) bool {
+	def, ok := f[flag]
+	if !ok {
+		return defaultValue
+	}
+	return def.enabled(flag, SubjectFromContext(ctx))
+}
+
+// Variant implements Provider. Requests without a subject get the first variant.
+func (f Flags) Variant(ctx context.Context, flag string) string {
+	def, ok := f[flag]
+	if !ok || len(def.Variants) == 0 {
+		return ""
+	}
+	subject := SubjectFromContext(ctx)
+	if !def.enabled(flag, subject) {
+		return ""
+	}
+	if subject == "" {
+		return def.Variants[0].Name
+	}
+	total := 0
+	for _, v := range def.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return def.Variants[0].Name
+	}
+	// The variant hash is salted differently from the rollout so that the two are independent.
+	n := int(hash(flag+"/variant", subject) % uint32(total))
+	for _, v := range def.Variants {
+		if n < v.Weight {
+			return v.Name
+		}
+		n -= v.Weight
+	}
+	return def.Variants[len(def.Variants)-1].Name
+}
+
+func (f Flag) enabled(flag, subject string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.Rollout == nil || *f.Rollout >= 100 {
+		return true
+	}
+	if subject == "" || *f.Rollout <= 0 {
+		return false
+	}
+	return int(hash(flag, subject)%100) < *f.Rollout
+}
+
+// hash is stable across processes, so a subject stays in the rollout as long as the percentage
+// is not decreased.
+func hash(flag, subject string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return h.Sum32()
+}
@@ -0,0 +1,123 @@
+package featureflags
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rollout(p int) *int {
+	return &p
+}
+
+func TestFlags(t *testing.T) {
+	flags := Flags{
+		"on":  {Enabled: true},
+		"off": {Enabled: false},
+	}
+	ctx := context.Background()
+	require.True(t, flags.IsEnabled(ctx, "on", false))
+	require.False(t, flags.IsEnabled(ctx, "off", true))
+	require.True(t, flags.IsEnabled(ctx, "unknown", true))
+	require.False(t, flags.IsEnabled(ctx, "unknown", false))
+}
+
+func TestFlagsRollout(t *testing.T) {
+	flags := Flags{
+		"half": {Enabled: true, Rollout: rollout(50)},
+		"none": {Enabled: true, Rollout: rollout(0)},
+	}
+	require.False(t, flags.IsEnabled(context.Background(), "half", true))
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		ctx := ContextWithSubject(context.Background(), fmt.Sprintf("user-%d", i))
+		if flags.IsEnabled(ctx, "half", false) {
+			enabled++
+		}
+		// The result has to be stable for a subject.
+		require.Equal(t, flags.IsEnabled(ctx, "half", false), flags.IsEnabled(ctx, "half", false))
+		require.False(t, flags.IsEnabled(ctx, "none", true))
+	}
+	require.InDelta(t, 500, enabled, 75)
+}
+
+func TestFlagsVariant(t *testing.T) {
+	flags := Flags{
+		"color": {Enabled: true, Variants: []FlagVariant{{Name: "red", Weight: 1}, {Name: "blue", Weight: 3}}},
+		"off":   {Enabled: false, Variants: []FlagVariant{{Name: "red", Weight: 1}}},
+	}
+	require.Equal(t, "red", flags.Variant(context.Background(), "color"))
+	require.Empty(t, flags.Variant(context.Background(), "off"))
+	require.Empty(t, flags.Variant(context.Background(), "unknown"))
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		ctx := ContextWithSubject(context.Background(), fmt.Sprintf("user-%d", i))
+		counts[flags.Variant(ctx, "color")]++
+	}
+	require.InDelta(t, 250, counts["red"], 75)
+	require.InDelta(t, 750, counts["blue"], 75)
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	require.True(t, IsEnabled(ctx, "foo", true))
+	ctx = ContextWithProvider(ctx, Flags{"foo": {Enabled: false, Variants: []FlagVariant{{Name: "a"}}}})
+	require.False(t, IsEnabled(ctx, "foo", true))
+	require.Empty(t, Variant(ctx, "foo"))
+}
+
+func TestContextFunc(t *testing.T) {
+	withContext := ContextFunc(Flags{"foo": {Enabled: true}})
+	ctx := withContext(context.Background(), "")
+	require.True(t, IsEnabled(ctx, "foo", false))
+	require.Empty(t, SubjectFromContext(ctx))
+	ctx = withContext(context.Background(), "bar")
+	require.Equal(t, "bar", SubjectFromContext(ctx))
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.yaml")
+	err := os.WriteFile(path, []byte("foo:\n  enabled: true\n"), 0o600)
+	require.NoError(t, err)
+	cfg := DefaultFileConfig()
+	cfg.Path = path
+	cfg.Interval = 10 * time.Millisecond
+	errs := make(chan error, 10)
+	cfg.OnError = func(err error) {
+		errs <- err
+	}
+	p, err := NewFileProvider(cfg)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.True(t, p.IsEnabled(ctx, "foo", false))
+	go p.Start(ctx)
+
+	err = os.WriteFile(path, []byte(`{"foo": {"enabled": false}}`), 0o600)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return !p.IsEnabled(ctx, "foo", true)
+	}, time.Second, 10*time.Millisecond)
+
+	// An invalid file keeps the previous flags.
+	err = os.WriteFile(path, []byte("foo:\n  enabled: true\n  rollout: 200\n"), 0o600)
+	require.NoError(t, err)
+	require.EqualError(t, <-errs, "rollout of flag foo has to be between 0 and 100")
+	require.False(t, p.IsEnabled(ctx, "foo", true))
+
+	err = p.Stop(ctx)
+	require.NoError(t, err)
+}
+
+func TestNewFileProviderMissing(t *testing.T) {
+	cfg := DefaultFileConfig()
+	cfg.Path = filepath.Join(t.TempDir(), "missing.yaml")
+	_, err := NewFileProvider(cfg)
+	require.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package featureflags
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type FileConfig struct {
+	// Path to a YAML or JSON file mapping flag names to definitions, for example a mounted ConfigMap.
+	Path string
+	// Interval between checks of the file for changes.
+	Interval time.Duration
+	// Called when a reload fails, the previous flags are kept.
+	OnError func(error)
+}
+
+func DefaultFileConfig() FileConfig {
+	return FileConfig{
+		Path:     "",
+		Interval: 10 * time.Second,
+		OnError:  nil,
+	}
+}
+
+// FileProvider evaluates flags read from a file, reloading it when it changes. Files mounted from
+// a ConfigMap are replaced by swapping a symlink, which is detected as the content is compared
+// rather than the modification time.
+type FileProvider struct {
+	cfg FileConfig
+
+	mu      sync.RWMutex
+	content []byte
+	flags   Flags
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewFileProvider reads the flags from the file, an error is returned if the initial read fails.
+func NewFileProvider(cfg FileConfig) (*FileProvider, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("path cannot be empty")
+	}
+	if cfg.Interval <= 0 {
+		return nil, errors.New("interval has to be larger than zero")
+	}
+	p := &FileProvider{
+		cfg:  cfg,
+		done: make(chan struct{}),
+	}
+	err := p.Reload(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// IsEnabled implements Provider.
+func (p *FileProvider) IsEnabled(ctx context.Context, flag string, defaultValue bool) bool {
+	return p.Flags().IsEnabled(ctx, flag, defaultValue)
+}
+
+// Variant implements Provider.
+func (p *FileProvider) Variant(ctx context.Context, flag string) string {
+	return p.Flags().Variant(ctx, flag)
+}
+
+// Flags returns the latest valid flags.
+func (p *FileProvider) Flags() Flags {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.flags
+}
+
+// Reload reads the file regardless of whether it has changed. The previous flags are kept if an
+// error is returned.
+func (p *FileProvider) Reload(ctx context.Context) error {
+	return p.reload(true)
+}
+
+// Start checks the file for changes until Stop is called or ctx is cancelled.
+func (p *FileProvider) Start(ctx context.Context) error {
+	p.mu.Lock()
+	if p.cancel != nil {
+		p.mu.Unlock()
+		return errors.New("file provider has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.mu.Unlock()
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := p.reload(false)
+			if err != nil && p.cfg.OnError != nil {
+				p.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+// Stop stops checking the file for changes.
+func (p *FileProvider) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *FileProvider) reload(force bool) error {
+	content, err := os.ReadFile(p.cfg.Path)
+	if err != nil {
+		return err
+	}
+	// The content is stored before parsing so that an invalid file is only reported once.
+	p.mu.Lock()
+	unchanged := bytes.Equal(content, p.content)
+	p.content = content
+	p.mu.Unlock()
+	if unchanged && !force {
+		return nil
+	}
+
+	flags := Flags{}
+	err = yaml.Unmarshal(content, &flags)
+	if err != nil {
+		return fmt.Errorf("could not parse feature flags: %w", err)
+	}
+	for name, flag := range flags {
+		if flag.Rollout != nil && (*flag.Rollout < 0 || *flag.Rollout > 100) {
+			return fmt.Errorf("rollout of flag %s has to be between 0 and 100", name)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags = flags
+	return nil
+}
@@ -0,0 +1,126 @@
+package gin
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyOwnerKey is the context key the owner of a valid API key is stored with. Add it to
+// LogConfig.IncludeKeys to include the owner in request logs.
+const APIKeyOwnerKey = "apikey.owner"
+
+// APIKeyValidator validates API keys and returns the owner of valid keys.
+type APIKeyValidator interface {
+	Validate(ctx context.Context, key string) (owner string, ok bool, err error)
+}
+
+// APIKeyValidatorFunc allows a function to be used as an APIKeyValidator, for example to look up
+// keys in a database.
+type APIKeyValidatorFunc func(ctx context.Context, key string) (string, bool, error)
+
+func (f APIKeyValidatorFunc) Validate(ctx context.Context, key string) (string, bool, error) {
+	return f(ctx, key)
+}
+
+type staticAPIKey struct {
+	owner string
+	hash  [sha256.Size]byte
+}
+
+type staticAPIKeys struct {
+	keys []staticAPIKey
+}
+
+// StaticAPIKeys returns a validator for a fixed set of keys mapped to their owner. Keys are
+// compared in constant time.
+func StaticAPIKeys(keys map[string]string) APIKeyValidator {
+	s := &staticAPIKeys{}
+	for key, owner := range keys {
+		s.keys = append(s.keys, staticAPIKey{owner: owner, hash: sha256.Sum256([]byte(key))})
+	}
+	return s
+}
+
+// APIKeysFromEnv returns a static validator with keys read from the environment variable, which
+// should contain a comma separated list of owner:key pairs.
+func APIKeysFromEnv(name string) (APIKeyValidator, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	keys := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		owner, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || owner == "" || key == "" {
+			return nil, fmt.Errorf("environment variable %s contains invalid owner:key pair", name)
+		}
+		keys[key] = owner
+	}
+	return StaticAPIKeys(keys), nil
+}
+
+func (s *staticAPIKeys) Validate(_ context.Context, key string) (string, bool, error) {
+	hash := sha256.Sum256([]byte(key))
+	match := ""
+	// Compare against all keys so the time taken does not reveal which key matched.
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			match = k.owner
+		}
+	}
+	return match, match != "", nil
+}
+
+type APIKeyConfig struct {
+	// Header to read the API key from.
+	Header string
+	// Query parameter to read the API key from when the header is not set, disabled if empty.
+	QueryParam string
+	// Validator used to validate API keys, required.
+	Validator APIKeyValidator
+}
+
+func DefaultAPIKeyConfig() APIKeyConfig {
+	return APIKeyConfig{
+		Header:     "X-API-Key",
+		QueryParam: "",
+		Validator:  nil,
+	}
+}
+
+// APIKey authenticates requests with an API key, aborting with 401 if the key is missing or invalid.
+// It panics if no validator is configured, as every request would otherwise fail.
+func APIKey(cfg APIKeyConfig) gin.HandlerFunc {
+	if cfg.Validator == nil {
+		panic("api key validator cannot be nil")
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(cfg.Header)
+		if key == "" && cfg.QueryParam != "" {
+			key = c.Query(cfg.QueryParam)
+		}
+		if key == "" {
+			c.AbortWithError(http.StatusUnauthorized, errors.New("api key is missing"))
+			return
+		}
+		owner, ok, err := cfg.Validator.Validate(c.Request.Context(), key)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not validate api key: %w", err))
+			return
+		}
+		if !ok {
+			c.AbortWithError(http.StatusUnauthorized, errors.New("api key is invalid"))
+			return
+		}
+		c.Set(APIKeyOwnerKey, owner)
+		c.Next()
+	}
+}
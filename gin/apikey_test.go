@@ -0,0 +1,111 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func TestAPIKey(t *testing.T) {
+	var buf bytes.Buffer
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Logger(LogConfig{
+		Logger:      buflogr.NewWithBuffer(&buf),
+		IncludeKeys: []string{APIKeyOwnerKey},
+	}))
+	cfg := DefaultAPIKeyConfig()
+	cfg.QueryParam = "api_key"
+	cfg.Validator = StaticAPIKeys(map[string]string{"secret": "foo"})
+	engine.Use(APIKey(cfg))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(APIKeyOwnerKey))
+	})
+
+	tests := []struct {
+		name       string
+		header     string
+		query      string
+		statusCode int
+		log        string
+	}{
+		{
+			name:       "header",
+			header:     "secret",
+			statusCode: http.StatusOK,
+			log:        "INFO path / status 200 method GET apikey.owner foo\n",
+		},
+		{
+			name:       "query parameter",
+			query:      "?api_key=secret",
+			statusCode: http.StatusOK,
+			log:        "INFO path / status 200 method GET apikey.owner foo\n",
+		},
+		{
+			name:       "missing",
+			statusCode: http.StatusUnauthorized,
+			log:        "ERROR api key is missing path / status 401 method GET\n",
+		},
+		{
+			name:       "invalid",
+			header:     "wrong",
+			statusCode: http.StatusUnauthorized,
+			log:        "ERROR api key is invalid path / status 401 method GET\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			require.Equal(t, tt.statusCode, rec.Code)
+			require.Equal(t, tt.log, buf.String())
+		})
+	}
+}
+
+func TestAPIKeyValidatorError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := DefaultAPIKeyConfig()
+	cfg.Validator = APIKeyValidatorFunc(func(ctx context.Context, key string) (string, bool, error) {
+		return "", false, errors.New("store unavailable")
+	})
+	engine := gin.New()
+	engine.Use(APIKey(cfg))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestAPIKeyWithoutValidator(t *testing.T) {
+	require.Panics(t, func() {
+		APIKey(DefaultAPIKeyConfig())
+	})
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	t.Setenv("API_KEYS", "foo:first, bar:second")
+	validator, err := APIKeysFromEnv("API_KEYS")
+	require.NoError(t, err)
+	owner, ok, err := validator.Validate(context.Background(), "second")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "bar", owner)
+
+	t.Setenv("API_KEYS", "foo")
+	_, err = APIKeysFromEnv("API_KEYS")
+	require.EqualError(t, err, "environment variable API_KEYS contains invalid owner:key pair")
+}
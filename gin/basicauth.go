@@ -0,0 +1,66 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuthUserKey is the context key the authenticated username is stored with. Add it to
+// LogConfig.IncludeKeys to include the username in request logs.
+const BasicAuthUserKey = "basicauth.user"
+
+type BasicAuthConfig struct {
+	// Realm sent in the WWW-Authenticate header.
+	Realm string
+	// Bcrypt hashed passwords by username.
+	Users map[string]string
+}
+
+func DefaultBasicAuthConfig() BasicAuthConfig {
+	return BasicAuthConfig{
+		Realm: "Restricted",
+		Users: map[string]string{},
+	}
+}
+
+// BasicAuth authenticates requests with basic auth, aborting with 401 and a WWW-Authenticate
+// header if the credentials are missing or invalid.
+func BasicAuth(cfg BasicAuthConfig) gin.HandlerFunc {
+	challenge := fmt.Sprintf("Basic realm=%s, charset=\"UTF-8\"", strconv.Quote(cfg.Realm))
+	// Unknown users are compared against a dummy hash so the response time does not reveal which
+	// usernames exist.
+	cost := bcrypt.DefaultCost
+	for _, hash := range cfg.Users {
+		if c, err := bcrypt.Cost([]byte(hash)); err == nil {
+			cost = c
+			break
+		}
+	}
+	dummy, _ := bcrypt.GenerateFromPassword([]byte("dummy"), cost)
+
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithError(http.StatusUnauthorized, errors.New("basic auth credentials are missing"))
+			return
+		}
+		hash, ok := cfg.Users[username]
+		if !ok {
+			hash = string(dummy)
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if !ok || err != nil {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithError(http.StatusUnauthorized, errors.New("basic auth credentials are invalid"))
+			return
+		}
+		c.Set(BasicAuthUserKey, username)
+		c.Next()
+	}
+}
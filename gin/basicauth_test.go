@@ -0,0 +1,84 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Logger(LogConfig{
+		Logger:      buflogr.NewWithBuffer(&buf),
+		IncludeKeys: []string{BasicAuthUserKey},
+	}))
+	cfg := DefaultBasicAuthConfig()
+	cfg.Realm = "metrics"
+	cfg.Users = map[string]string{"admin": string(hash)}
+	engine.Use(BasicAuth(cfg))
+	engine.GET("/metrics", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		statusCode int
+		log        string
+	}{
+		{
+			name:       "valid",
+			username:   "admin",
+			password:   "password",
+			statusCode: http.StatusOK,
+			log:        "INFO path /metrics status 200 method GET basicauth.user admin\n",
+		},
+		{
+			name:       "missing",
+			statusCode: http.StatusUnauthorized,
+			log:        "ERROR basic auth credentials are missing path /metrics status 401 method GET\n",
+		},
+		{
+			name:       "invalid password",
+			username:   "admin",
+			password:   "wrong",
+			statusCode: http.StatusUnauthorized,
+			log:        "ERROR basic auth credentials are invalid path /metrics status 401 method GET\n",
+		},
+		{
+			name:       "unknown user",
+			username:   "guest",
+			password:   "password",
+			statusCode: http.StatusUnauthorized,
+			log:        "ERROR basic auth credentials are invalid path /metrics status 401 method GET\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			require.Equal(t, tt.statusCode, rec.Code)
+			require.Equal(t, tt.log, buf.String())
+			if tt.statusCode == http.StatusUnauthorized {
+				require.Equal(t, `Basic realm="metrics", charset="UTF-8"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package gin
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+type CompressionConfig struct {
+	// Compress responses in the engine created by NewEngine.
+	Enabled bool
+	// Minimum response size in bytes to compress, smaller responses are sent uncompressed.
+	MinSize int
+	// Media types which are compressed.
+	ContentTypes []string
+	// Gzip compression level.
+	GzipLevel int
+	// Use brotli when accepted by the client, brotli is preferred over gzip.
+	EnableBrotli bool
+	// Brotli compression level.
+	BrotliLevel int
+}
+
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Enabled: false,
+		MinSize: 1024,
+		ContentTypes: []string{
+			"application/json",
+			"application/javascript",
+			"application/problem+json",
+			"application/xml",
+			"image/svg+xml",
+			"text/css",
+			"text/csv",
+			"text/html",
+			"text/javascript",
+			"text/plain",
+			"text/xml",
+		},
+		GzipLevel:    gzip.DefaultCompression,
+		EnableBrotli: false,
+		BrotliLevel:  brotli.DefaultCompression,
+	}
+}
+
+// Compression compresses responses with gzip or brotli depending on the Accept-Encoding header of
+// the request. Responses are buffered until MinSize bytes have been written to decide if they
+// should be compressed. Middlewares added before Compression observe the compressed response size.
+func Compression(cfg CompressionConfig) gin.HandlerFunc {
+	contentTypes := map[string]bool{}
+	for _, ct := range cfg.ContentTypes {
+		contentTypes[ct] = true
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), cfg.EnableBrotli)
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			cfg:            cfg,
+			contentTypes:   contentTypes,
+			encoding:       encoding,
+		}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding returns the preferred supported encoding, or an empty string if none is accepted.
+func negotiateEncoding(header string, enableBrotli bool) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	if enableBrotli && accepted["br"] {
+		return "br"
+	}
+	if accepted["gzip"] {
+		return "gzip"
+	}
+	return ""
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	cfg          CompressionConfig
+	contentTypes map[string]bool
+	encoding     string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	w.decide()
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.cfg.MinSize {
+			return len(b), nil
+		}
+		w.decide()
+		return len(b), w.flushBuffer()
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	w.decide()
+	w.flushBuffer()
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide writes the header, compressing the response if the content type is allowed and the
+// buffered response is large enough.
+func (w *compressWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	header := w.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType == "" && len(w.buf) > 0 {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(w.buf))
+	}
+	status := w.Status()
+	eligible := w.contentTypes[mediaType] && header.Get("Content-Encoding") == "" && status != http.StatusNoContent && status != http.StatusNotModified
+	if eligible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if eligible && w.encoding != "" && len(w.buf) >= w.cfg.MinSize {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		switch w.encoding {
+		case "br":
+			w.enc = brotli.NewWriterLevel(w.ResponseWriter, w.cfg.BrotliLevel)
+		case "gzip":
+			enc, err := gzip.NewWriterLevel(w.ResponseWriter, w.cfg.GzipLevel)
+			if err != nil {
+				enc = gzip.NewWriter(w.ResponseWriter)
+			}
+			w.enc = enc
+		}
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) flushBuffer() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) close() {
+	if !w.decided && len(w.buf) == 0 && !w.ResponseWriter.Written() && w.status == 0 {
+		// Nothing has been written, leave the response to gin.
+		return
+	}
+	w.decide()
+	w.flushBuffer()
+	if w.enc != nil {
+		w.enc.Close()
+	}
+}
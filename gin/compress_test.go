@@ -0,0 +1,116 @@
+package gin
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	gogin "github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		contentType      string
+		body             string
+		expectedEncoding string
+		expectedVary     string
+	}{
+		{
+			name:             "gzip",
+			acceptEncoding:   "gzip, deflate",
+			contentType:      "text/plain; charset=utf-8",
+			body:             large,
+			expectedEncoding: "gzip",
+			expectedVary:     "Accept-Encoding",
+		},
+		{
+			name:             "brotli preferred",
+			acceptEncoding:   "gzip, br",
+			contentType:      "application/json",
+			body:             large,
+			expectedEncoding: "br",
+			expectedVary:     "Accept-Encoding",
+		},
+		{
+			name:             "gzip rejected",
+			acceptEncoding:   "gzip;q=0",
+			contentType:      "text/plain",
+			body:             large,
+			expectedEncoding: "",
+			expectedVary:     "Accept-Encoding",
+		},
+		{
+			name:             "below minimum size",
+			acceptEncoding:   "gzip",
+			contentType:      "text/plain",
+			body:             "hello world",
+			expectedEncoding: "",
+			expectedVary:     "Accept-Encoding",
+		},
+		{
+			name:             "content type not allowed",
+			acceptEncoding:   "gzip",
+			contentType:      "image/png",
+			body:             large,
+			expectedEncoding: "",
+			expectedVary:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultCompressionConfig()
+			cfg.EnableBrotli = true
+			gogin.SetMode(gogin.TestMode)
+			engine := gogin.New()
+			engine.Use(Compression(cfg))
+			engine.GET("/", func(c *gogin.Context) {
+				c.Data(http.StatusOK, tt.contentType, []byte(tt.body))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tt.expectedEncoding, rec.Header().Get("Content-Encoding"))
+			require.Equal(t, tt.expectedVary, rec.Header().Get("Vary"))
+			var r io.Reader = rec.Body
+			switch tt.expectedEncoding {
+			case "gzip":
+				gr, err := gzip.NewReader(rec.Body)
+				require.NoError(t, err)
+				r = gr
+			case "br":
+				r = brotli.NewReader(rec.Body)
+			}
+			body, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestCompressionNoContent(t *testing.T) {
+	gogin.SetMode(gogin.TestMode)
+	engine := gogin.New()
+	engine.Use(Compression(DefaultCompressionConfig()))
+	engine.DELETE("/", func(c *gogin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+	require.Empty(t, rec.Body.Bytes())
+}
@@ -0,0 +1,190 @@
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ConcurrencyLimitConfig struct {
+	// Name of the limiter, used as label in metrics to differentiate between route groups.
+	Name string
+	// Maximum number of requests handled concurrently.
+	MaxInFlight int
+	// Maximum number of requests waiting for a slot, requests are rejected immediately if zero.
+	MaxQueue int
+	// Maximum duration a request waits in the queue before it is rejected.
+	QueueTimeout time.Duration
+	// Status code of rejected requests, usually 429 or 503.
+	RejectStatus int
+	// Sent as the Retry-After header of rejected requests, not sent if zero.
+	RetryAfter time.Duration
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultConcurrencyLimitConfig() ConcurrencyLimitConfig {
+	return ConcurrencyLimitConfig{
+		Name:         "",
+		MaxInFlight:  100,
+		MaxQueue:     0,
+		QueueTimeout: time.Second,
+		RejectStatus: http.StatusServiceUnavailable,
+		RetryAfter:   time.Second,
+		Registerer:   nil,
+	}
+}
+
+// ConcurrencyLimiter limits the number of requests handled concurrently, for example by a route
+// group with endpoints which are expensive for the database. Use one limiter per route group.
+type ConcurrencyLimiter struct {
+	cfg     ConcurrencyLimitConfig
+	slots   chan struct{}
+	queued  atomic.Int64
+	metrics *concurrencyMetrics
+}
+
+func NewConcurrencyLimiter(cfg ConcurrencyLimitConfig) (*ConcurrencyLimiter, error) {
+	if cfg.MaxInFlight < 1 {
+		return nil, errors.New("max in flight has to be larger than zero")
+	}
+	if cfg.MaxQueue < 0 {
+		return nil, errors.New("max queue cannot be negative")
+	}
+	if cfg.RejectStatus == 0 {
+		cfg.RejectStatus = http.StatusServiceUnavailable
+	}
+	l := &ConcurrencyLimiter{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxInFlight),
+	}
+	if cfg.Registerer != nil {
+		m, err := newConcurrencyMetrics(cfg.Registerer)
+		if err != nil {
+			return nil, err
+		}
+		l.metrics = m
+	}
+	return l, nil
+}
+
+// InFlight returns the number of requests currently being handled.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// QueueDepth returns the number of requests waiting for a slot.
+func (l *ConcurrencyLimiter) QueueDepth() int {
+	return int(l.queued.Load())
+}
+
+// Middleware handles requests while there is a free slot. Requests wait in the queue for a slot
+// when all slots are taken, and are rejected when the queue is full or the queue timeout passes.
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reason, ok := l.acquire(c)
+		if !ok {
+			l.reject(c, reason)
+			return
+		}
+		l.updateInFlight()
+		defer func() {
+			<-l.slots
+			l.updateInFlight()
+		}()
+		c.Next()
+	}
+}
+
+func (l *ConcurrencyLimiter) acquire(c *gin.Context) (string, bool) {
+	select {
+	case l.slots <- struct{}{}:
+		return "", true
+	default:
+	}
+	if l.queued.Add(1) > int64(l.cfg.MaxQueue) {
+		l.queued.Add(-1)
+		return "queue_full", false
+	}
+	l.updateQueueDepth()
+	defer func() {
+		l.queued.Add(-1)
+		l.updateQueueDepth()
+	}()
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return "", true
+	case <-timer.C:
+		return "queue_timeout", false
+	case <-c.Request.Context().Done():
+		return "canceled", false
+	}
+}
+
+func (l *ConcurrencyLimiter) reject(c *gin.Context, reason string) {
+	if l.metrics != nil {
+		l.metrics.rejected.WithLabelValues(l.cfg.Name, reason).Inc()
+	}
+	if l.cfg.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(l.cfg.RetryAfter.Seconds())))
+	}
+	AbortWithProblem(c, Problem{
+		Status: l.cfg.RejectStatus,
+		Detail: "concurrency limit reached",
+	})
+}
+
+func (l *ConcurrencyLimiter) updateInFlight() {
+	if l.metrics != nil {
+		l.metrics.inFlight.WithLabelValues(l.cfg.Name).Set(float64(len(l.slots)))
+	}
+}
+
+func (l *ConcurrencyLimiter) updateQueueDepth() {
+	if l.metrics != nil {
+		l.metrics.queueDepth.WithLabelValues(l.cfg.Name).Set(float64(l.queued.Load()))
+	}
+}
+
+type concurrencyMetrics struct {
+	inFlight   *prometheus.GaugeVec
+	queueDepth *prometheus.GaugeVec
+	rejected   *prometheus.CounterVec
+}
+
+func newConcurrencyMetrics(reg prometheus.Registerer) (*concurrencyMetrics, error) {
+	inFlight, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_concurrency_limit_in_flight",
+		Help: "Number of requests handled by the concurrency limiter.",
+	}, []string{"limiter"}))
+	if err != nil {
+		return nil, err
+	}
+	queueDepth, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_concurrency_limit_queue_depth",
+		Help: "Number of requests waiting for a slot in the concurrency limiter.",
+	}, []string{"limiter"}))
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_concurrency_limit_rejected_total",
+		Help: "Total number of requests rejected by the concurrency limiter by reason.",
+	}, []string{"limiter", "reason"}))
+	if err != nil {
+		return nil, err
+	}
+	return &concurrencyMetrics{
+		inFlight:   inFlight,
+		queueDepth: queueDepth,
+		rejected:   rejected,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultConcurrencyLimitConfig()
+	cfg.Name = "db"
+	cfg.MaxInFlight = 1
+	cfg.MaxQueue = 1
+	cfg.QueueTimeout = 50 * time.Millisecond
+	cfg.RejectStatus = http.StatusTooManyRequests
+	cfg.Registerer = reg
+	limiter, err := NewConcurrencyLimiter(cfg)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(limiter.Middleware())
+	engine.GET("/", func(c *gin.Context) {
+		<-release
+		c.Status(http.StatusOK)
+	})
+	serve := func() <-chan int {
+		codes := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes <- rec.Code
+		}()
+		return codes
+	}
+
+	first := serve()
+	require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+	second := serve()
+	require.Eventually(t, func() bool { return limiter.QueueDepth() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.metrics.queueDepth.WithLabelValues("db")))
+
+	// The queue is full.
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	release <- struct{}{}
+	require.Equal(t, http.StatusOK, <-first)
+	release <- struct{}{}
+	require.Equal(t, http.StatusOK, <-second)
+
+	// The queued request times out while the slot is taken.
+	third := serve()
+	require.Eventually(t, func() bool { return limiter.InFlight() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, http.StatusTooManyRequests, <-serve())
+	release <- struct{}{}
+	require.Equal(t, http.StatusOK, <-third)
+
+	require.Equal(t, 0, limiter.InFlight())
+	require.Equal(t, 0, limiter.QueueDepth())
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.metrics.rejected.WithLabelValues("db", "queue_full")))
+	require.Equal(t, 1.0, testutil.ToFloat64(limiter.metrics.rejected.WithLabelValues("db", "queue_timeout")))
+}
+
+func TestNewConcurrencyLimiterInvalidConfig(t *testing.T) {
+	cfg := DefaultConcurrencyLimitConfig()
+	cfg.MaxInFlight = 0
+	_, err := NewConcurrencyLimiter(cfg)
+	require.EqualError(t, err, "max in flight has to be larger than zero")
+}
@@ -0,0 +1,42 @@
+package gin
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagsContext returns the context used by handlers for a request, given the subject of
+// the request which is empty if not known. Use featureflags.ContextFunc to create one for a provider.
+type FeatureFlagsContext func(ctx context.Context, subject string) context.Context
+
+type FeatureFlagsConfig struct {
+	// Context keys checked in order for the subject used in percentage rollouts, such as the
+	// OIDC subject set by authentication middleware.
+	SubjectKeys []string
+}
+
+func DefaultFeatureFlagsConfig() FeatureFlagsConfig {
+	return FeatureFlagsConfig{
+		SubjectKeys: []string{APIKeyOwnerKey, BasicAuthUserKey},
+	}
+}
+
+// FeatureFlags adds the provider and the subject of the request to the request context, so that
+// handlers can call featureflags.IsEnabled(c.Request.Context(), ...). The middleware has to be
+// added after the authentication middleware which sets the subject. It panics if withContext is nil.
+func FeatureFlags(withContext FeatureFlagsContext, cfg FeatureFlagsConfig) gin.HandlerFunc {
+	if withContext == nil {
+		panic("feature flags context function cannot be nil")
+	}
+	return func(c *gin.Context) {
+		subject := ""
+		for _, key := range cfg.SubjectKeys {
+			if subject = c.GetString(key); subject != "" {
+				break
+			}
+		}
+		c.Request = c.Request.WithContext(withContext(c.Request.Context(), subject))
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type subjectKey struct{}
+
+func TestFeatureFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("oidc.subject", c.GetHeader("X-Subject"))
+	})
+	cfg := DefaultFeatureFlagsConfig()
+	cfg.SubjectKeys = []string{"oidc.subject"}
+	withContext := func(ctx context.Context, subject string) context.Context {
+		return context.WithValue(ctx, subjectKey{}, subject)
+	}
+	engine.Use(FeatureFlags(withContext, cfg))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Context().Value(subjectKey{}).(string))
+	})
+
+	for _, subject := range []string{"foo", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Subject", subject)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, subject, rec.Body.String())
+	}
+}
+
+func TestFeatureFlagsWithoutContext(t *testing.T) {
+	require.Panics(t, func() {
+		FeatureFlags(nil, DefaultFeatureFlagsConfig())
+	})
+}
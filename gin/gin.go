@@ -1,22 +1,37 @@
 package gin
 
 import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net"
 	"regexp"
+	"strings"
 
+	"github.com/andybalholm/brotli"
 	gogin "github.com/gin-gonic/gin"
 	"github.com/go-logr/logr"
-	metrics "github.com/slok/go-http-metrics/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
 	metricsmiddleware "github.com/slok/go-http-metrics/middleware"
 	ginmetricsmiddleware "github.com/slok/go-http-metrics/middleware/gin"
 )
 
-var (
-	recorder = metrics.NewRecorder(metrics.Config{})
-)
-
 type Config struct {
-	LogConfig     LogConfig
-	MetricsConfig MetricsConfig
+	LogConfig         LogConfig
+	MetricsConfig     MetricsConfig
+	CompressionConfig CompressionConfig
+	ErrorReportConfig ErrorReportConfig
+	LoadShedConfig    LoadShedConfig
+	// Network origins of proxies trusted to set client IP headers, as IP addresses or CIDRs.
+	// No proxies are trusted if empty, so clients cannot spoof their IP with X-Forwarded-For.
+	TrustedProxies []string
+	// Header set by a trusted platform containing the client IP, such as gin.PlatformCloudflare.
+	TrustedPlatform string
+	// Headers used to read the client IP when the request is from a trusted proxy,
+	// X-Forwarded-For and X-Real-IP if nil.
+	RemoteIPHeaders []string
+	// Route paths excluded from request logs and metrics, such as /healthz or /static/*filepath.
+	SkipObservabilityPaths []string
 }
 
 type LogConfig struct {
@@ -30,6 +45,24 @@ type LogConfig struct {
 	IncludeClientIP bool
 	// Context keys to include in request log.
 	IncludeKeys []string
+	// Keys of the request log fields, empty names use the default key.
+	FieldNames FieldNames
+	// Verbosity of the request logger for matching requests, such as to debug a single endpoint.
+	VerbosityOverride VerbosityOverrideConfig
+}
+
+type VerbosityOverrideConfig struct {
+	// Regexes matched against the request path to enable the override.
+	Paths []*regexp.Regexp
+	// Header enabling the override when set to one of the tokens, such as X-Debug-Trace.
+	Header string
+	// Tokens allowed in the header, the header is ignored if empty.
+	Tokens []string
+	// Logr verbosity of the request logger when the override is enabled, such as 2.
+	Verbosity int
+	// Returns a logger logging up to the verbosity, such as logging.WithVerbosity. The override is
+	// disabled if nil.
+	WithVerbosity func(log logr.Logger, v int) logr.Logger
 }
 
 type MetricsConfig struct {
@@ -37,6 +70,22 @@ type MetricsConfig struct {
 	Service string
 	// Handler ID to use when using dynamic path parameters.
 	HandlerID string
+	// Registerer the request metrics are registered with.
+	Registerer prometheus.Registerer
+	// Buckets of the request duration histogram, prometheus.DefBuckets if empty.
+	DurationBuckets []float64
+	// Growth factor between native histogram buckets of the request duration histogram, such as
+	// 1.1. Native histograms are disabled if zero, classic buckets are exposed in both cases.
+	NativeHistogramBucketFactor float64
+	// Maximum number of native histogram buckets, unlimited if zero.
+	NativeHistogramMaxBucketNumber uint32
+	// Returns exemplar labels for a request duration observation, such as the trace ID of the
+	// span in the request context. No exemplar is attached if nil or if no labels are returned.
+	// Exemplars are only exposed when metrics are served in the OpenMetrics format.
+	Exemplar func(ctx context.Context) prometheus.Labels
+	// Should the request duration and response size metrics include a protocol label with the
+	// HTTP version of the request, such as HTTP/1.1 or HTTP/2.0.
+	ProtocolLabel bool
 }
 
 func DefaultConfig() Config {
@@ -46,23 +95,140 @@ func DefaultConfig() Config {
 			PathFilter:      nil,
 			IncludeLatency:  true,
 			IncludeClientIP: false,
+			FieldNames:      DefaultFieldNames(),
+			VerbosityOverride: VerbosityOverrideConfig{
+				Paths:         nil,
+				Header:        "",
+				Tokens:        nil,
+				Verbosity:     0,
+				WithVerbosity: nil,
+			},
 		},
 		MetricsConfig: MetricsConfig{
-			Service:   "",
-			HandlerID: "",
+			Service:                        "",
+			HandlerID:                      "",
+			Registerer:                     prometheus.DefaultRegisterer,
+			DurationBuckets:                nil,
+			NativeHistogramBucketFactor:    0,
+			NativeHistogramMaxBucketNumber: 0,
+			Exemplar:                       nil,
+			ProtocolLabel:                  false,
 		},
+		CompressionConfig:      DefaultCompressionConfig(),
+		ErrorReportConfig:      DefaultErrorReportConfig(),
+		LoadShedConfig:         DefaultLoadShedConfig(),
+		TrustedProxies:         nil,
+		TrustedPlatform:        "",
+		RemoteIPHeaders:        defaultRemoteIPHeaders(),
+		SkipObservabilityPaths: nil,
+	}
+}
+
+// Validate returns all invalid values in the config.
+func (c Config) Validate() error {
+	msgs := []string{}
+	for i, proxy := range c.TrustedProxies {
+		if !isIPOrCIDR(proxy) {
+			msgs = append(msgs, fmt.Sprintf("TrustedProxies[%d] has to be an IP address or a CIDR, got %q", i, proxy))
+		}
+	}
+	// The status code is only used when errors are reported.
+	if c.ErrorReportConfig.Reporter != nil {
+		msgs = validateRange(msgs, "ErrorReportConfig.MinStatusCode", c.ErrorReportConfig.MinStatusCode, 100, 599)
+	}
+	if c.CompressionConfig.Enabled {
+		if c.CompressionConfig.MinSize < 0 {
+			msgs = append(msgs, fmt.Sprintf("CompressionConfig.MinSize has to be at least 0, got %d", c.CompressionConfig.MinSize))
+		}
+		msgs = validateRange(msgs, "CompressionConfig.GzipLevel", c.CompressionConfig.GzipLevel, gzip.HuffmanOnly, gzip.BestCompression)
+		if c.CompressionConfig.EnableBrotli {
+			msgs = validateRange(msgs, "CompressionConfig.BrotliLevel", c.CompressionConfig.BrotliLevel, brotli.BestSpeed, brotli.BestCompression)
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(msgs, ", "))
+	}
+	return nil
+}
+
+func validateRange(msgs []string, field string, value, min, max int) []string {
+	if value < min || value > max {
+		return append(msgs, fmt.Sprintf("%s has to be between %d and %d, got %d", field, min, max, value))
+	}
+	return msgs
+}
+
+func isIPOrCIDR(value string) bool {
+	if value == "" || net.ParseIP(value) != nil {
+		return true
 	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
 }
 
+func defaultRemoteIPHeaders() []string {
+	return []string{"X-Forwarded-For", "X-Real-IP"}
+}
+
+// NewEngine returns an engine with the shared middleware chain. It panics if the config is
+// invalid or the metrics cannot be registered, use NewEngineE to handle the error instead.
 func NewEngine(cfg Config) *gogin.Engine {
+	engine, err := NewEngineE(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// NewEngineE is like NewEngine but returns an error if the config is invalid or the metrics
+// cannot be registered.
+func NewEngineE(cfg Config) (*gogin.Engine, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
 	gogin.SetMode(gogin.ReleaseMode)
+	recorder, err := newRecorder(cfg.MetricsConfig)
+	if err != nil {
+		return nil, err
+	}
 	mdlw := metricsmiddleware.New(metricsmiddleware.Config{
 		Service:  cfg.MetricsConfig.Service,
 		Recorder: recorder,
 	})
 	engine := gogin.New()
+	err = engine.SetTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	engine.TrustedPlatform = cfg.TrustedPlatform
+	engine.RemoteIPHeaders = cfg.RemoteIPHeaders
+	if engine.RemoteIPHeaders == nil {
+		engine.RemoteIPHeaders = defaultRemoteIPHeaders()
+	}
+	if len(cfg.SkipObservabilityPaths) > 0 {
+		engine.Use(skipObservabilityPaths(cfg.SkipObservabilityPaths))
+	}
 	engine.Use(Logger(cfg.LogConfig))
-	engine.Use(ginmetricsmiddleware.Handler(cfg.MetricsConfig.HandlerID, mdlw))
-	engine.Use(gogin.Recovery())
-	return engine
+	if cfg.MetricsConfig.ProtocolLabel {
+		engine.Use(protocolContext())
+	}
+	engine.Use(withoutSkipped(ginmetricsmiddleware.Handler(cfg.MetricsConfig.HandlerID, mdlw)))
+	if cfg.LoadShedConfig.Enabled {
+		shedder, err := NewLoadShedder(cfg.LoadShedConfig)
+		if err != nil {
+			return nil, err
+		}
+		// Skipped paths such as health checks are never shed.
+		engine.Use(withoutSkipped(shedder.Middleware()))
+	}
+	if cfg.CompressionConfig.Enabled {
+		engine.Use(Compression(cfg.CompressionConfig))
+	}
+	// Recovery is used even without a reporter so that panics are logged by the request logger.
+	engine.Use(Recovery(cfg.ErrorReportConfig))
+	if cfg.ErrorReportConfig.Reporter != nil {
+		engine.Use(ReportErrors(cfg.ErrorReportConfig))
+	}
+	return engine, nil
 }
@@ -0,0 +1,120 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gogin "github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func TestNewEngineTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name            string
+		trustedProxies  []string
+		trustedPlatform string
+		headers         map[string]string
+		expectedIP      string
+	}{
+		{
+			name:           "no proxies trusted by default",
+			trustedProxies: nil,
+			headers:        map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedIP:     "192.0.2.1",
+		},
+		{
+			name:           "no trusted proxies",
+			trustedProxies: []string{},
+			headers:        map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedIP:     "192.0.2.1",
+		},
+		{
+			name:           "trusted proxy",
+			trustedProxies: []string{"192.0.2.0/24"},
+			headers:        map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedIP:     "10.0.0.1",
+		},
+		{
+			name:            "trusted platform",
+			trustedPlatform: gogin.PlatformCloudflare,
+			headers:         map[string]string{"CF-Connecting-IP": "10.0.0.2"},
+			expectedIP:      "10.0.0.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.TrustedProxies = tt.trustedProxies
+			cfg.TrustedPlatform = tt.trustedPlatform
+			engine := NewEngine(cfg)
+			engine.GET("/", func(c *gogin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			require.Equal(t, tt.expectedIP, rec.Body.String())
+		})
+	}
+}
+
+func TestNewEngineInvalidTrustedProxy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TrustedProxies = []string{"10.0.0.0/8", "foo"}
+	_, err := NewEngineE(cfg)
+	require.EqualError(t, err, `invalid configuration: TrustedProxies[1] has to be an IP address or a CIDR, got "foo"`)
+	require.Panics(t, func() {
+		NewEngine(cfg)
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.CompressionConfig.Enabled = true
+	cfg.CompressionConfig.GzipLevel = 10
+	cfg.ErrorReportConfig.MinStatusCode = 0
+	err := cfg.Validate()
+	require.EqualError(t, err, "invalid configuration: CompressionConfig.GzipLevel has to be between -2 and 9, got 10")
+
+	cfg.ErrorReportConfig.Reporter = ErrorReporterFunc(func(ctx context.Context, report ErrorReport) {})
+	err = cfg.Validate()
+	require.EqualError(t, err, "invalid configuration: ErrorReportConfig.MinStatusCode has to be between 100 and 599, got 0, CompressionConfig.GzipLevel has to be between -2 and 9, got 10")
+}
+
+func TestNewEngineEmptyConfig(t *testing.T) {
+	engine, err := NewEngineE(Config{})
+	require.NoError(t, err)
+	engine.GET("/", func(c *gogin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNewEnginePanicLog(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.LogConfig.Logger = buflogr.NewWithBuffer(&buf)
+	cfg.LogConfig.IncludeLatency = false
+	cfg.MetricsConfig.Service = "panic-log"
+	engine := NewEngine(cfg)
+	engine.GET("/panic", func(c *gogin.Context) {
+		panic("boom")
+	})
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("ERROR ")))
+	require.Contains(t, buf.String(), "ERROR panic: boom path /panic status 500 method GET stack goroutine")
+}
@@ -0,0 +1,199 @@
+package gintest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	pkggin "github.com/xenitab/pkg/gin"
+)
+
+// Engine is an engine with the default middleware chain and a logger capturing request logs.
+type Engine struct {
+	*gin.Engine
+	Logs *LogRecorder
+}
+
+// NewEngine creates an engine with pkggin.NewEngineE, replacing the logger in cfg with a
+// recorder. Use pkggin.DefaultConfig() to get the same middleware as a service would.
+func NewEngine(t testing.TB, cfg pkggin.Config) *Engine {
+	t.Helper()
+	logs := &LogRecorder{}
+	cfg.LogConfig.Logger = logr.New(logs)
+	engine, err := pkggin.NewEngineE(cfg)
+	if err != nil {
+		t.Fatalf("could not create engine: %v", err)
+	}
+	return &Engine{
+		Engine: engine,
+		Logs:   logs,
+	}
+}
+
+// Request sends a request to the engine and returns the recorded response.
+func (e *Engine) Request(method, path string, body io.Reader, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// LogEntry is a single captured log line.
+type LogEntry struct {
+	Message string
+	// Error passed to Logger.Error, nil for info logs.
+	Error error
+	// Verbosity level of info logs.
+	Level int
+	// Key value pairs including those added with WithValues.
+	Values map[string]interface{}
+}
+
+// LogRecorder is a logr.LogSink capturing all log entries.
+type LogRecorder struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	values  []interface{}
+	parent  *LogRecorder
+}
+
+// Entries returns the captured log entries.
+func (r *LogRecorder) Entries() []LogEntry {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	return append([]LogEntry{}, root.entries...)
+}
+
+// Reset removes all captured log entries.
+func (r *LogRecorder) Reset() {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.entries = nil
+}
+
+func (r *LogRecorder) Init(info logr.RuntimeInfo) {}
+
+func (r *LogRecorder) Enabled(level int) bool {
+	return true
+}
+
+func (r *LogRecorder) Info(level int, msg string, keysAndValues ...interface{}) {
+	r.record(LogEntry{Message: msg, Level: level}, keysAndValues)
+}
+
+func (r *LogRecorder) Error(err error, msg string, keysAndValues ...interface{}) {
+	r.record(LogEntry{Message: msg, Error: err}, keysAndValues)
+}
+
+func (r *LogRecorder) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	values := append(append([]interface{}{}, r.values...), keysAndValues...)
+	return &LogRecorder{values: values, parent: r.root()}
+}
+
+func (r *LogRecorder) WithName(name string) logr.LogSink {
+	return r
+}
+
+func (r *LogRecorder) root() *LogRecorder {
+	if r.parent != nil {
+		return r.parent
+	}
+	return r
+}
+
+func (r *LogRecorder) record(entry LogEntry, keysAndValues []interface{}) {
+	entry.Values = map[string]interface{}{}
+	kvs := append(append([]interface{}{}, r.values...), keysAndValues...)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		entry.Values[fmt.Sprint(kvs[i])] = kvs[i+1]
+	}
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.entries = append(root.entries, entry)
+}
+
+// Metric returns the value of the counter or gauge, or the sample count of the histogram or
+// summary, with the given name and labels in the default registry. Other labels of the metric
+// are ignored, and the values of all matching metrics are summed. The registry is shared by all
+// tests, so compare against the value before the request rather than an absolute value.
+func Metric(t testing.TB, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("could not gather metrics: %v", err)
+	}
+	sum := 0.0
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if !matchLabels(m, labels) {
+				continue
+			}
+			switch {
+			case m.Counter != nil:
+				sum += m.Counter.GetValue()
+			case m.Gauge != nil:
+				sum += m.Gauge.GetValue()
+			case m.Histogram != nil:
+				sum += float64(m.Histogram.GetSampleCount())
+			case m.Summary != nil:
+				sum += float64(m.Summary.GetSampleCount())
+			}
+		}
+	}
+	return sum
+}
+
+func matchLabels(m *dto.Metric, labels map[string]string) bool {
+	found := 0
+	for _, pair := range m.GetLabel() {
+		v, ok := labels[pair.GetName()]
+		if !ok {
+			continue
+		}
+		if v != pair.GetValue() {
+			return false
+		}
+		found++
+	}
+	return found == len(labels)
+}
+
+// RequireProblem fails the test unless the response is a problem details response with the
+// status code, and returns the decoded problem.
+func RequireProblem(t testing.TB, rec *httptest.ResponseRecorder, statusCode int) pkggin.Problem {
+	t.Helper()
+	if rec.Code != statusCode {
+		t.Fatalf("expected status code %d, got %d: %s", statusCode, rec.Code, rec.Body.String())
+	}
+	mediaType, _, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != pkggin.ProblemContentType {
+		t.Fatalf("expected content type %s, got %q", pkggin.ProblemContentType, rec.Header().Get("Content-Type"))
+	}
+	problem := pkggin.Problem{}
+	err = json.Unmarshal(rec.Body.Bytes(), &problem)
+	if err != nil {
+		t.Fatalf("could not decode problem: %v", err)
+	}
+	if problem.Status != statusCode {
+		t.Fatalf("expected problem status %d, got %d", statusCode, problem.Status)
+	}
+	return problem
+}
@@ -0,0 +1,55 @@
+package gintest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	pkggin "github.com/xenitab/pkg/gin"
+)
+
+type item struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestEngine(t *testing.T) {
+	cfg := pkggin.DefaultConfig()
+	cfg.MetricsConfig.Service = "gintest"
+	e := NewEngine(t, cfg)
+	e.GET("/items/:id", func(c *gin.Context) {
+		pkggin.FromContextOrDiscard(c).WithValues("id", c.Param("id")).Info("found item")
+		c.Status(http.StatusOK)
+	})
+	e.POST("/items", func(c *gin.Context) {
+		req := item{}
+		if !pkggin.Bind(c, &req) {
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	labels := map[string]string{"service": "gintest", "handler": "/items/1", "code": "200"}
+	before := Metric(t, "http_request_duration_seconds", labels)
+	rec := e.Request(http.MethodGet, "/items/1", nil, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, before+1, Metric(t, "http_request_duration_seconds", labels))
+
+	entries := e.Logs.Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "found item", entries[0].Message)
+	require.Equal(t, "1", entries[0].Values["id"])
+	require.Equal(t, "/items/1", entries[1].Values["path"])
+	require.Equal(t, 200, entries[1].Values["status"])
+	require.NoError(t, entries[1].Error)
+
+	e.Logs.Reset()
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	rec = e.Request(http.MethodPost, "/items", strings.NewReader(`{}`), header)
+	problem := RequireProblem(t, rec, http.StatusUnprocessableEntity)
+	require.Equal(t, "name", problem.Errors[0].Field)
+	entries = e.Logs.Entries()
+	require.Len(t, entries, 1)
+	require.Error(t, entries[0].Error)
+}
@@ -0,0 +1,207 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LoadShedKey is the context key set to true on requests rejected by the load shedder, add it to
+// LogConfig.IncludeKeys to log the shed decision.
+const LoadShedKey = "loadshed.shed"
+
+type LoadShedConfig struct {
+	// Shed load in the engine created by NewEngine.
+	Enabled bool
+	// Latency target of the smoothed request latency, the limit is decreased while it is exceeded.
+	TargetLatency time.Duration
+	// Lower bound of the in-flight limit, so that some requests are always handled.
+	MinInFlight int
+	// Upper bound and initial value of the in-flight limit.
+	MaxInFlight int
+	// Weight of each latency sample in the exponentially weighted moving average, between 0 and 1.
+	Smoothing float64
+	// Factor the limit is multiplied with when the latency target is exceeded, between 0 and 1.
+	// The limit is decreased at most once per target latency.
+	DecreaseFactor float64
+	// Sent as the Retry-After header of rejected requests, not sent if zero.
+	RetryAfter time.Duration
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultLoadShedConfig() LoadShedConfig {
+	return LoadShedConfig{
+		Enabled:        false,
+		TargetLatency:  500 * time.Millisecond,
+		MinInFlight:    10,
+		MaxInFlight:    1000,
+		Smoothing:      0.1,
+		DecreaseFactor: 0.9,
+		RetryAfter:     time.Second,
+		Registerer:     nil,
+	}
+}
+
+// LoadShedder rejects requests when the number of requests in flight exceeds an adaptive limit.
+// The limit is increased by one for each request completing within the latency target and
+// decreased multiplicatively while the smoothed latency exceeds the target, so that the service
+// degrades by rejecting excess requests instead of slowing down for everyone.
+type LoadShedder struct {
+	cfg     LoadShedConfig
+	now     func() time.Time
+	metrics *loadShedMetrics
+
+	mu           sync.Mutex
+	inFlight     int
+	limit        float64
+	latency      float64
+	lastDecrease time.Time
+}
+
+func NewLoadShedder(cfg LoadShedConfig) (*LoadShedder, error) {
+	if cfg.TargetLatency <= 0 {
+		return nil, errors.New("target latency has to be larger than zero")
+	}
+	if cfg.MinInFlight < 1 || cfg.MaxInFlight < cfg.MinInFlight {
+		return nil, errors.New("min in flight has to be larger than zero and not larger than max in flight")
+	}
+	if cfg.Smoothing <= 0 || cfg.Smoothing > 1 {
+		return nil, errors.New("smoothing has to be larger than 0 and at most 1")
+	}
+	if cfg.DecreaseFactor <= 0 || cfg.DecreaseFactor >= 1 {
+		return nil, errors.New("decrease factor has to be between 0 and 1")
+	}
+	s := &LoadShedder{
+		cfg:   cfg,
+		now:   time.Now,
+		limit: float64(cfg.MaxInFlight),
+	}
+	if cfg.Registerer != nil {
+		m, err := newLoadShedMetrics(cfg.Registerer)
+		if err != nil {
+			return nil, err
+		}
+		s.metrics = m
+		s.metrics.limit.Set(s.limit)
+	}
+	return s, nil
+}
+
+// Limit returns the current in-flight limit.
+func (s *LoadShedder) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int(s.limit)
+}
+
+// Middleware rejects requests with 503 while the in-flight limit is reached.
+func (s *LoadShedder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inFlight, limit, ok := s.acquire()
+		if !ok {
+			s.reject(c, inFlight, limit)
+			return
+		}
+		start := s.now()
+		defer func() {
+			s.release(s.now().Sub(start))
+		}()
+		c.Next()
+	}
+}
+
+func (s *LoadShedder) acquire() (int, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limit := int(s.limit)
+	if s.inFlight >= limit {
+		return s.inFlight, limit, false
+	}
+	s.inFlight++
+	return s.inFlight, limit, true
+}
+
+func (s *LoadShedder) release(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	s.observe(latency)
+}
+
+// observe updates the latency average and the limit, it has to be called with the lock held.
+func (s *LoadShedder) observe(latency time.Duration) {
+	if s.latency == 0 {
+		s.latency = latency.Seconds()
+	} else {
+		s.latency += s.cfg.Smoothing * (latency.Seconds() - s.latency)
+	}
+	now := s.now()
+	switch {
+	case s.latency <= s.cfg.TargetLatency.Seconds():
+		s.limit = math.Min(s.limit+1, float64(s.cfg.MaxInFlight))
+	case now.Sub(s.lastDecrease) >= s.cfg.TargetLatency:
+		s.limit = math.Max(s.limit*s.cfg.DecreaseFactor, float64(s.cfg.MinInFlight))
+		s.lastDecrease = now
+	}
+	if s.metrics != nil {
+		s.metrics.limit.Set(s.limit)
+		s.metrics.latency.Set(s.latency)
+	}
+}
+
+func (s *LoadShedder) reject(c *gin.Context, inFlight, limit int) {
+	if s.metrics != nil {
+		s.metrics.shed.Inc()
+	}
+	c.Set(LoadShedKey, true)
+	if s.cfg.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(s.cfg.RetryAfter.Seconds())))
+	}
+	AbortWithProblem(c, Problem{
+		Status: http.StatusServiceUnavailable,
+		Detail: fmt.Sprintf("load shed with %d requests in flight and limit %d", inFlight, limit),
+	})
+}
+
+type loadShedMetrics struct {
+	limit   prometheus.Gauge
+	latency prometheus.Gauge
+	shed    prometheus.Counter
+}
+
+func newLoadShedMetrics(reg prometheus.Registerer) (*loadShedMetrics, error) {
+	limit, err := register(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_load_shed_limit",
+		Help: "Current in-flight request limit of the load shedder.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	latency, err := register(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_load_shed_latency_seconds",
+		Help: "Smoothed request latency observed by the load shedder.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	shed, err := register(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_load_shed_rejected_total",
+		Help: "Total number of requests rejected by the load shedder.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &loadShedMetrics{
+		limit:   limit,
+		latency: latency,
+		shed:    shed,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadShedderLimit(t *testing.T) {
+	cfg := DefaultLoadShedConfig()
+	cfg.TargetLatency = 100 * time.Millisecond
+	cfg.MinInFlight = 2
+	cfg.MaxInFlight = 10
+	cfg.Smoothing = 1
+	cfg.DecreaseFactor = 0.5
+	s, err := NewLoadShedder(cfg)
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.observe(200 * time.Millisecond)
+	require.Equal(t, 5, s.Limit())
+	// The limit is only decreased once per target latency.
+	s.observe(200 * time.Millisecond)
+	require.Equal(t, 5, s.Limit())
+	now = now.Add(100 * time.Millisecond)
+	s.observe(200 * time.Millisecond)
+	require.Equal(t, 2, s.Limit())
+	now = now.Add(100 * time.Millisecond)
+	s.observe(200 * time.Millisecond)
+	require.Equal(t, 2, s.Limit())
+
+	s.observe(50 * time.Millisecond)
+	require.Equal(t, 3, s.Limit())
+	for i := 0; i < 20; i++ {
+		s.observe(50 * time.Millisecond)
+	}
+	require.Equal(t, 10, s.Limit())
+}
+
+func TestLoadShedderMiddleware(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultLoadShedConfig()
+	cfg.Enabled = true
+	cfg.MinInFlight = 1
+	cfg.MaxInFlight = 1
+	cfg.Registerer = reg
+	engineCfg := DefaultConfig()
+	engineCfg.MetricsConfig.Registerer = prometheus.NewRegistry()
+	engineCfg.LoadShedConfig = cfg
+	engineCfg.SkipObservabilityPaths = []string{"/healthz"}
+	engine := NewEngine(engineCfg)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	engine.GET("/", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/healthz", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	first := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		first <- rec.Code
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	require.Contains(t, rec.Body.String(), "load shed with 1 requests in flight and limit 1")
+
+	// Skipped paths are never shed.
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-first)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	rejected := 0.0
+	for _, family := range families {
+		if family.GetName() == "http_load_shed_rejected_total" {
+			rejected = family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	require.Equal(t, 1.0, rejected)
+}
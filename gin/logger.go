@@ -1,6 +1,8 @@
 package gin
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
 	"time"
 
@@ -10,13 +12,87 @@ import (
 
 const loggerKey = "logr.logger"
 
+// FieldNames are the keys of the fields in the request log.
+type FieldNames struct {
+	Path     string
+	Status   string
+	Method   string
+	Latency  string
+	ClientIP string
+	Stack    string
+}
+
+func DefaultFieldNames() FieldNames {
+	return FieldNames{
+		Path:     "path",
+		Status:   "status",
+		Method:   "method",
+		Latency:  "latency",
+		ClientIP: "ip",
+		Stack:    "stack",
+	}
+}
+
+// ECSFieldNames returns field names following the Elastic Common Schema.
+func ECSFieldNames() FieldNames {
+	return FieldNames{
+		Path:     "url.path",
+		Status:   "http.response.status_code",
+		Method:   "http.request.method",
+		Latency:  "event.duration",
+		ClientIP: "client.ip",
+		Stack:    "error.stack_trace",
+	}
+}
+
+// OTelFieldNames returns field names following the OpenTelemetry semantic conventions.
+func OTelFieldNames() FieldNames {
+	return FieldNames{
+		Path:     "url.path",
+		Status:   "http.response.status_code",
+		Method:   "http.request.method",
+		Latency:  "http.server.request.duration",
+		ClientIP: "client.address",
+		Stack:    "exception.stacktrace",
+	}
+}
+
+// withDefaults returns the field names with empty names replaced by the default names.
+func (f FieldNames) withDefaults() FieldNames {
+	d := DefaultFieldNames()
+	if f.Path == "" {
+		f.Path = d.Path
+	}
+	if f.Status == "" {
+		f.Status = d.Status
+	}
+	if f.Method == "" {
+		f.Method = d.Method
+	}
+	if f.Latency == "" {
+		f.Latency = d.Latency
+	}
+	if f.ClientIP == "" {
+		f.ClientIP = d.ClientIP
+	}
+	if f.Stack == "" {
+		f.Stack = d.Stack
+	}
+	return f
+}
+
 func Logger(cfg LogConfig) gin.HandlerFunc {
+	names := cfg.FieldNames.withDefaults()
 	return func(c *gin.Context) {
-		// Inject loggin in gin context
-		c.Set(loggerKey, cfg.Logger)
+		// Inject logger in gin and request context
+		log := cfg.Logger
+		if cfg.VerbosityOverride.enabled(c) {
+			log = cfg.VerbosityOverride.WithVerbosity(log, cfg.VerbosityOverride.Verbosity)
+		}
+		SetLogger(c, log)
 
-		// Do not log if path matches filter.
-		if cfg.PathFilter != nil && cfg.PathFilter.MatchString(c.Request.URL.Path) {
+		// Do not log if path matches filter or the route skips observability.
+		if (cfg.PathFilter != nil && cfg.PathFilter.MatchString(c.Request.URL.Path)) || isObservabilitySkipped(c) {
 			c.Next()
 			return
 		}
@@ -33,12 +109,12 @@ func Logger(cfg LogConfig) gin.HandlerFunc {
 		// Log request
 		path := c.Request.URL.Path
 		statusCode := c.Writer.Status()
-		kvs := []interface{}{"path", path, "status", statusCode, "method", c.Request.Method}
+		kvs := []interface{}{names.Path, path, names.Status, statusCode, names.Method, c.Request.Method}
 		if cfg.IncludeLatency {
-			kvs = append(kvs, "latency", latency)
+			kvs = append(kvs, names.Latency, latency)
 		}
 		if cfg.IncludeClientIP {
-			kvs = append(kvs, "ip", c.ClientIP())
+			kvs = append(kvs, names.ClientIP, c.ClientIP())
 		}
 		for _, key := range cfg.IncludeKeys {
 			v, ok := c.Keys[key]
@@ -48,6 +124,10 @@ func Logger(cfg LogConfig) gin.HandlerFunc {
 			kvs = append(kvs, key, v)
 		}
 
+		if stack := c.GetString(panicStackKey); stack != "" {
+			kvs = append(kvs, names.Stack, stack)
+		}
+
 		// Info log if 2xx response
 		if statusCode >= 200 && statusCode < 300 {
 			cfg.Logger.Info("", kvs...)
@@ -63,10 +143,52 @@ func Logger(cfg LogConfig) gin.HandlerFunc {
 	}
 }
 
+// enabled returns true if the request path matches one of the paths or the header contains
+// one of the tokens.
+func (v VerbosityOverrideConfig) enabled(c *gin.Context) bool {
+	if v.WithVerbosity == nil {
+		return false
+	}
+	for _, re := range v.Paths {
+		if re.MatchString(c.Request.URL.Path) {
+			return true
+		}
+	}
+	if v.Header == "" {
+		return false
+	}
+	token := c.GetHeader(v.Header)
+	if token == "" {
+		return false
+	}
+	match := false
+	// Compare against all tokens so the time taken does not reveal which token matched.
+	for _, t := range v.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			match = true
+		}
+	}
+	return match
+}
+
+// SetLogger replaces the request logger, such as with a logger with additional request fields,
+// in both the gin context and the request context for the remaining handlers.
+func SetLogger(c *gin.Context, log logr.Logger) {
+	c.Set(loggerKey, log)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(logr.NewContext(c.Request.Context(), log))
+	}
+}
+
+// FromContextOrDiscard returns the request logger from the gin context, falling back to the
+// request context, or a discard logger if no logger has been set.
 func FromContextOrDiscard(c *gin.Context) logr.Logger {
 	logVal, ok := c.Get(loggerKey)
 	if !ok {
-		return logr.Discard()
+		if c.Request == nil {
+			return logr.Discard()
+		}
+		return LoggerFromContext(c.Request.Context())
 	}
 	log, ok := logVal.(logr.Logger)
 	if !ok {
@@ -74,3 +196,10 @@ func FromContextOrDiscard(c *gin.Context) logr.Logger {
 	}
 	return log
 }
+
+// LoggerFromContext returns the request logger from a context derived from c.Request.Context(),
+// so that layers without access to the gin context can log with the request fields. A discard
+// logger is returned if no logger has been set.
+func LoggerFromContext(ctx context.Context) logr.Logger {
+	return logr.FromContextOrDiscard(ctx)
+}
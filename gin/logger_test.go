@@ -2,12 +2,17 @@ package gin
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	"github.com/stretchr/testify/require"
 	"github.com/tonglil/buflogr"
 )
@@ -42,3 +47,94 @@ func TestLogInternalServerError(t *testing.T) {
 	mdlw(c)
 	require.Equal(t, "ERROR hello world path /bar status 500 method POST ip 192.0.2.1\n", string(buf.Bytes()))
 }
+
+func TestLogFieldNames(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := LogConfig{
+		Logger:          buflogr.NewWithBuffer(&buf),
+		IncludeClientIP: true,
+		FieldNames:      ECSFieldNames(),
+	}
+	cfg.FieldNames.Method = ""
+	mdlw := Logger(cfg)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/foo", nil)
+	mdlw(c)
+	require.Equal(t, "INFO url.path /foo http.response.status_code 200 method GET client.ip 192.0.2.1\n", buf.String())
+}
+
+func TestLoggerRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := LogConfig{
+		Logger: buflogr.NewWithBuffer(&buf),
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Logger(cfg))
+	router.GET("/items/:id", func(c *gin.Context) {
+		SetLogger(c, FromContextOrDiscard(c).WithValues("id", c.Param("id")))
+		LoggerFromContext(c.Request.Context()).Info("found item")
+		c.Status(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, buf.String(), "INFO found item id 1\n")
+}
+
+func TestLoggerFromContextDiscard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	require.Equal(t, logr.Discard(), FromContextOrDiscard(c))
+	require.Equal(t, logr.Discard(), LoggerFromContext(context.Background()))
+}
+
+func TestLogVerbosityOverride(t *testing.T) {
+	var buf bytes.Buffer
+	newLogger := func(v int) logr.Logger {
+		return funcr.NewJSON(func(obj string) {
+			buf.WriteString(obj + "\n")
+		}, funcr.Options{Verbosity: v})
+	}
+	cfg := LogConfig{
+		Logger: newLogger(0),
+		VerbosityOverride: VerbosityOverrideConfig{
+			Paths:     []*regexp.Regexp{regexp.MustCompile("^/debug")},
+			Header:    "X-Debug-Trace",
+			Tokens:    []string{"secret"},
+			Verbosity: 2,
+			WithVerbosity: func(_ logr.Logger, v int) logr.Logger {
+				return newLogger(v)
+			},
+		},
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Logger(cfg))
+	router.GET("/*path", func(c *gin.Context) {
+		FromContextOrDiscard(c).V(2).Info("trace", "path", c.Request.URL.Path)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		path    string
+		token   string
+		visible bool
+	}{
+		{path: "/foo", visible: false},
+		{path: "/debug/foo", visible: true},
+		{path: "/foo", token: "secret", visible: true},
+		{path: "/foo", token: "guess", visible: false},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("X-Debug-Trace", tt.token)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, tt.visible, strings.Contains(buf.String(), `"msg":"trace"`), tt)
+	}
+}
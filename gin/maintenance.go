@@ -0,0 +1,131 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type MaintenanceConfig struct {
+	// Paths served while in maintenance mode, a trailing * matches any path with the prefix.
+	AllowedPaths []string
+	// Sent as the Retry-After header, not sent if zero.
+	RetryAfter time.Duration
+	// Checked on every request in addition to the toggle, for example to read a flag file or a
+	// feature flag. Maintenance mode is enabled if either returns true.
+	Enabled func() bool
+}
+
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		AllowedPaths: nil,
+		RetryAfter:   5 * time.Minute,
+		Enabled:      nil,
+	}
+}
+
+// Maintenance rejects requests with 503 while maintenance mode is enabled.
+type Maintenance struct {
+	cfg     MaintenanceConfig
+	enabled atomic.Bool
+}
+
+func NewMaintenance(cfg MaintenanceConfig) *Maintenance {
+	return &Maintenance{
+		cfg: cfg,
+	}
+}
+
+func (m *Maintenance) Enable() {
+	m.enabled.Store(true)
+}
+
+func (m *Maintenance) Disable() {
+	m.enabled.Store(false)
+}
+
+// Enabled returns true if maintenance mode is enabled through the toggle or the config callback.
+func (m *Maintenance) Enabled() bool {
+	if m.enabled.Load() {
+		return true
+	}
+	return m.cfg.Enabled != nil && m.cfg.Enabled()
+}
+
+// Watch sets the toggle to the values received from ch until it is closed or ctx is cancelled.
+func (m *Maintenance) Watch(ctx context.Context, ch <-chan bool) {
+	for {
+		select {
+		case enabled, ok := <-ch:
+			if !ok {
+				return
+			}
+			m.enabled.Store(enabled)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Middleware aborts requests to paths which are not allowed with 503 while maintenance mode is
+// enabled.
+func (m *Maintenance) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Enabled() || m.allowed(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if m.cfg.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(m.cfg.RetryAfter.Seconds())))
+		}
+		AbortWithProblem(c, Problem{
+			Status: http.StatusServiceUnavailable,
+			Detail: "service is in maintenance mode",
+		})
+	}
+}
+
+type maintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Handler returns the toggle state on GET and sets it from a JSON body such as {"enabled":true}
+// on PUT. The handler should be protected by authentication middleware and its path allowed.
+func (m *Maintenance) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			status := maintenanceStatus{}
+			if !BindWith(c, &status, binding.JSON) {
+				return
+			}
+			m.enabled.Store(status.Enabled)
+		default:
+			c.AbortWithStatus(http.StatusMethodNotAllowed)
+			return
+		}
+		c.JSON(http.StatusOK, maintenanceStatus{Enabled: m.Enabled()})
+	}
+}
+
+func (m *Maintenance) allowed(path string) bool {
+	for _, allowed := range m.cfg.AllowedPaths {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+			continue
+		}
+		if path == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newMaintenanceTestEngine(m *Maintenance) *gin.Engine {
+	engine := gin.New()
+	engine.Use(m.Middleware())
+	engine.GET("/foo", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/health/live", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.Any("/admin/maintenance", m.Handler())
+	return engine
+}
+
+func TestMaintenance(t *testing.T) {
+	cfg := DefaultMaintenanceConfig()
+	cfg.AllowedPaths = []string{"/health/*", "/admin/maintenance"}
+	m := NewMaintenance(cfg)
+	engine := newMaintenanceTestEngine(m)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	m.Enable()
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "300", rec.Header().Get("Retry-After"))
+	require.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	m.Disable()
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMaintenanceEnabledFunc(t *testing.T) {
+	enabled := true
+	cfg := DefaultMaintenanceConfig()
+	cfg.RetryAfter = 0
+	cfg.Enabled = func() bool {
+		return enabled
+	}
+	m := NewMaintenance(cfg)
+	engine := newMaintenanceTestEngine(m)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Empty(t, rec.Header().Get("Retry-After"))
+
+	enabled = false
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMaintenanceHandler(t *testing.T) {
+	cfg := DefaultMaintenanceConfig()
+	cfg.AllowedPaths = []string{"/admin/maintenance"}
+	m := NewMaintenance(cfg)
+	engine := newMaintenanceTestEngine(m)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{"enabled":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"enabled":true}`, rec.Body.String())
+	require.True(t, m.Enabled())
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"enabled":true}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/maintenance", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestMaintenanceWatch(t *testing.T) {
+	m := NewMaintenance(DefaultMaintenanceConfig())
+	ch := make(chan bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Watch(context.Background(), ch)
+	}()
+	ch <- true
+	require.Eventually(t, m.Enabled, time.Second, time.Millisecond)
+	ch <- false
+	require.Eventually(t, func() bool {
+		return !m.Enabled()
+	}, time.Second, time.Millisecond)
+	close(ch)
+	<-done
+}
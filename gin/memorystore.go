@@ -0,0 +1,97 @@
+package gin
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type MemoryStoreConfig struct {
+	// Maximum number of entries, the least recently used entry is evicted when exceeded. Zero disables the limit.
+	MaxEntries int
+}
+
+func DefaultMemoryStoreConfig() MemoryStoreConfig {
+	return MemoryStoreConfig{
+		MaxEntries: 1000,
+	}
+}
+
+type memoryEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// memoryStore keeps entries in memory with per entry expiry and LRU eviction, it is used by the
+// in-memory response and session stores. It is safe for concurrent use.
+type memoryStore[V any] struct {
+	cfg MemoryStoreConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+func newMemoryStore[V any](cfg MemoryStoreConfig) *memoryStore[V] {
+	return &memoryStore[V]{
+		cfg:   cfg,
+		now:   time.Now,
+		items: map[string]*list.Element{},
+		lru:   list.New(),
+	}
+}
+
+func (s *memoryStore[V]) get(key string) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	e := elem.Value.(*memoryEntry[V])
+	if !e.expiresAt.IsZero() && !s.now().Before(e.expiresAt) {
+		s.remove(elem)
+		var zero V
+		return zero, false
+	}
+	s.lru.MoveToFront(elem)
+	return e.value, true
+}
+
+// set adds or replaces the value for the key, a zero ttl means the entry does not expire.
+func (s *memoryStore[V]) set(key string, value V, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt := time.Time{}
+	if ttl > 0 {
+		expiresAt = s.now().Add(ttl)
+	}
+	if elem, ok := s.items[key]; ok {
+		e := elem.Value.(*memoryEntry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		s.lru.MoveToFront(elem)
+		return
+	}
+	s.items[key] = s.lru.PushFront(&memoryEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	for s.cfg.MaxEntries > 0 && s.lru.Len() > s.cfg.MaxEntries {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *memoryStore[V]) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// remove has to be called with the lock held.
+func (s *memoryStore[V]) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.items, elem.Value.(*memoryEntry[V]).key)
+}
@@ -0,0 +1,38 @@
+package gin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := newMemoryStore[int](MemoryStoreConfig{MaxEntries: 2})
+	store.now = func() time.Time {
+		return now
+	}
+
+	store.set("foo", 1, time.Minute)
+	store.set("bar", 2, 0)
+	v, ok := store.get("foo")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	// The least recently used entry is evicted.
+	store.set("baz", 3, 0)
+	_, ok = store.get("bar")
+	require.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = store.get("foo")
+	require.False(t, ok)
+	v, ok = store.get("baz")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+
+	store.delete("baz")
+	_, ok = store.get("baz")
+	require.False(t, ok)
+}
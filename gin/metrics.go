@@ -0,0 +1,175 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/slok/go-http-metrics/metrics"
+)
+
+// recorder records request metrics, it replaces the recorder from go-http-metrics to support
+// exemplars and native histograms.
+type recorder struct {
+	cfg          MetricsConfig
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inflight     *prometheus.GaugeVec
+}
+
+// newRecorder registers the request metrics with the registerer of the config. Engines using the
+// same registerer share the metrics registered by the first engine, an error is returned if the
+// histograms of the engines are configured differently.
+func newRecorder(cfg MetricsConfig) (metrics.Recorder, error) {
+	reg := cfg.Registerer
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	buckets := cfg.DurationBuckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	labels := []string{"service", "handler", "method", "code"}
+	if cfg.ProtocolLabel {
+		labels = append(labels, "protocol")
+	}
+	duration, err := registerHistogram(reg, prometheus.HistogramOpts{
+		Subsystem:                      "http",
+		Name:                           "request_duration_seconds",
+		Help:                           "The latency of the HTTP requests.",
+		Buckets:                        buckets,
+		NativeHistogramBucketFactor:    cfg.NativeHistogramBucketFactor,
+		NativeHistogramMaxBucketNumber: cfg.NativeHistogramMaxBucketNumber,
+	}, labels)
+	if err != nil {
+		return nil, err
+	}
+	responseSize, err := registerHistogram(reg, prometheus.HistogramOpts{
+		Subsystem: "http",
+		Name:      "response_size_bytes",
+		Help:      "The size of the HTTP responses.",
+		Buckets:   prometheus.ExponentialBuckets(100, 10, 8),
+	}, labels)
+	if err != nil {
+		return nil, err
+	}
+	inflight, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: "http",
+		Name:      "requests_inflight",
+		Help:      "The number of inflight requests being handled at the same time.",
+	}, []string{"service", "handler"}))
+	if err != nil {
+		return nil, err
+	}
+	return &recorder{
+		cfg:          cfg,
+		duration:     duration,
+		responseSize: responseSize,
+		inflight:     inflight,
+	}, nil
+}
+
+func (r *recorder) ObserveHTTPRequestDuration(ctx context.Context, p metrics.HTTPReqProperties, duration time.Duration) {
+	observer := r.duration.WithLabelValues(r.labelValues(ctx, p)...)
+	if r.cfg.Exemplar != nil {
+		if labels := r.cfg.Exemplar(ctx); len(labels) > 0 {
+			if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+				eo.ObserveWithExemplar(duration.Seconds(), labels)
+				return
+			}
+		}
+	}
+	observer.Observe(duration.Seconds())
+}
+
+func (r *recorder) ObserveHTTPResponseSize(ctx context.Context, p metrics.HTTPReqProperties, sizeBytes int64) {
+	r.responseSize.WithLabelValues(r.labelValues(ctx, p)...).Observe(float64(sizeBytes))
+}
+
+func (r *recorder) AddInflightRequests(ctx context.Context, p metrics.HTTPProperties, quantity int) {
+	r.inflight.WithLabelValues(p.Service, p.ID).Add(float64(quantity))
+}
+
+func (r *recorder) labelValues(ctx context.Context, p metrics.HTTPReqProperties) []string {
+	values := []string{p.Service, p.ID, p.Method, p.Code}
+	if r.cfg.ProtocolLabel {
+		protocol, _ := ctx.Value(protocolKey{}).(string)
+		values = append(values, protocol)
+	}
+	return values
+}
+
+type protocolKey struct{}
+
+// protocolContext adds the protocol of the request to the request context, as the recorder only
+// has access to the context.
+func protocolContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), protocolKey{}, c.Request.Proto))
+		c.Next()
+	}
+}
+
+var (
+	histogramsMu sync.Mutex
+	// histograms contains the options of the registered histograms, as the buckets are not part
+	// of the descriptor and cannot be compared when the histogram is already registered.
+	histograms = map[*prometheus.HistogramVec]prometheus.HistogramOpts{}
+)
+
+// registerHistogram registers the histogram, reusing the existing histogram if it has been
+// registered with the same options by another engine. An error is returned if the options
+// differ, as the engine would otherwise silently use the buckets of the first engine.
+func registerHistogram(reg prometheus.Registerer, opts prometheus.HistogramOpts, labels []string) (*prometheus.HistogramVec, error) {
+	histogramsMu.Lock()
+	defer histogramsMu.Unlock()
+	h := prometheus.NewHistogramVec(opts, labels)
+	existing, err := register(reg, h)
+	if err != nil {
+		return nil, err
+	}
+	if existing == h {
+		histograms[h] = opts
+		return h, nil
+	}
+	existingOpts, ok := histograms[existing]
+	if !ok || !sameHistogramOpts(existingOpts, opts) {
+		return nil, fmt.Errorf("could not register metrics: %s_%s is already registered with different buckets", opts.Subsystem, opts.Name)
+	}
+	return existing, nil
+}
+
+func sameHistogramOpts(a, b prometheus.HistogramOpts) bool {
+	if len(a.Buckets) != len(b.Buckets) {
+		return false
+	}
+	for i := range a.Buckets {
+		if a.Buckets[i] != b.Buckets[i] {
+			return false
+		}
+	}
+	return a.NativeHistogramBucketFactor == b.NativeHistogramBucketFactor &&
+		a.NativeHistogramMaxBucketNumber == b.NativeHistogramMaxBucketNumber
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered, such as by another engine using the same registerer. Other
+// registration errors, such as a collector with the same name but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
@@ -0,0 +1,85 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type traceIDKey struct{}
+
+func TestMetricsExemplarAndNativeHistogram(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultConfig()
+	cfg.MetricsConfig.Service = "exemplar"
+	cfg.MetricsConfig.Registerer = reg
+	cfg.MetricsConfig.NativeHistogramBucketFactor = 1.1
+	cfg.MetricsConfig.Exemplar = func(ctx context.Context) prometheus.Labels {
+		traceID, ok := ctx.Value(traceIDKey{}).(string)
+		if !ok {
+			return nil
+		}
+		return prometheus.Labels{"trace_id": traceID}
+	}
+	engine := NewEngine(cfg)
+	engine.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), traceIDKey{}, "abc123"))
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	found := false
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		found = true
+		histogram := family.GetMetric()[0].GetHistogram()
+		require.Equal(t, uint64(1), histogram.GetSampleCount())
+		require.NotZero(t, histogram.GetSchema())
+		exemplars := []string{}
+		for _, bucket := range histogram.GetBucket() {
+			if e := bucket.GetExemplar(); e != nil {
+				for _, l := range e.GetLabel() {
+					exemplars = append(exemplars, l.GetName()+"="+l.GetValue())
+				}
+			}
+		}
+		require.Equal(t, []string{"trace_id=abc123"}, exemplars)
+	}
+	require.True(t, found)
+}
+
+func TestNewEngineMetricsRegistrationError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultConfig()
+	cfg.MetricsConfig.Registerer = reg
+	NewEngine(cfg)
+	// Engines sharing a registerer share the metrics.
+	NewEngine(cfg)
+
+	// The protocol label changes the labels of the already registered metrics.
+	cfg.MetricsConfig.ProtocolLabel = true
+	_, err := NewEngineE(cfg)
+	require.ErrorContains(t, err, "could not register metrics")
+
+	// Buckets are not part of the descriptor but would be silently ignored.
+	cfg.MetricsConfig.ProtocolLabel = false
+	cfg.MetricsConfig.DurationBuckets = []float64{0.1, 1}
+	_, err = NewEngineE(cfg)
+	require.EqualError(t, err, "could not register metrics: http_request_duration_seconds is already registered with different buckets")
+
+	cfg.MetricsConfig.DurationBuckets = nil
+	cfg.MetricsConfig.NativeHistogramBucketFactor = 1.1
+	_, err = NewEngineE(cfg)
+	require.EqualError(t, err, "could not register metrics: http_request_duration_seconds is already registered with different buckets")
+}
@@ -0,0 +1,59 @@
+package gin
+
+import (
+	"reflect"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+const skipObservabilityKey = "observability.skip"
+
+var skipObservabilityName = runtime.FuncForPC(reflect.ValueOf(skipObservability).Pointer()).Name()
+
+// SkipObservability marks a route or group as excluded from request logs and metrics, for
+// example engine.GET("/healthz", SkipObservability(), handler). The marker is detected from the
+// handler chain before any middleware runs, so it can be added anywhere in the chain.
+func SkipObservability() gin.HandlerFunc {
+	return skipObservability
+}
+
+func skipObservability(c *gin.Context) {}
+
+// skipObservabilityPaths marks requests to the route paths as excluded from request logs and
+// metrics. Paths are matched against the route, such as /static/*filepath.
+func skipObservabilityPaths(paths []string) gin.HandlerFunc {
+	skip := map[string]bool{}
+	for _, path := range paths {
+		skip[path] = true
+	}
+	return func(c *gin.Context) {
+		if skip[c.FullPath()] {
+			c.Set(skipObservabilityKey, true)
+		}
+		c.Next()
+	}
+}
+
+func isObservabilitySkipped(c *gin.Context) bool {
+	if c.GetBool(skipObservabilityKey) {
+		return true
+	}
+	for _, name := range c.HandlerNames() {
+		if name == skipObservabilityName {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutSkipped runs the middleware unless the request is excluded from observability.
+func withoutSkipped(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isObservabilitySkipped(c) {
+			c.Next()
+			return
+		}
+		handler(c)
+	}
+}
@@ -0,0 +1,66 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func requestMetricHandlers(t *testing.T, service string) []string {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	handlers := []string{}
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range m.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			if labels["service"] == service {
+				handlers = append(handlers, labels["handler"])
+			}
+		}
+	}
+	return handlers
+}
+
+func TestSkipObservability(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.LogConfig.Logger = buflogr.NewWithBuffer(&buf)
+	cfg.LogConfig.IncludeLatency = false
+	cfg.MetricsConfig.Service = "skip-observability"
+	cfg.SkipObservabilityPaths = []string{"/static/*filepath"}
+	engine := NewEngine(cfg)
+	engine.GET("/healthz", SkipObservability(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	internal := engine.Group("/internal", SkipObservability())
+	internal.GET("/ready", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/static/*filepath", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/api", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/healthz", "/internal/ready", "/static/app.js", "/api"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	require.Equal(t, "INFO path /api status 200 method GET\n", buf.String())
+	require.Equal(t, []string{"/api"}, requestMetricHandlers(t, "skip-observability"))
+}
@@ -0,0 +1,393 @@
+package gin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xenitab/pkg/oidc"
+)
+
+// Session keys used by OIDCLogin.
+const (
+	oidcStateKey        = "oidc.state"
+	oidcVerifierKey     = "oidc.verifier"
+	oidcNonceKey        = "oidc.nonce"
+	oidcReturnToKey     = "oidc.return_to"
+	oidcAccessTokenKey  = "oidc.access_token"
+	oidcIDTokenKey      = "oidc.id_token"
+	oidcRefreshTokenKey = "oidc.refresh_token"
+	oidcExpiryKey       = "oidc.expiry"
+)
+
+type OIDCLoginConfig struct {
+	// Issuer the provider metadata is discovered from.
+	Issuer string
+	// Client ID and secret of the application registration.
+	ClientID     string
+	ClientSecret string
+	// Absolute URL of the callback handler registered with the provider.
+	RedirectURL string
+	// Scopes requested during login.
+	Scopes []string
+	// Path redirected to after login when the login request has no return_to parameter.
+	PostLoginPath string
+	// URL the provider redirects to after logout, it has to be registered with the provider.
+	PostLogoutRedirectURL string
+	// HTTP client used for discovery and token requests.
+	HTTPClient *http.Client
+}
+
+func DefaultOIDCLoginConfig() OIDCLoginConfig {
+	return OIDCLoginConfig{
+		Issuer:                "",
+		ClientID:              "",
+		ClientSecret:          "",
+		RedirectURL:           "",
+		Scopes:                []string{"openid", "profile", "email"},
+		PostLoginPath:         "/",
+		PostLogoutRedirectURL: "",
+		HTTPClient:            http.DefaultClient,
+	}
+}
+
+// OIDCTokens are the tokens stored in the session after login.
+type OIDCTokens struct {
+	AccessToken  string
+	IDToken      string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// OIDCTokensFromContext returns the tokens stored in the session, false is returned if the user
+// has not logged in or the access token has expired.
+func OIDCTokensFromContext(c *gin.Context) (OIDCTokens, bool) {
+	session := SessionFromContext(c)
+	if session == nil {
+		return OIDCTokens{}, false
+	}
+	tokens := OIDCTokens{}
+	tokens.AccessToken, _ = session.Get(oidcAccessTokenKey)
+	tokens.IDToken, _ = session.Get(oidcIDTokenKey)
+	tokens.RefreshToken, _ = session.Get(oidcRefreshTokenKey)
+	if expiry, ok := session.Get(oidcExpiryKey); ok {
+		if unix, err := strconv.ParseInt(expiry, 10, 64); err == nil {
+			tokens.Expiry = time.Unix(unix, 0)
+		}
+	}
+	if tokens.AccessToken == "" || (!tokens.Expiry.IsZero() && time.Now().After(tokens.Expiry)) {
+		return OIDCTokens{}, false
+	}
+	return tokens, true
+}
+
+// OIDCLogin implements browser login with the authorization code flow and PKCE. The handlers
+// store the login state and tokens in the session, so the session middleware has to be used
+// before them. The tokens exceed the size limit of a cookie, so the session middleware has to
+// use a session store.
+type OIDCLogin struct {
+	cfg       OIDCLoginConfig
+	discovery oidc.Discovery
+}
+
+// NewOIDCLogin discovers the provider metadata of the issuer, an error is returned if discovery
+// fails.
+func NewOIDCLogin(ctx context.Context, cfg OIDCLoginConfig) (*OIDCLogin, error) {
+	if cfg.ClientID == "" {
+		return nil, errors.New("client id cannot be empty")
+	}
+	if cfg.RedirectURL == "" {
+		return nil, errors.New("redirect url cannot be empty")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	discovery, err := oidc.Discover(ctx, cfg.HTTPClient, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" {
+		return nil, errors.New("discovery document does not contain authorization and token endpoints")
+	}
+	return &OIDCLogin{
+		cfg:       cfg,
+		discovery: discovery,
+	}, nil
+}
+
+// RegisterRoutes adds the login, callback and logout handlers to the routes.
+func (l *OIDCLogin) RegisterRoutes(routes gin.IRoutes) {
+	routes.GET("/login", l.Login)
+	routes.GET("/callback", l.Callback)
+	routes.POST("/logout", l.Logout)
+}
+
+// Login redirects to the authorization endpoint of the provider. A relative return_to query
+// parameter is redirected to after the callback.
+func (l *OIDCLogin) Login(c *gin.Context) {
+	session, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	state, err := randomString()
+	if err != nil {
+		AbortWithProblem(c, Problem{Status: http.StatusInternalServerError, Detail: err.Error()})
+		return
+	}
+	verifier, err := randomString()
+	if err != nil {
+		AbortWithProblem(c, Problem{Status: http.StatusInternalServerError, Detail: err.Error()})
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		AbortWithProblem(c, Problem{Status: http.StatusInternalServerError, Detail: err.Error()})
+		return
+	}
+	session.Set(oidcStateKey, state)
+	session.Set(oidcVerifierKey, verifier)
+	session.Set(oidcNonceKey, nonce)
+	session.Set(oidcReturnToKey, l.returnTo(c.Query("return_to")))
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         []string{"code"},
+		"client_id":             []string{l.cfg.ClientID},
+		"redirect_uri":          []string{l.cfg.RedirectURL},
+		"scope":                 []string{strings.Join(l.cfg.Scopes, " ")},
+		"state":                 []string{state},
+		"nonce":                 []string{nonce},
+		"code_challenge":        []string{base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": []string{"S256"},
+	}
+	c.Redirect(http.StatusFound, withQuery(l.discovery.AuthorizationEndpoint, query))
+}
+
+// Callback exchanges the authorization code for tokens, stores them in the session and redirects
+// to the path the login was started from. The session ID is renewed to prevent session fixation.
+func (l *OIDCLogin) Callback(c *gin.Context) {
+	session, ok := loginSession(c)
+	if !ok {
+		return
+	}
+	state, _ := session.Get(oidcStateKey)
+	verifier, _ := session.Get(oidcVerifierKey)
+	nonce, _ := session.Get(oidcNonceKey)
+	returnTo, _ := session.Get(oidcReturnToKey)
+	for _, key := range []string{oidcStateKey, oidcVerifierKey, oidcNonceKey, oidcReturnToKey} {
+		session.Delete(key)
+	}
+	if state == "" || c.Query("state") != state {
+		AbortWithProblem(c, Problem{Status: http.StatusBadRequest, Detail: "invalid state"})
+		return
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		AbortWithProblem(c, Problem{Status: http.StatusUnauthorized, Detail: strings.TrimSpace(errCode + " " + c.Query("error_description"))})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		AbortWithProblem(c, Problem{Status: http.StatusBadRequest, Detail: "missing code"})
+		return
+	}
+
+	tokens, err := l.exchange(c.Request.Context(), code, verifier)
+	if err != nil {
+		// The error is only logged as it contains details of the provider response.
+		c.Error(err)
+		AbortWithProblem(c, Problem{Status: http.StatusBadGateway, Detail: "token request failed"})
+		return
+	}
+	if tokens.IDToken != "" {
+		err := l.verifyIDTokenClaims(tokens.IDToken, nonce)
+		if err != nil {
+			AbortWithProblem(c, Problem{Status: http.StatusUnauthorized, Detail: err.Error()})
+			return
+		}
+	}
+
+	session.Renew()
+	session.Set(oidcAccessTokenKey, tokens.AccessToken)
+	session.Set(oidcIDTokenKey, tokens.IDToken)
+	session.Set(oidcRefreshTokenKey, tokens.RefreshToken)
+	if !tokens.Expiry.IsZero() {
+		session.Set(oidcExpiryKey, strconv.FormatInt(tokens.Expiry.Unix(), 10))
+	}
+	if returnTo == "" {
+		returnTo = l.cfg.PostLoginPath
+	}
+	c.Redirect(http.StatusFound, returnTo)
+}
+
+// Logout clears the session and redirects to the end session endpoint of the provider if it has
+// one, otherwise to the post logout redirect URL. It has to be registered as POST, as a GET
+// handler lets other sites log users out with a link or an image. Requests from other origins
+// are rejected for session cookies which are sent with cross-site requests.
+func (l *OIDCLogin) Logout(c *gin.Context) {
+	if origin := c.GetHeader("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != c.Request.Host {
+			AbortWithProblem(c, Problem{Status: http.StatusForbidden, Detail: "cross-origin logout is not allowed"})
+			return
+		}
+	}
+	idToken := ""
+	if session := SessionFromContext(c); session != nil {
+		idToken, _ = session.Get(oidcIDTokenKey)
+		session.Clear()
+	}
+	redirect := l.cfg.PostLogoutRedirectURL
+	if redirect == "" {
+		redirect = "/"
+	}
+	if l.discovery.EndSessionEndpoint != "" {
+		query := url.Values{"client_id": []string{l.cfg.ClientID}}
+		if idToken != "" {
+			query.Set("id_token_hint", idToken)
+		}
+		if l.cfg.PostLogoutRedirectURL != "" {
+			query.Set("post_logout_redirect_uri", l.cfg.PostLogoutRedirectURL)
+		}
+		redirect = withQuery(l.discovery.EndSessionEndpoint, query)
+	}
+	c.Redirect(http.StatusSeeOther, redirect)
+}
+
+// loginSession returns the session of the request, the request is aborted if the session
+// middleware is not used or does not have a store.
+func loginSession(c *gin.Context) (*Session, bool) {
+	session := SessionFromContext(c)
+	if session == nil {
+		AbortWithProblem(c, Problem{Status: http.StatusInternalServerError, Detail: "session middleware is required"})
+		return nil, false
+	}
+	if !session.stored {
+		AbortWithProblem(c, Problem{Status: http.StatusInternalServerError, Detail: "session store is required"})
+		return nil, false
+	}
+	return session, true
+}
+
+// returnTo only allows relative paths to prevent open redirects.
+func (l *OIDCLogin) returnTo(value string) string {
+	if !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//") || strings.HasPrefix(value, "/\\") {
+		return l.cfg.PostLoginPath
+	}
+	return value
+}
+
+func (l *OIDCLogin) exchange(ctx context.Context, code, verifier string) (OIDCTokens, error) {
+	form := url.Values{
+		"grant_type":    []string{"authorization_code"},
+		"code":          []string{code},
+		"redirect_uri":  []string{l.cfg.RedirectURL},
+		"code_verifier": []string{verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OIDCTokens{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(l.cfg.ClientID), url.QueryEscape(l.cfg.ClientSecret))
+	resp, err := l.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return OIDCTokens{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return OIDCTokens{}, fmt.Errorf("token request failed with status code %d", resp.StatusCode)
+	}
+	body := struct {
+		AccessToken  string `json:"access_token"`
+		IDToken      string `json:"id_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return OIDCTokens{}, fmt.Errorf("could not decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return OIDCTokens{}, errors.New("token response does not contain an access token")
+	}
+	tokens := OIDCTokens{
+		AccessToken:  body.AccessToken,
+		IDToken:      body.IDToken,
+		RefreshToken: body.RefreshToken,
+	}
+	if body.ExpiresIn > 0 {
+		tokens.Expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return tokens, nil
+}
+
+// verifyIDTokenClaims checks the issuer, audience and nonce of the ID token. The signature is
+// not verified as the token is received directly from the token endpoint over TLS.
+func (l *OIDCLogin) verifyIDTokenClaims(idToken, nonce string) error {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed id token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errors.New("malformed id token")
+	}
+	claims := struct {
+		Issuer   string          `json:"iss"`
+		Audience json.RawMessage `json:"aud"`
+		Nonce    string          `json:"nonce"`
+	}{}
+	err = json.Unmarshal(b, &claims)
+	if err != nil {
+		return errors.New("malformed id token")
+	}
+	if claims.Issuer != l.discovery.Issuer {
+		return errors.New("id token issuer does not match")
+	}
+	audiences := []string{}
+	if json.Unmarshal(claims.Audience, &audiences) != nil {
+		audience := ""
+		json.Unmarshal(claims.Audience, &audience)
+		audiences = []string{audience}
+	}
+	found := false
+	for _, audience := range audiences {
+		if audience == l.cfg.ClientID {
+			found = true
+		}
+	}
+	if !found {
+		return errors.New("id token audience does not match")
+	}
+	if claims.Nonce != nonce {
+		return errors.New("id token nonce does not match")
+	}
+	return nil
+}
+
+func withQuery(endpoint string, query url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode()
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,240 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvider(t *testing.T, nonce *string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	challenges := map[string]string{}
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"end_session_endpoint":   srv.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		challenges["code"] = q.Get("code_challenge")
+		*nonce = q.Get("nonce")
+		redirect := q.Get("redirect_uri") + "?" + url.Values{"code": []string{"code"}, "state": []string{q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect, http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if challenges[r.PostFormValue("code")] != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "internal details"})
+			return
+		}
+		claims, _ := json.Marshal(map[string]interface{}{"iss": srv.URL, "aud": "client", "nonce": *nonce})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"id_token":     "e30." + base64.RawURLEncoding.EncodeToString(claims) + ".sig",
+			"expires_in":   3600,
+		})
+	})
+	return srv
+}
+
+func TestOIDCLogin(t *testing.T) {
+	nonce := ""
+	provider := newTestProvider(t, &nonce)
+
+	sessionCfg := DefaultSessionConfig()
+	sessionCfg.Keys = [][]byte{bytes.Repeat([]byte("a"), 32)}
+	sessionCfg.Store = NewMemorySessionStore(DefaultMemoryStoreConfig())
+	sessionCfg.Secure = false
+	sessions, err := NewSessionManager(sessionCfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	app := httptest.NewServer(engine)
+	defer app.Close()
+
+	cfg := DefaultOIDCLoginConfig()
+	cfg.Issuer = provider.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+	cfg.RedirectURL = app.URL + "/auth/callback"
+	cfg.PostLogoutRedirectURL = app.URL + "/"
+	login, err := NewOIDCLogin(context.Background(), cfg)
+	require.NoError(t, err)
+
+	engine.Use(sessions.Middleware())
+	login.RegisterRoutes(engine.Group("/auth"))
+	engine.GET("/profile", func(c *gin.Context) {
+		tokens, ok := OIDCTokensFromContext(c)
+		if !ok {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.String(http.StatusOK, tokens.AccessToken)
+	})
+
+	jar := &testCookieJar{cookies: map[string]*http.Cookie{}}
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Host == provider.Listener.Addr().String() && req.URL.Path == "/logout" {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	resp, err := client.Get(app.URL + "/auth/login?return_to=/profile")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "/profile", resp.Request.URL.Path)
+
+	// Logout cannot be triggered with a link or from another site.
+	resp, err = client.Get(app.URL + "/auth/logout")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	req, err := http.NewRequest(http.MethodPost, app.URL+"/auth/logout", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, app.URL+"/auth/logout", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", app.URL)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/logout", location.Path)
+	require.NotEmpty(t, location.Query().Get("id_token_hint"))
+	require.Equal(t, app.URL+"/", location.Query().Get("post_logout_redirect_uri"))
+
+	resp, err = client.Get(app.URL + "/profile")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestOIDCLoginInvalidState(t *testing.T) {
+	nonce := ""
+	provider := newTestProvider(t, &nonce)
+	sessionCfg := DefaultSessionConfig()
+	sessionCfg.Keys = [][]byte{bytes.Repeat([]byte("a"), 32)}
+	sessionCfg.Store = NewMemorySessionStore(DefaultMemoryStoreConfig())
+	sessions, err := NewSessionManager(sessionCfg)
+	require.NoError(t, err)
+	cfg := DefaultOIDCLoginConfig()
+	cfg.Issuer = provider.URL
+	cfg.ClientID = "client"
+	cfg.RedirectURL = "https://app.example.com/callback"
+	login, err := NewOIDCLogin(context.Background(), cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sessions.Middleware())
+	login.RegisterRoutes(engine)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login?return_to=//evil.example.com", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+	require.Equal(t, "/", login.returnTo("//evil.example.com"))
+	cookies := rec.Result().Cookies()
+
+	req := httptest.NewRequest(http.MethodGet, "/callback?code=code&state=wrong", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	problem := Problem{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, http.StatusBadRequest, problem.Status)
+	require.Equal(t, "invalid state", problem.Detail)
+
+	// The response of a failed token request is not included in the problem.
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookies = rec.Result().Cookies()
+	state, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	req = httptest.NewRequest(http.MethodGet, "/callback?code=wrong&state="+state.Query().Get("state"), nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	problem = Problem{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, http.StatusBadGateway, problem.Status)
+	require.Equal(t, "token request failed", problem.Detail)
+}
+
+func TestOIDCLoginWithoutSessionStore(t *testing.T) {
+	nonce := ""
+	provider := newTestProvider(t, &nonce)
+	sessionCfg := DefaultSessionConfig()
+	sessionCfg.Keys = [][]byte{bytes.Repeat([]byte("a"), 32)}
+	sessions, err := NewSessionManager(sessionCfg)
+	require.NoError(t, err)
+	cfg := DefaultOIDCLoginConfig()
+	cfg.Issuer = provider.URL
+	cfg.ClientID = "client"
+	cfg.RedirectURL = "https://app.example.com/callback"
+	login, err := NewOIDCLogin(context.Background(), cfg)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(sessions.Middleware())
+	login.RegisterRoutes(engine)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	problem := Problem{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, http.StatusInternalServerError, problem.Status)
+	require.Equal(t, "session store is required", problem.Detail)
+}
+
+// testCookieJar stores cookies regardless of host, as the test servers only differ by port.
+type testCookieJar struct {
+	cookies map[string]*http.Cookie
+}
+
+func (j *testCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		if cookie.MaxAge < 0 {
+			delete(j.cookies, cookie.Name)
+			continue
+		}
+		j.cookies[cookie.Name] = cookie
+	}
+}
+
+func (j *testCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	cookies := []*http.Cookie{}
+	for _, cookie := range j.cookies {
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
@@ -0,0 +1,68 @@
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of problem details responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	// URI identifying the problem type, about:blank is implied if empty.
+	Type string `json:"type,omitempty"`
+	// Short summary of the problem type, defaults to the status text.
+	Title string `json:"title"`
+	// HTTP status code of the response.
+	Status int `json:"status"`
+	// Explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// URI identifying this occurrence of the problem, defaults to the request path.
+	Instance string `json:"instance,omitempty"`
+	// Validation errors for individual fields.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a field which failed validation.
+type FieldError struct {
+	// Path to the field using the names from the json, form or uri tags, for example items[0].name.
+	Field string `json:"field"`
+	// Validation rule which failed, for example required or max.
+	Rule string `json:"rule"`
+	// Parameter of the rule, for example 10 for max=10.
+	Param string `json:"param,omitempty"`
+	// Human readable description of the error.
+	Message string `json:"message"`
+}
+
+// AbortWithProblem aborts the request and writes the problem as application/problem+json. The
+// problem is added to the context errors so that it is included in the request log.
+func AbortWithProblem(c *gin.Context, problem Problem) {
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	c.Error(problem)
+	c.Abort()
+	b, err := json.Marshal(problem)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(problem.Status, ProblemContentType, b)
+}
+
+func (p Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
@@ -0,0 +1,132 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PropagatedHeadersContext returns a context with the headers to set on outgoing requests, such as
+// httpclient.ContextWithPropagatedHeaders.
+type PropagatedHeadersContext func(ctx context.Context, header http.Header) context.Context
+
+type PropagationConfig struct {
+	// Inbound headers captured and set on outgoing requests.
+	Headers []string
+}
+
+func DefaultPropagationConfig() PropagationConfig {
+	return PropagationConfig{
+		Headers: []string{"X-Request-ID", "Traceparent", "Tracestate", "Baggage", "Accept-Language", "X-Tenant-ID"},
+	}
+}
+
+// PropagateHeaders captures the configured headers of the request into the request context with
+// withHeaders, so that clients created with httpclient.NewClient and PropagateHeaders enabled set
+// them on requests made with the context. It panics if withHeaders is nil.
+func PropagateHeaders(withHeaders PropagatedHeadersContext, cfg PropagationConfig) gin.HandlerFunc {
+	if withHeaders == nil {
+		panic("propagated headers context function cannot be nil")
+	}
+	return func(c *gin.Context) {
+		header := http.Header{}
+		for _, name := range cfg.Headers {
+			if values := c.Request.Header.Values(name); len(values) > 0 {
+				header[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		if len(header) > 0 {
+			ctx := withHeaders(c.Request.Context(), header)
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+	}
+}
+
+// DeadlineHeader carries the time remaining until the deadline of the request in milliseconds, it
+// matches the header set by clients created with httpclient.NewClient.
+const DeadlineHeader = "X-Request-Timeout"
+
+type DeadlineConfig struct {
+	// Headers read for the client deadline in order. Values are either an integer number of
+	// milliseconds or in the grpc-timeout format of an integer followed by one of the units H, M,
+	// S, m, u or n.
+	Headers []string
+	// Upper bound for the deadline set by the client, no upper bound if zero.
+	MaxTimeout time.Duration
+}
+
+func DefaultDeadlineConfig() DeadlineConfig {
+	return DeadlineConfig{
+		Headers:    []string{DeadlineHeader, "Grpc-Timeout"},
+		MaxTimeout: 0,
+	}
+}
+
+// PropagateDeadline applies the deadline sent by the client to the request context, so that work
+// is abandoned once the client has given up and clients created with httpclient.NewClient and
+// PropagateDeadline enabled send the remaining time onwards. Invalid header values are ignored.
+func PropagateDeadline(cfg DeadlineConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := requestTimeout(c.Request, cfg.Headers)
+		if !ok {
+			c.Next()
+			return
+		}
+		if cfg.MaxTimeout > 0 && timeout > cfg.MaxTimeout {
+			timeout = cfg.MaxTimeout
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func requestTimeout(req *http.Request, headers []string) (time.Duration, bool) {
+	for _, name := range headers {
+		value := req.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			continue
+		}
+		return timeout, true
+	}
+	return 0, false
+}
+
+var timeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("timeout cannot be empty")
+	}
+	unit := time.Millisecond
+	if u, ok := timeoutUnits[value[len(value)-1]]; ok {
+		unit = u
+		value = value[:len(value)-1]
+	}
+	// The grpc-timeout format allows at most 8 digits, which also prevents overflow.
+	if len(value) == 0 || len(value) > 8 {
+		return 0, errors.New("invalid timeout")
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid timeout")
+	}
+	return time.Duration(n) * unit, nil
+}
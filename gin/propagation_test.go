@@ -0,0 +1,92 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type propagatedHeadersKey struct{}
+
+func TestPropagateHeaders(t *testing.T) {
+	withHeaders := func(ctx context.Context, header http.Header) context.Context {
+		return context.WithValue(ctx, propagatedHeadersKey{}, header)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(PropagateHeaders(withHeaders, DefaultPropagationConfig()))
+	engine.GET("/", func(c *gin.Context) {
+		header, ok := c.Request.Context().Value(propagatedHeadersKey{}).(http.Header)
+		require.True(t, ok)
+		c.JSON(http.StatusOK, header)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "foo")
+	req.Header.Set("X-Tenant-ID", "bar")
+	req.Header.Set("Cookie", "session=secret")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"X-Request-Id":["foo"],"X-Tenant-Id":["bar"]}`, rec.Body.String())
+}
+
+func TestPropagateHeadersWithoutContext(t *testing.T) {
+	require.Panics(t, func() {
+		PropagateHeaders(nil, DefaultPropagationConfig())
+	})
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	cfg := DefaultDeadlineConfig()
+	cfg.MaxTimeout = 2 * time.Second
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(PropagateDeadline(cfg))
+	engine.GET("/", func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.Status(http.StatusOK)
+			return
+		}
+		c.String(http.StatusOK, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+	})
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		value  string
+		min    int
+		max    int
+	}{
+		{name: "milliseconds", header: DeadlineHeader, value: "1500", min: 1000, max: 1500},
+		{name: "grpc timeout", header: "Grpc-Timeout", value: "1S", min: 500, max: 1000},
+		{name: "max timeout", header: DeadlineHeader, value: "60000", min: 1500, max: 2000},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			ms, err := strconv.Atoi(rec.Body.String())
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, ms, tt.min)
+			require.LessOrEqual(t, ms, tt.max)
+		})
+	}
+
+	for _, value := range []string{"invalid", "S", "-1", "1.5S", "123456789"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DeadlineHeader, value)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		require.Empty(t, rec.Body.String(), value)
+	}
+}
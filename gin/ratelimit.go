@@ -0,0 +1,107 @@
+package gin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RateLimitResult has the same fields as ratelimit.Result, so that one can be converted to the other.
+type RateLimitResult struct {
+	// Allowed is true if the request was allowed and recorded.
+	Allowed bool
+	// Remaining number of requests allowed right now.
+	Remaining int
+	// Duration until the request would be allowed, zero if it was allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimiter reports whether a request for the key may happen now and records it if allowed.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// RateLimiterFunc allows a function to be used as a RateLimiter, such as one calling AllowN of a
+// limiter from the ratelimit module with one event.
+type RateLimiterFunc func(ctx context.Context, key string) (RateLimitResult, error)
+
+func (f RateLimiterFunc) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	return f(ctx, key)
+}
+
+type RateLimitConfig struct {
+	// Limiter applying the limit, such as a ratelimit.MemoryLimiter or a Redis backed limiter
+	// shared between replicas wrapped with RateLimiterFunc.
+	Limiter RateLimiter
+	// Name of the limit, prefixed to the limiter keys and used as label in metrics to
+	// differentiate between route groups sharing a limiter.
+	Name string
+	// Returns the key requests are limited by, such as the API key owner. The client IP is used if nil.
+	KeyFunc func(c *gin.Context) string
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Limiter:    nil,
+		Name:       "",
+		KeyFunc:    nil,
+		Registerer: nil,
+	}
+}
+
+// RateLimit rejects requests with 429 when the limiter does not allow another request for the
+// key, setting Retry-After to when the request would be allowed. Errors from the limiter are
+// added to the context errors and the request is handled without the limit. It panics if the
+// limiter is nil or the metrics cannot be registered.
+func RateLimit(cfg RateLimitConfig) gin.HandlerFunc {
+	if cfg.Limiter == nil {
+		panic("rate limiter cannot be nil")
+	}
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = func(c *gin.Context) string {
+			return c.ClientIP()
+		}
+	}
+	var rejected *prometheus.CounterVec
+	if cfg.Registerer != nil {
+		var err error
+		rejected, err = register(cfg.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_rate_limit_rejected_total",
+			Help: "Total number of requests rejected by the rate limiter.",
+		}, []string{"limiter"}))
+		if err != nil {
+			panic(err)
+		}
+	}
+	return func(c *gin.Context) {
+		res, err := cfg.Limiter.Allow(c.Request.Context(), cfg.Name+":"+keyFunc(c))
+		if err != nil {
+			c.Error(err)
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if res.Allowed {
+			c.Next()
+			return
+		}
+
+		if rejected != nil {
+			rejected.WithLabelValues(cfg.Name).Inc()
+		}
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+		AbortWithProblem(c, Problem{
+			Status: http.StatusTooManyRequests,
+			Detail: fmt.Sprintf("rate limit exceeded, retry after %s", res.RetryAfter),
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	// Allows two requests per key and then rejects them for a minute.
+	counts := map[string]int{}
+	limiter := RateLimiterFunc(func(_ context.Context, key string) (RateLimitResult, error) {
+		counts[key]++
+		if counts[key] > 2 {
+			return RateLimitResult{RetryAfter: time.Minute}, nil
+		}
+		return RateLimitResult{Allowed: true, Remaining: 2 - counts[key]}, nil
+	})
+
+	reg := prometheus.NewRegistry()
+	cfg := DefaultRateLimitConfig()
+	cfg.Limiter = limiter
+	cfg.Name = "api"
+	cfg.KeyFunc = func(c *gin.Context) string {
+		return c.GetHeader("X-Client")
+	}
+	cfg.Registerer = reg
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RateLimit(cfg))
+	engine.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func(client string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client", client)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serve("foo")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, remaining, rec.Header().Get("X-RateLimit-Remaining"))
+	}
+	rec := serve("foo")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+	require.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	require.Equal(t, http.StatusOK, serve("bar").Code)
+
+	rejected, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limit_rejected_total",
+		Help: "Total number of requests rejected by the rate limiter.",
+	}, []string{"limiter"}))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(rejected.WithLabelValues("api")))
+}
+
+func TestRateLimitError(t *testing.T) {
+	cfg := DefaultRateLimitConfig()
+	cfg.Limiter = RateLimiterFunc(func(context.Context, string) (RateLimitResult, error) {
+		return RateLimitResult{}, errors.New("unavailable")
+	})
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RateLimit(cfg))
+	engine.GET("/", func(c *gin.Context) {
+		require.EqualError(t, c.Errors.Last(), "unavailable")
+		c.Status(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRateLimitWithoutLimiter(t *testing.T) {
+	require.Panics(t, func() {
+		RateLimit(DefaultRateLimitConfig())
+	})
+}
@@ -0,0 +1,120 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	pkggin "github.com/xenitab/pkg/gin"
+)
+
+type Config struct {
+	// Client used to store the entries, such as a *redis.Client or *redis.ClusterClient.
+	Client redis.Cmdable
+	// Prefix added to the keys stored in Redis, so that multiple applications can share a database.
+	KeyPrefix string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Client:    nil,
+		KeyPrefix: "gin:",
+	}
+}
+
+// ResponseStore keeps cached responses in Redis, so that they are shared between replicas. It
+// implements gin.ResponseStore.
+type ResponseStore struct {
+	cfg Config
+}
+
+func NewResponseStore(cfg Config) (*ResponseStore, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("client cannot be nil")
+	}
+	return &ResponseStore{
+		cfg: cfg,
+	}, nil
+}
+
+func (s *ResponseStore) Get(ctx context.Context, key string) (*pkggin.CachedResponse, bool, error) {
+	b, err := s.cfg.Client.Get(ctx, s.cfg.KeyPrefix+"response:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get cached response: %w", err)
+	}
+	resp := &pkggin.CachedResponse{}
+	err = json.Unmarshal(b, resp)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not decode cached response: %w", err)
+	}
+	return resp, true, nil
+}
+
+func (s *ResponseStore) Set(ctx context.Context, key string, resp *pkggin.CachedResponse, ttl time.Duration) error {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("could not encode cached response: %w", err)
+	}
+	err = s.cfg.Client.Set(ctx, s.cfg.KeyPrefix+"response:"+key, b, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("could not set cached response: %w", err)
+	}
+	return nil
+}
+
+// SessionStore keeps session values in Redis, so that sessions are shared between replicas. It
+// implements gin.SessionStore.
+type SessionStore struct {
+	cfg Config
+}
+
+func NewSessionStore(cfg Config) (*SessionStore, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("client cannot be nil")
+	}
+	return &SessionStore{
+		cfg: cfg,
+	}, nil
+}
+
+func (s *SessionStore) Get(ctx context.Context, id string) (map[string]string, bool, error) {
+	b, err := s.cfg.Client.Get(ctx, s.cfg.KeyPrefix+"session:"+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get session: %w", err)
+	}
+	values := map[string]string{}
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not decode session: %w", err)
+	}
+	return values, true, nil
+}
+
+func (s *SessionStore) Set(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("could not encode session: %w", err)
+	}
+	err = s.cfg.Client.Set(ctx, s.cfg.KeyPrefix+"session:"+id, b, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("could not set session: %w", err)
+	}
+	return nil
+}
+
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	err := s.cfg.Client.Del(ctx, s.cfg.KeyPrefix+"session:"+id).Err()
+	if err != nil {
+		return fmt.Errorf("could not delete session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package redisstore
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	pkggin "github.com/xenitab/pkg/gin"
+)
+
+var (
+	_ pkggin.ResponseStore = &ResponseStore{}
+	_ pkggin.SessionStore  = &SessionStore{}
+)
+
+func TestResponseStore(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg := DefaultConfig()
+	cfg.Client = redis.NewClient(&redis.Options{Addr: s.Addr()})
+	store, err := NewResponseStore(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, ok, err := store.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	resp := &pkggin.CachedResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Body:       []byte("bar"),
+		StoredAt:   time.Unix(1000, 0).UTC(),
+	}
+	err = store.Set(ctx, "foo", resp, time.Minute)
+	require.NoError(t, err)
+	require.True(t, s.Exists("gin:response:foo"))
+	require.Equal(t, time.Minute, s.TTL("gin:response:foo"))
+	cached, ok, err := store.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, resp, cached)
+
+	s.FastForward(time.Minute)
+	_, ok, err = store.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	s.Set("gin:response:invalid", "foo")
+	_, _, err = store.Get(ctx, "invalid")
+	require.Error(t, err)
+}
+
+func TestNewResponseStoreWithoutClient(t *testing.T) {
+	_, err := NewResponseStore(DefaultConfig())
+	require.EqualError(t, err, "client cannot be nil")
+}
+
+func TestSessionStore(t *testing.T) {
+	s := miniredis.RunT(t)
+	cfg := DefaultConfig()
+	cfg.Client = redis.NewClient(&redis.Options{Addr: s.Addr()})
+	store, err := NewSessionStore(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, ok, err := store.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	err = store.Set(ctx, "foo", map[string]string{"bar": "baz"}, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, s.TTL("gin:session:foo"))
+	values, ok, err := store.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"bar": "baz"}, values)
+
+	err = store.Delete(ctx, "foo")
+	require.NoError(t, err)
+	require.False(t, s.Exists("gin:session:foo"))
+}
@@ -0,0 +1,169 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorReport describes an error or panic which occurred while handling a request.
+type ErrorReport struct {
+	// Error returned by the handler, or created from the recovered value for panics.
+	Err error
+	// Value recovered from a panic, nil if the report is not caused by a panic.
+	Panic interface{}
+	// Stack trace of the panic, nil if the report is not caused by a panic.
+	Stack []byte
+	// Request which caused the error, the body may already have been consumed.
+	Request *http.Request
+	// Route of the request, for example /users/:id.
+	Route string
+	// Status code of the response.
+	StatusCode int
+	// ClientIP of the request.
+	ClientIP string
+	// User which made the request, empty if the request is not authenticated.
+	User string
+}
+
+// ErrorReporter sends error reports to an external service such as Sentry or Application Insights.
+// Report is called synchronously while handling the request and should not block.
+type ErrorReporter interface {
+	Report(ctx context.Context, report ErrorReport)
+}
+
+// ErrorReporterFunc allows a function to be used as an ErrorReporter.
+type ErrorReporterFunc func(ctx context.Context, report ErrorReport)
+
+func (f ErrorReporterFunc) Report(ctx context.Context, report ErrorReport) {
+	f(ctx, report)
+}
+
+type ErrorReportConfig struct {
+	// Reporter errors and panics are reported to, reporting is disabled if nil.
+	Reporter ErrorReporter
+	// Context keys checked in order for the authenticated user.
+	UserKeys []string
+	// Minimum response status code for errors to be reported.
+	MinStatusCode int
+}
+
+func DefaultErrorReportConfig() ErrorReportConfig {
+	return ErrorReportConfig{
+		Reporter:      nil,
+		UserKeys:      []string{APIKeyOwnerKey, BasicAuthUserKey},
+		MinStatusCode: http.StatusInternalServerError,
+	}
+}
+
+// panicStackKey is the context key the truncated stack of a recovered panic is stored in, so that
+// it is included in the request log.
+const panicStackKey = "panic.stack"
+
+// maxLoggedStackSize is the maximum size in bytes of the stack included in the request log, the
+// full stack is still sent to the reporter.
+const maxLoggedStackSize = 4096
+
+// Recovery recovers panics, reports them with the stack trace and aborts the request with 500.
+// The panic and a truncated stack trace are added to the context so that they are included in
+// the request log.
+func Recovery(cfg ErrorReportConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			stack := debug.Stack()
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+			err = fmt.Errorf("panic: %w", err)
+			c.Error(err)
+			c.Set(panicStackKey, truncateStack(stack, maxLoggedStackSize))
+			// The status cannot be written to a broken connection.
+			if isBrokenPipe(rec) {
+				c.Abort()
+			} else {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+
+			if cfg.Reporter == nil {
+				return
+			}
+			report := newErrorReport(c, cfg, err)
+			report.Panic = rec
+			report.Stack = stack
+			cfg.Reporter.Report(c.Request.Context(), report)
+		}()
+		c.Next()
+	}
+}
+
+// ReportErrors reports errors added to the context when the response status code is at least
+// MinStatusCode.
+func ReportErrors(cfg ErrorReportConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if cfg.Reporter == nil || len(c.Errors) == 0 || c.Writer.Status() < cfg.MinStatusCode {
+			return
+		}
+		errs := []error{}
+		for _, e := range c.Errors {
+			errs = append(errs, e.Err)
+		}
+		cfg.Reporter.Report(c.Request.Context(), newErrorReport(c, cfg, errors.Join(errs...)))
+	}
+}
+
+func newErrorReport(c *gin.Context, cfg ErrorReportConfig, err error) ErrorReport {
+	report := ErrorReport{
+		Err:        err,
+		Request:    c.Request,
+		Route:      c.FullPath(),
+		StatusCode: c.Writer.Status(),
+		ClientIP:   c.ClientIP(),
+	}
+	for _, key := range cfg.UserKeys {
+		if user := c.GetString(key); user != "" {
+			report.User = user
+			break
+		}
+	}
+	return report
+}
+
+// truncateStack truncates the stack to at most size bytes, cutting at the last complete line.
+func truncateStack(stack []byte, size int) string {
+	if len(stack) <= size {
+		return string(stack)
+	}
+	stack = stack[:size]
+	if i := bytes.LastIndexByte(stack, '\n'); i > 0 {
+		stack = stack[:i]
+	}
+	return string(stack) + "\n..."
+}
+
+func isBrokenPipe(rec interface{}) bool {
+	ne, ok := rec.(*net.OpError)
+	if !ok {
+		return false
+	}
+	se := &os.SyscallError{}
+	if !errors.As(ne, &se) {
+		return false
+	}
+	msg := strings.ToLower(se.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,61 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gogin "github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorReporting(t *testing.T) {
+	reports := []ErrorReport{}
+	cfg := DefaultConfig()
+	cfg.ErrorReportConfig.Reporter = ErrorReporterFunc(func(ctx context.Context, report ErrorReport) {
+		reports = append(reports, report)
+	})
+	engine := NewEngine(cfg)
+	engine.GET("/panic/:id", func(c *gogin.Context) {
+		c.Set(APIKeyOwnerKey, "foo")
+		panic("something went wrong")
+	})
+	engine.GET("/error", func(c *gogin.Context) {
+		c.AbortWithError(http.StatusBadGateway, errors.New("upstream failed"))
+	})
+	engine.GET("/bad-request", func(c *gogin.Context) {
+		c.AbortWithError(http.StatusBadRequest, errors.New("invalid input"))
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic/1", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Len(t, reports, 1)
+	require.EqualError(t, reports[0].Err, "panic: something went wrong")
+	require.Equal(t, "something went wrong", reports[0].Panic)
+	require.Contains(t, string(reports[0].Stack), "report_test.go")
+	require.Equal(t, "/panic/:id", reports[0].Route)
+	require.Equal(t, http.StatusInternalServerError, reports[0].StatusCode)
+	require.Equal(t, "foo", reports[0].User)
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/error", nil))
+	require.Equal(t, http.StatusBadGateway, rec.Code)
+	require.Len(t, reports, 2)
+	require.EqualError(t, reports[1].Err, "upstream failed")
+	require.Nil(t, reports[1].Panic)
+	require.Equal(t, http.StatusBadGateway, reports[1].StatusCode)
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bad-request", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Len(t, reports, 2)
+}
+
+func TestTruncateStack(t *testing.T) {
+	stack := []byte("line 1\nline 2\nline 3\n")
+	require.Equal(t, string(stack), truncateStack(stack, 100))
+	require.Equal(t, "line 1\nline 2\n...", truncateStack(stack, 16))
+}
@@ -0,0 +1,291 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CacheStatusHit   = "HIT"
+	CacheStatusStale = "STALE"
+	CacheStatusMiss  = "MISS"
+)
+
+// CachedResponse is a response stored by ResponseCache.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+}
+
+// ResponseStore stores cached responses, implementations have to be safe for concurrent use.
+type ResponseStore interface {
+	// Get returns the response for the key, false is returned if it does not exist or has expired.
+	Get(ctx context.Context, key string) (*CachedResponse, bool, error)
+	// Set stores the response for the key until ttl has passed.
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error
+}
+
+type memoryResponseStore struct {
+	store *memoryStore[*CachedResponse]
+}
+
+// NewMemoryResponseStore returns a store keeping responses in memory of a single replica, use a
+// shared store such as redisstore.ResponseStore when running multiple replicas.
+func NewMemoryResponseStore(cfg MemoryStoreConfig) ResponseStore {
+	return &memoryResponseStore{
+		store: newMemoryStore[*CachedResponse](cfg),
+	}
+}
+
+func (s *memoryResponseStore) Get(ctx context.Context, key string) (*CachedResponse, bool, error) {
+	resp, ok := s.store.get(key)
+	return resp, ok, nil
+}
+
+func (s *memoryResponseStore) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	s.store.set(key, resp, ttl)
+	return nil
+}
+
+type ResponseCacheConfig struct {
+	// Store responses are cached in, required.
+	Store ResponseStore
+	// Duration responses are served from the cache without revalidation.
+	TTL time.Duration
+	// Duration after the TTL a stale response is served while it is revalidated by the request.
+	StaleWhileRevalidate time.Duration
+	// Request headers included in the cache key, such as Accept-Language. Requests with an
+	// Authorization header are not cached unless it is included.
+	VaryHeaders []string
+	// Maximum size in bytes of a cached response body, larger responses are not cached.
+	MaxBodySize int
+	// Response header set to HIT, STALE or MISS, not set if empty.
+	StatusHeader string
+}
+
+func DefaultResponseCacheConfig() ResponseCacheConfig {
+	return ResponseCacheConfig{
+		Store:                nil,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: 0,
+		VaryHeaders:          nil,
+		MaxBodySize:          1 << 20,
+		StatusHeader:         "X-Cache",
+	}
+}
+
+// ResponseCache caches successful responses to GET requests, keyed by the path, query and vary
+// headers, and serves them to GET and HEAD requests. Responses with Cache-Control no-store or private are not cached. Errors
+// from the store are added to the context errors and the request is handled without the cache.
+// It panics if no store is configured.
+func ResponseCache(cfg ResponseCacheConfig) gin.HandlerFunc {
+	if cfg.Store == nil {
+		panic("response cache store cannot be nil")
+	}
+	revalidating := sync.Map{}
+	varyAuthorization := false
+	for _, h := range cfg.VaryHeaders {
+		if http.CanonicalHeaderKey(h) == "Authorization" {
+			varyAuthorization = true
+		}
+	}
+	return func(c *gin.Context) {
+		if (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) ||
+			(c.GetHeader("Authorization") != "" && !varyAuthorization) ||
+			strings.Contains(c.GetHeader("Cache-Control"), "no-store") {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c.Request, cfg.VaryHeaders)
+		resp, ok, err := cfg.Store.Get(c.Request.Context(), key)
+		if err != nil {
+			c.Error(err)
+			c.Next()
+			return
+		}
+		if ok {
+			age := time.Since(resp.StoredAt)
+			if age < cfg.TTL {
+				writeCachedResponse(c, cfg, resp, CacheStatusHit)
+				c.Abort()
+				return
+			}
+			// Only a single request revalidates a stale response, the others are served the stale response.
+			if _, loaded := revalidating.LoadOrStore(key, true); loaded {
+				writeCachedResponse(c, cfg, resp, CacheStatusStale)
+				c.Abort()
+				return
+			}
+			defer revalidating.Delete(key)
+			writeCachedResponse(c, cfg, resp, CacheStatusStale)
+			c.Writer.Flush()
+			w := &cacheWriter{ResponseWriter: c.Writer, maxSize: cfg.MaxBodySize, header: http.Header{}, discard: true}
+			c.Writer = w
+			c.Next()
+			c.Writer = w.ResponseWriter
+			storeResponse(c, cfg, key, w)
+			return
+		}
+
+		if cfg.StatusHeader != "" {
+			c.Header(cfg.StatusHeader, CacheStatusMiss)
+		}
+		w := &cacheWriter{ResponseWriter: c.Writer, maxSize: cfg.MaxBodySize}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		storeResponse(c, cfg, key, w)
+	}
+}
+
+// responseCacheKey uses the same key for GET and HEAD requests, so that HEAD requests are served
+// from responses cached by GET requests.
+func responseCacheKey(req *http.Request, varyHeaders []string) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.RequestURI()))
+	for _, name := range varyHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(req.Header.Values(name), ",")))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeCachedResponse(c *gin.Context, cfg ResponseCacheConfig, resp *CachedResponse, status string) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = append([]string{}, v...)
+	}
+	if cfg.StatusHeader != "" {
+		header.Set(cfg.StatusHeader, status)
+	}
+	header.Set("Age", strconv.Itoa(int(time.Since(resp.StoredAt).Seconds())))
+	header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	c.Status(resp.StatusCode)
+	if c.Request.Method == http.MethodHead {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.Write(resp.Body)
+}
+
+func storeResponse(c *gin.Context, cfg ResponseCacheConfig, key string, w *cacheWriter) {
+	if w.Status() != http.StatusOK || w.overflow || c.Request.Method == http.MethodHead {
+		return
+	}
+	header := w.Header()
+	cacheControl := header.Get("Cache-Control")
+	if strings.Contains(cacheControl, "no-store") || strings.Contains(cacheControl, "private") {
+		return
+	}
+	stored := header.Clone()
+	if cfg.StatusHeader != "" {
+		stored.Del(cfg.StatusHeader)
+	}
+	// The body is stored before it is compressed by middleware added before the cache.
+	stored.Del("Content-Length")
+	stored.Del("Content-Encoding")
+	resp := &CachedResponse{
+		StatusCode: w.Status(),
+		Header:     stored,
+		Body:       w.buf.Bytes(),
+		StoredAt:   time.Now(),
+	}
+	err := cfg.Store.Set(c.Request.Context(), key, resp, cfg.TTL+cfg.StaleWhileRevalidate)
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// cacheWriter buffers the response body, when discard is set the response is not written to the
+// client as a stale response has already been sent.
+type cacheWriter struct {
+	gin.ResponseWriter
+	maxSize  int
+	buf      bytes.Buffer
+	overflow bool
+
+	discard bool
+	header  http.Header
+	status  int
+}
+
+func (w *cacheWriter) Header() http.Header {
+	if w.discard {
+		return w.header
+	}
+	return w.ResponseWriter.Header()
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if w.discard {
+		if w.status == 0 {
+			w.status = code
+		}
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheWriter) WriteHeaderNow() {
+	if w.discard {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheWriter) Status() int {
+	if w.discard {
+		if w.status == 0 {
+			return http.StatusOK
+		}
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *cacheWriter) Written() bool {
+	if w.discard {
+		return w.status != 0
+	}
+	return w.ResponseWriter.Written()
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(b) > w.maxSize {
+			w.overflow = true
+			w.buf.Reset()
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	if w.discard {
+		w.WriteHeader(http.StatusOK)
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *cacheWriter) Flush() {
+	if w.discard {
+		return
+	}
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,128 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newResponseCacheEngine(cfg ResponseCacheConfig) (*gin.Engine, *int) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ResponseCache(cfg))
+	calls := 0
+	engine.GET("/items", func(c *gin.Context) {
+		calls++
+		c.Header("Cache-Control", c.Query("cache-control"))
+		c.String(http.StatusOK, "%s %d", c.GetHeader("Accept-Language"), calls)
+	})
+	engine.GET("/large", func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, "this response is too large")
+	})
+	engine.GET("/error", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusInternalServerError)
+	})
+	return engine, &calls
+}
+
+func doResponseCacheRequest(engine *gin.Engine, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponseCache(t *testing.T) {
+	cfg := DefaultResponseCacheConfig()
+	cfg.Store = NewMemoryResponseStore(DefaultMemoryStoreConfig())
+	cfg.VaryHeaders = []string{"Accept-Language"}
+	cfg.MaxBodySize = 10
+	engine, calls := newResponseCacheEngine(cfg)
+
+	rec := doResponseCacheRequest(engine, http.MethodGet, "/items", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, CacheStatusMiss, rec.Header().Get("X-Cache"))
+	require.Equal(t, " 1", rec.Body.String())
+
+	rec = doResponseCacheRequest(engine, http.MethodGet, "/items", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, CacheStatusHit, rec.Header().Get("X-Cache"))
+	require.Equal(t, " 1", rec.Body.String())
+	require.Equal(t, "2", rec.Header().Get("Content-Length"))
+	require.Equal(t, 1, *calls)
+
+	rec = doResponseCacheRequest(engine, http.MethodHead, "/items", nil)
+	require.Equal(t, CacheStatusHit, rec.Header().Get("X-Cache"))
+	require.Empty(t, rec.Body.String())
+
+	rec = doResponseCacheRequest(engine, http.MethodGet, "/items", http.Header{"Accept-Language": []string{"sv"}})
+	require.Equal(t, CacheStatusMiss, rec.Header().Get("X-Cache"))
+	require.Equal(t, "sv 2", rec.Body.String())
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+	}{
+		{
+			name: "no-store response",
+			path: "/items?cache-control=no-store",
+		},
+		{
+			name: "private response",
+			path: "/items?cache-control=private",
+		},
+		{
+			name:   "authorization",
+			path:   "/items?auth",
+			header: http.Header{"Authorization": []string{"Bearer foo"}},
+		},
+		{
+			name: "too large",
+			path: "/large",
+		},
+		{
+			name: "error",
+			path: "/error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *calls
+			doResponseCacheRequest(engine, http.MethodGet, tt.path, tt.header)
+			doResponseCacheRequest(engine, http.MethodGet, tt.path, tt.header)
+			require.Equal(t, before+2, *calls)
+		})
+	}
+}
+
+func TestResponseCacheStaleWhileRevalidate(t *testing.T) {
+	cfg := DefaultResponseCacheConfig()
+	cfg.Store = NewMemoryResponseStore(DefaultMemoryStoreConfig())
+	cfg.TTL = 10 * time.Millisecond
+	cfg.StaleWhileRevalidate = time.Hour
+	engine, calls := newResponseCacheEngine(cfg)
+
+	rec := doResponseCacheRequest(engine, http.MethodGet, "/items", nil)
+	require.Equal(t, " 1", rec.Body.String())
+	time.Sleep(20 * time.Millisecond)
+
+	// The stale response is served while the handler revalidates the cache.
+	rec = doResponseCacheRequest(engine, http.MethodGet, "/items", nil)
+	require.Equal(t, CacheStatusStale, rec.Header().Get("X-Cache"))
+	require.Equal(t, " 1", rec.Body.String())
+	require.Equal(t, 2, *calls)
+
+	rec = doResponseCacheRequest(engine, http.MethodGet, "/items", nil)
+	require.Equal(t, CacheStatusHit, rec.Header().Get("X-Cache"))
+	require.Equal(t, " 2", rec.Body.String())
+}
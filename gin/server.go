@@ -0,0 +1,351 @@
+package gin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type ListenerConfig struct {
+	// Network of the listener, tcp or unix.
+	Network string
+	// Address to listen on, host:port for tcp and the socket path for unix.
+	Address string
+	// TLS config of the listener, overriding the TLS config of the server.
+	TLSConfig *tls.Config
+	// Should requests be served in plain text even if the server has a TLS config, such as for a
+	// health check listener on localhost.
+	PlainText bool
+	// Should HTTP/2 be served in clear text, both with prior knowledge and through an upgrade.
+	// Enable it for plain text listeners receiving HTTP/2 from a proxy such as Envoy. TLS listeners
+	// negotiate HTTP/2 with ALPN if h2 is in the next protos of the TLS config.
+	H2C bool
+	// Should HTTP/3 be served over QUIC on the UDP port of the same address, in addition to the
+	// TCP listener. Responses served over TCP advertise it with the Alt-Svc header. Requires a tcp
+	// listener with TLS. Stop waits for HTTP/3 clients to close their connections until its context
+	// is cancelled, as idle QUIC connections are not closed by the server. HTTP/3 support is
+	// experimental.
+	HTTP3 bool
+}
+
+type ServerConfig struct {
+	// Listeners the handler is served on, such as a TLS listener for external traffic, a plain
+	// text listener on localhost for health checks and a Unix socket for sidecars.
+	Listeners []ListenerConfig
+	// TLS config of the listeners without their own, requests are served in plain text if nil.
+	// Use the server config of a tlsconfig.Reloader to reload certificates without restarting.
+	TLSConfig *tls.Config
+	// Maximum duration for reading request headers.
+	ReadHeaderTimeout time.Duration
+	// Maximum duration for reading the entire request, disabled if zero.
+	ReadTimeout time.Duration
+	// Maximum duration before timing out writes of the response, disabled if zero.
+	WriteTimeout time.Duration
+	// Maximum duration to wait for the next request on keep-alive connections.
+	IdleTimeout time.Duration
+}
+
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Listeners: []ListenerConfig{
+			{
+				Network:   "tcp",
+				Address:   ":8080",
+				TLSConfig: nil,
+				PlainText: false,
+				H2C:       false,
+				HTTP3:     false,
+			},
+		},
+		TLSConfig:         nil,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       0,
+		WriteTimeout:      0,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// Server serves a handler, usually an engine created with NewEngine, on multiple listeners.
+type Server struct {
+	cfg     ServerConfig
+	servers []*http.Server
+	// h3Servers has the HTTP/3 server of each listener, nil if HTTP/3 is disabled.
+	h3Servers []*http3.Server
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	startErr  error
+	started   chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewServer(handler http.Handler, cfg ServerConfig) (*Server, error) {
+	if len(cfg.Listeners) == 0 {
+		return nil, errors.New("at least one listener is required")
+	}
+	listeners := []ListenerConfig{}
+	for i, l := range cfg.Listeners {
+		if l.Network != "tcp" && l.Network != "unix" {
+			return nil, fmt.Errorf("listener %d has unsupported network %s", i, l.Network)
+		}
+		if l.Address == "" {
+			return nil, fmt.Errorf("listener %d address cannot be empty", i)
+		}
+		if l.PlainText && l.TLSConfig != nil {
+			return nil, fmt.Errorf("listener %d cannot be plain text with a TLS config", i)
+		}
+		if !l.PlainText && l.TLSConfig == nil {
+			l.TLSConfig = cfg.TLSConfig
+		}
+		if l.HTTP3 && (l.Network != "tcp" || l.TLSConfig == nil) {
+			return nil, fmt.Errorf("listener %d requires tcp and TLS to serve HTTP/3", i)
+		}
+		listeners = append(listeners, l)
+	}
+	cfg.Listeners = listeners
+	// Each listener has its own server as the handler differs when h2c or HTTP/3 is enabled.
+	servers := []*http.Server{}
+	h3Servers := []*http3.Server{}
+	for _, l := range cfg.Listeners {
+		var h3Server *http3.Server
+		h := handler
+		if l.HTTP3 {
+			h3Server = &http3.Server{
+				Handler:     handler,
+				TLSConfig:   l.TLSConfig,
+				IdleTimeout: cfg.IdleTimeout,
+			}
+			h = altSvc(h, h3Server)
+		}
+		if l.H2C {
+			h = h2c.NewHandler(h, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+		}
+		servers = append(servers, &http.Server{
+			Handler:           h,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		})
+		h3Servers = append(h3Servers, h3Server)
+	}
+	return &Server{
+		cfg:       cfg,
+		servers:   servers,
+		h3Servers: h3Servers,
+		started:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// altSvc advertises HTTP/3 in responses served over TCP so that clients can switch to QUIC.
+func altSvc(next http.Handler, h3Server *http3.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Setting the header fails until the UDP listener is served, clients then stay on TCP.
+		_ = h3Server.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Addrs returns the addresses of the listeners once the server has started, which is useful
+// when listening on port 0. HTTP/3 is served on the UDP port of the same address. The error of Start is returned if the listeners could not be opened.
+func (s *Server) Addrs(ctx context.Context) ([]net.Addr, error) {
+	select {
+	case <-s.started:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.startErr != nil {
+		return nil, s.startErr
+	}
+	addrs := []net.Addr{}
+	for _, l := range s.listeners {
+		addrs = append(addrs, l.Addr())
+	}
+	return addrs, nil
+}
+
+// Start opens all listeners and serves requests until Stop is called or ctx is cancelled. If a
+// listener cannot be opened or fails, the other listeners are shut down and the error is returned.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return errors.New("server has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer close(s.done)
+
+	listeners, packetConns, err := s.listen()
+	if err != nil {
+		s.mu.Lock()
+		s.startErr = err
+		s.mu.Unlock()
+		close(s.started)
+		return err
+	}
+	s.mu.Lock()
+	s.listeners = listeners
+	s.mu.Unlock()
+	close(s.started)
+
+	errCh := make(chan error, len(listeners)+len(packetConns))
+	for i, l := range listeners {
+		go func(server *http.Server, l net.Listener) {
+			errCh <- server.Serve(l)
+		}(s.servers[i], l)
+	}
+	for i, pc := range packetConns {
+		if pc == nil {
+			continue
+		}
+		go func(server *http3.Server, pc net.PacketConn) {
+			errCh <- server.Serve(pc)
+		}(s.h3Servers[i], pc)
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case err := <-errCh:
+		serveErr = err
+	}
+	// Shutdown waits for active requests to complete, Stop bounds the wait by cancelling its
+	// context which closes the remaining connections.
+	var shutdownErr error
+	for _, server := range s.servers {
+		err := server.Shutdown(context.Background())
+		if err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+	for i, server := range s.h3Servers {
+		if server == nil {
+			continue
+		}
+		err := server.Shutdown(context.Background())
+		if err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+		// The HTTP/3 server does not close the connection it serves.
+		packetConns[i].Close()
+	}
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return shutdownErr
+}
+
+// Stop gracefully shuts down all listeners, active connections are closed when ctx is cancelled.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		for _, server := range s.servers {
+			server.Close()
+		}
+		for _, server := range s.h3Servers {
+			if server != nil {
+				server.Close()
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// listen opens the listeners and, for listeners serving HTTP/3, a UDP connection on the port of
+// the TCP listener. Everything opened is closed again if a listener cannot be opened.
+func (s *Server) listen() ([]net.Listener, []net.PacketConn, error) {
+	listeners := []net.Listener{}
+	packetConns := []net.PacketConn{}
+	closeAll := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+		for _, pc := range packetConns {
+			if pc != nil {
+				pc.Close()
+			}
+		}
+	}
+	for _, cfg := range s.cfg.Listeners {
+		l, err := listen(cfg)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		listeners = append(listeners, l)
+		var pc net.PacketConn
+		if cfg.HTTP3 {
+			pc, err = net.ListenPacket("udp", l.Addr().String())
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+		}
+		packetConns = append(packetConns, pc)
+	}
+	return listeners, packetConns, nil
+}
+
+func listen(cfg ListenerConfig) (net.Listener, error) {
+	if cfg.Network == "unix" {
+		err := removeStaleSocket(cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen(cfg.Network, cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLSConfig != nil {
+		return tls.NewListener(l, cfg.TLSConfig), nil
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes a socket left behind by a previous process, which would cause the
+// listen to fail. Sockets which accept connections are kept so that a running process is not
+// hijacked, the listen fails instead.
+func removeStaleSocket(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Type() != fs.ModeSocket {
+		return nil
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return nil
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,270 @@
+package gin
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestServerMultipleListeners(t *testing.T) {
+	// The test server provides a certificate and a client trusting it.
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	socket := filepath.Join(t.TempDir(), "server.sock")
+	cfg := DefaultServerConfig()
+	cfg.TLSConfig = &tls.Config{Certificates: tlsServer.TLS.Certificates}
+	cfg.Listeners = []ListenerConfig{
+		{Network: "tcp", Address: "127.0.0.1:0"},
+		{Network: "tcp", Address: "127.0.0.1:0", PlainText: true},
+		{Network: "unix", Address: socket, PlainText: true},
+	}
+	server, err := NewServer(engine, cfg)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(context.Background())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := server.Addrs(ctx)
+	require.NoError(t, err)
+	require.Len(t, addrs, 3)
+
+	get := func(client *http.Client, url string) string {
+		t.Helper()
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+	require.Equal(t, "ok", get(tlsServer.Client(), "https://"+addrs[0].String()))
+	require.Equal(t, "ok", get(http.DefaultClient, "http://"+addrs[1].String()))
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	require.Equal(t, "ok", get(unixClient, "http://unix/"))
+
+	err = server.Start(context.Background())
+	require.EqualError(t, err, "server has already been started")
+	require.NoError(t, server.Stop(ctx))
+	require.NoError(t, <-errCh)
+	_, err = http.Get("http://" + addrs[1].String())
+	require.Error(t, err)
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "server.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	err = removeStaleSocket(socket)
+	require.EqualError(t, err, fmt.Sprintf("socket %s is in use", socket))
+	require.FileExists(t, socket)
+
+	// Closing without unlinking leaves a stale socket like a process which was killed.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	require.FileExists(t, socket)
+	err = removeStaleSocket(socket)
+	require.NoError(t, err)
+	require.NoFileExists(t, socket)
+
+	require.NoError(t, removeStaleSocket(socket))
+}
+
+func TestServerH2C(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	engineCfg := DefaultConfig()
+	engineCfg.MetricsConfig.Registerer = reg
+	engineCfg.MetricsConfig.ProtocolLabel = true
+	engine := NewEngine(engineCfg)
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	cfg := DefaultServerConfig()
+	cfg.Listeners = []ListenerConfig{
+		{Network: "tcp", Address: "127.0.0.1:0", H2C: true},
+		{Network: "tcp", Address: "127.0.0.1:0"},
+	}
+	server, err := NewServer(engine, cfg)
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(context.Background())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := server.Addrs(ctx)
+	require.NoError(t, err)
+
+	// Prior knowledge HTTP/2 client dialing plain text connections.
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	for _, tt := range []struct {
+		addr     net.Addr
+		expected string
+	}{
+		{addr: addrs[0], expected: "HTTP/2.0"},
+		{addr: addrs[1], expected: "HTTP/1.1"},
+	} {
+		client := http.DefaultClient
+		if tt.expected == "HTTP/2.0" {
+			client = h2cClient
+		}
+		resp, err := client.Get("http://" + tt.addr.String())
+		require.NoError(t, err)
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, tt.expected, string(b))
+	}
+
+	require.ElementsMatch(t, []string{"HTTP/1.1", "HTTP/2.0"}, protocolLabels(t, reg))
+
+	require.NoError(t, server.Stop(ctx))
+	require.NoError(t, <-errCh)
+}
+
+func TestServerHTTP3(t *testing.T) {
+	// The test server provides a certificate and a client trusting it.
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	reg := prometheus.NewRegistry()
+	engineCfg := DefaultConfig()
+	engineCfg.MetricsConfig.Registerer = reg
+	engineCfg.MetricsConfig.ProtocolLabel = true
+	engine := NewEngine(engineCfg)
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Proto)
+	})
+	cfg := DefaultServerConfig()
+	cfg.TLSConfig = &tls.Config{Certificates: tlsServer.TLS.Certificates}
+	cfg.Listeners = []ListenerConfig{{Network: "tcp", Address: "127.0.0.1:0", HTTP3: true}}
+	server, err := NewServer(engine, cfg)
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(context.Background())
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := server.Addrs(ctx)
+	require.NoError(t, err)
+	url := "https://" + addrs[0].String()
+
+	resp, err := tlsServer.Client().Get(url)
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "HTTP/1.1", string(b))
+	port := addrs[0].(*net.TCPAddr).Port
+	require.Equal(t, fmt.Sprintf(`h3=":%d"; ma=2592000`, port), resp.Header.Get("Alt-Svc"))
+
+	h3Transport := &http3.Transport{
+		TLSClientConfig: tlsServer.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+	resp, err = (&http.Client{Transport: h3Transport}).Get(url)
+	require.NoError(t, err)
+	b, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "HTTP/3.0", string(b))
+
+	require.ElementsMatch(t, []string{"HTTP/1.1", "HTTP/3.0"}, protocolLabels(t, reg))
+
+	// Shutdown waits for HTTP/3 clients to close their connections.
+	require.NoError(t, h3Transport.Close())
+	require.NoError(t, server.Stop(ctx))
+	require.NoError(t, <-errCh)
+}
+
+// protocolLabels returns the protocol label values of the request duration metric.
+func protocolLabels(t *testing.T, reg *prometheus.Registry) []string {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	protocols := []string{}
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "protocol" {
+					protocols = append(protocols, label.GetValue())
+				}
+			}
+		}
+	}
+	return protocols
+}
+
+func TestServerListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	cfg := DefaultServerConfig()
+	cfg.Listeners = []ListenerConfig{
+		{Network: "tcp", Address: "127.0.0.1:0"},
+		{Network: "tcp", Address: l.Addr().String()},
+	}
+	server, err := NewServer(http.NotFoundHandler(), cfg)
+	require.NoError(t, err)
+	err = server.Start(context.Background())
+	require.Error(t, err)
+
+	// Addrs should not block when the listeners could not be opened.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, addrsErr := server.Addrs(ctx)
+	require.Equal(t, err, addrsErr)
+}
+
+func TestNewServerInvalidConfig(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.Listeners = nil
+	_, err := NewServer(http.NotFoundHandler(), cfg)
+	require.EqualError(t, err, "at least one listener is required")
+
+	cfg.Listeners = []ListenerConfig{{Network: "udp", Address: ":0"}}
+	_, err = NewServer(http.NotFoundHandler(), cfg)
+	require.EqualError(t, err, "listener 0 has unsupported network udp")
+
+	cfg.Listeners = []ListenerConfig{{Network: "tcp", Address: ":0", PlainText: true, TLSConfig: &tls.Config{}}}
+	_, err = NewServer(http.NotFoundHandler(), cfg)
+	require.EqualError(t, err, "listener 0 cannot be plain text with a TLS config")
+
+	cfg.Listeners = []ListenerConfig{{Network: "tcp", Address: ":0", HTTP3: true}}
+	_, err = NewServer(http.NotFoundHandler(), cfg)
+	require.EqualError(t, err, "listener 0 requires tcp and TLS to serve HTTP/3")
+}
@@ -0,0 +1,399 @@
+package gin
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionKey = "session.value"
+	// SessionIDKey is the context key of the session ID, add it to LogConfig.IncludeKeys to log
+	// session IDs.
+	SessionIDKey = "session.id"
+)
+
+// SessionStore stores session values server side, only the session ID is stored in the cookie
+// when a store is used. Implementations have to be safe for concurrent use.
+type SessionStore interface {
+	// Get returns the values of the session, false is returned if it does not exist or has expired.
+	Get(ctx context.Context, id string) (map[string]string, bool, error)
+	// Set stores the values of the session until ttl has passed.
+	Set(ctx context.Context, id string, values map[string]string, ttl time.Duration) error
+	// Delete removes the session.
+	Delete(ctx context.Context, id string) error
+}
+
+type memorySessionStore struct {
+	store *memoryStore[map[string]string]
+}
+
+// NewMemorySessionStore returns a store keeping sessions in memory, each session is stored with
+// the max age of the session config.
+func NewMemorySessionStore(cfg MemoryStoreConfig) SessionStore {
+	return &memorySessionStore{
+		store: newMemoryStore[map[string]string](cfg),
+	}
+}
+
+func (s *memorySessionStore) Get(ctx context.Context, id string) (map[string]string, bool, error) {
+	values, ok := s.store.get(id)
+	return values, ok, nil
+}
+
+func (s *memorySessionStore) Set(ctx context.Context, id string, values map[string]string, ttl time.Duration) error {
+	s.store.set(id, values, ttl)
+	return nil
+}
+
+func (s *memorySessionStore) Delete(ctx context.Context, id string) error {
+	s.store.delete(id)
+	return nil
+}
+
+type SessionConfig struct {
+	// Name of the session cookie.
+	CookieName string
+	// Keys used to encrypt and authenticate the cookie, each 16, 24 or 32 bytes long. The first
+	// key is used to seal cookies while all keys are used to open them, so keys can be rotated by
+	// adding a new key first and removing the old key after MaxAge has passed.
+	Keys [][]byte
+	// Store for session values, values are stored in the cookie if nil.
+	Store SessionStore
+	// Duration a session is valid after it was last saved.
+	MaxAge time.Duration
+	// Path and domain of the cookie.
+	Path   string
+	Domain string
+	// Should the cookie only be sent over HTTPS.
+	Secure bool
+	// SameSite attribute of the cookie.
+	SameSite http.SameSite
+}
+
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		CookieName: "session",
+		Keys:       nil,
+		Store:      nil,
+		MaxAge:     24 * time.Hour,
+		Path:       "/",
+		Domain:     "",
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+}
+
+// Session holds the values of a session, it is only valid during the request.
+type Session struct {
+	id       string
+	values   map[string]string
+	previous string
+	modified bool
+	cleared  bool
+	// stored is set when the values are kept in a session store instead of the cookie.
+	stored bool
+}
+
+// ID returns the session ID, empty for new sessions which have not been saved.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Get returns the value of key, false is returned if it is not set.
+func (s *Session) Get(key string) (string, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set sets the value of key, the session is saved when the response is written.
+func (s *Session) Set(key, value string) {
+	s.values[key] = value
+	s.modified = true
+	s.cleared = false
+}
+
+// Delete removes the value of key.
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+	s.modified = true
+}
+
+// Clear removes all values and deletes the session, the cookie is expired unless values are set
+// again in the same request.
+func (s *Session) Clear() {
+	s.Renew()
+	s.values = map[string]string{}
+	s.cleared = true
+}
+
+// Renew assigns a new session ID while keeping the values, it should be called when the privilege
+// level changes, such as after login, to prevent session fixation.
+func (s *Session) Renew() {
+	if s.id != "" && s.previous == "" {
+		s.previous = s.id
+	}
+	s.id = ""
+	s.modified = true
+}
+
+// SessionFromContext returns the session of the request, nil if the session middleware is not used.
+func SessionFromContext(c *gin.Context) *Session {
+	v, ok := c.Get(sessionKey)
+	if !ok {
+		return nil
+	}
+	session, _ := v.(*Session)
+	return session
+}
+
+// SessionManager loads sessions from cookies and saves modified sessions.
+type SessionManager struct {
+	cfg   SessionConfig
+	aeads []cipher.AEAD
+	now   func() time.Time
+}
+
+func NewSessionManager(cfg SessionConfig) (*SessionManager, error) {
+	if cfg.CookieName == "" {
+		return nil, errors.New("cookie name cannot be empty")
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+	if cfg.MaxAge <= 0 {
+		return nil, errors.New("max age has to be larger than zero")
+	}
+	aeads := []cipher.AEAD{}
+	for i, key := range cfg.Keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d: %w", i, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		aeads = append(aeads, aead)
+	}
+	return &SessionManager{
+		cfg:   cfg,
+		aeads: aeads,
+		now:   time.Now,
+	}, nil
+}
+
+type sessionCookie struct {
+	ID      string            `json:"i"`
+	Values  map[string]string `json:"v,omitempty"`
+	Expires int64             `json:"e"`
+}
+
+// Middleware loads the session of the request, the session is saved before the response header
+// is written if it has been modified. Errors from the store are added to the context errors and
+// the request continues with an empty session.
+func (m *SessionManager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := m.load(c)
+		c.Set(sessionKey, session)
+		if session.id != "" {
+			c.Set(SessionIDKey, session.id)
+		}
+		w := &sessionWriter{ResponseWriter: c.Writer, commit: func() {
+			m.save(c, session)
+		}}
+		c.Writer = w
+		c.Next()
+		w.commitOnce()
+		c.Writer = w.ResponseWriter
+	}
+}
+
+func (m *SessionManager) load(c *gin.Context) *Session {
+	session := &Session{values: map[string]string{}, stored: m.cfg.Store != nil}
+	value, err := c.Cookie(m.cfg.CookieName)
+	if err != nil || value == "" {
+		return session
+	}
+	cookie, rotated, ok := m.open(value)
+	if !ok || m.now().Unix() > cookie.Expires {
+		return session
+	}
+	if m.cfg.Store == nil {
+		session.id = cookie.ID
+		if cookie.Values != nil {
+			session.values = cookie.Values
+		}
+		session.modified = rotated
+		return session
+	}
+	values, ok, err := m.cfg.Store.Get(c.Request.Context(), cookie.ID)
+	if err != nil {
+		c.Error(err)
+		return session
+	}
+	if !ok {
+		return session
+	}
+	session.id = cookie.ID
+	for k, v := range values {
+		session.values[k] = v
+	}
+	session.modified = rotated
+	return session
+}
+
+func (m *SessionManager) save(c *gin.Context, session *Session) {
+	if !session.modified {
+		return
+	}
+	ctx := c.Request.Context()
+	if session.previous != "" && m.cfg.Store != nil {
+		err := m.cfg.Store.Delete(ctx, session.previous)
+		if err != nil {
+			c.Error(err)
+		}
+	}
+	if session.cleared {
+		http.SetCookie(c.Writer, m.cookie("", -1))
+		return
+	}
+	if session.id == "" {
+		id, err := newSessionID()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		session.id = id
+		c.Set(SessionIDKey, id)
+	}
+	cookie := sessionCookie{
+		ID:      session.id,
+		Expires: m.now().Add(m.cfg.MaxAge).Unix(),
+	}
+	if m.cfg.Store == nil {
+		cookie.Values = session.values
+	} else {
+		err := m.cfg.Store.Set(ctx, session.id, session.values, m.cfg.MaxAge)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	value, err := m.seal(cookie)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	http.SetCookie(c.Writer, m.cookie(value, int(m.cfg.MaxAge.Seconds())))
+}
+
+func (m *SessionManager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.cfg.CookieName,
+		Value:    value,
+		Path:     m.cfg.Path,
+		Domain:   m.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.cfg.Secure,
+		HttpOnly: true,
+		SameSite: m.cfg.SameSite,
+	}
+}
+
+// seal encrypts the cookie with the first key, the cookie name is used as additional data so that
+// values cannot be moved between cookies sealed with the same keys.
+func (m *SessionManager) seal(cookie sessionCookie) (string, error) {
+	b, err := json.Marshal(cookie)
+	if err != nil {
+		return "", err
+	}
+	aead := m.aeads[0]
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, b, []byte(m.cfg.CookieName))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts the cookie with each key in order, rotated is true if it was not sealed with the
+// first key.
+func (m *SessionManager) open(value string) (sessionCookie, bool, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return sessionCookie{}, false, false
+	}
+	for i, aead := range m.aeads {
+		if len(b) < aead.NonceSize() {
+			continue
+		}
+		plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(m.cfg.CookieName))
+		if err != nil {
+			continue
+		}
+		cookie := sessionCookie{}
+		err = json.Unmarshal(plain, &cookie)
+		if err != nil || cookie.ID == "" {
+			return sessionCookie{}, false, false
+		}
+		return cookie, i > 0, true
+	}
+	return sessionCookie{}, false, false
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sessionWriter saves the session before the response header is written, as the cookie cannot
+// be set afterwards. WriteHeader is not intercepted as gin delays writing the header until the
+// body is written.
+type sessionWriter struct {
+	gin.ResponseWriter
+	commit    func()
+	committed bool
+}
+
+func (w *sessionWriter) commitOnce() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	w.commit()
+}
+
+func (w *sessionWriter) WriteHeaderNow() {
+	w.commitOnce()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sessionWriter) WriteString(s string) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *sessionWriter) Flush() {
+	w.commitOnce()
+	w.ResponseWriter.Flush()
+}
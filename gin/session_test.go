@@ -0,0 +1,139 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func newSessionEngine(t *testing.T, cfg SessionConfig, logCfg LogConfig) *gin.Engine {
+	t.Helper()
+	manager, err := NewSessionManager(cfg)
+	require.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Logger(logCfg))
+	engine.Use(manager.Middleware())
+	engine.GET("/get", func(c *gin.Context) {
+		v, _ := SessionFromContext(c).Get("user")
+		c.String(http.StatusOK, v)
+	})
+	engine.POST("/login", func(c *gin.Context) {
+		session := SessionFromContext(c)
+		session.Renew()
+		session.Set("user", c.Query("user"))
+		c.Status(http.StatusNoContent)
+	})
+	engine.POST("/logout", func(c *gin.Context) {
+		SessionFromContext(c).Clear()
+		c.Status(http.StatusNoContent)
+	})
+	return engine
+}
+
+func doSessionRequest(engine *gin.Engine, method, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		name  string
+		store SessionStore
+	}{
+		{
+			name: "cookie",
+		},
+		{
+			name:  "store",
+			store: NewMemorySessionStore(DefaultMemoryStoreConfig()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := DefaultSessionConfig()
+			cfg.Keys = [][]byte{bytes.Repeat([]byte("a"), 32)}
+			cfg.Store = tt.store
+			engine := newSessionEngine(t, cfg, LogConfig{Logger: buflogr.NewWithBuffer(&buf), IncludeKeys: []string{SessionIDKey}})
+
+			rec := doSessionRequest(engine, http.MethodGet, "/get", nil)
+			require.Empty(t, rec.Body.String())
+			require.Empty(t, rec.Result().Cookies())
+
+			rec = doSessionRequest(engine, http.MethodPost, "/login?user=alice", nil)
+			cookies := rec.Result().Cookies()
+			require.Len(t, cookies, 1)
+			require.True(t, cookies[0].HttpOnly)
+			require.True(t, cookies[0].Secure)
+			require.NotContains(t, cookies[0].Value, "alice")
+			require.Contains(t, buf.String(), SessionIDKey)
+
+			rec = doSessionRequest(engine, http.MethodGet, "/get", cookies)
+			require.Equal(t, "alice", rec.Body.String())
+			require.Empty(t, rec.Result().Cookies())
+
+			rec = doSessionRequest(engine, http.MethodPost, "/logout", cookies)
+			require.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+			if tt.store != nil {
+				// The session is deleted from the store, so the old cookie is no longer valid.
+				rec = doSessionRequest(engine, http.MethodGet, "/get", cookies)
+				require.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSessionKeyRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("a"), 32)
+	newKey := bytes.Repeat([]byte("b"), 32)
+	cfg := DefaultSessionConfig()
+	cfg.Keys = [][]byte{oldKey}
+	engine := newSessionEngine(t, cfg, LogConfig{Logger: buflogr.New()})
+	cookies := doSessionRequest(engine, http.MethodPost, "/login?user=alice", nil).Result().Cookies()
+
+	cfg.Keys = [][]byte{newKey, oldKey}
+	engine = newSessionEngine(t, cfg, LogConfig{Logger: buflogr.New()})
+	rec := doSessionRequest(engine, http.MethodGet, "/get", cookies)
+	require.Equal(t, "alice", rec.Body.String())
+	// The cookie is resealed with the new key.
+	rotated := rec.Result().Cookies()
+	require.Len(t, rotated, 1)
+
+	cfg.Keys = [][]byte{newKey}
+	engine = newSessionEngine(t, cfg, LogConfig{Logger: buflogr.New()})
+	require.Empty(t, doSessionRequest(engine, http.MethodGet, "/get", cookies).Body.String())
+	require.Equal(t, "alice", doSessionRequest(engine, http.MethodGet, "/get", rotated).Body.String())
+}
+
+func TestSessionExpired(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.Keys = [][]byte{bytes.Repeat([]byte("a"), 32)}
+	manager, err := NewSessionManager(cfg)
+	require.NoError(t, err)
+	value, err := manager.seal(sessionCookie{ID: "foo", Values: map[string]string{"user": "alice"}, Expires: time.Now().Add(-time.Minute).Unix()})
+	require.NoError(t, err)
+	engine := newSessionEngine(t, cfg, LogConfig{Logger: buflogr.New()})
+	rec := doSessionRequest(engine, http.MethodGet, "/get", []*http.Cookie{{Name: "session", Value: value}})
+	require.Empty(t, rec.Body.String())
+}
+
+func TestNewSessionManagerInvalidConfig(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	_, err := NewSessionManager(cfg)
+	require.EqualError(t, err, "at least one key is required")
+	cfg.Keys = [][]byte{[]byte("short")}
+	_, err = NewSessionManager(cfg)
+	require.EqualError(t, err, "invalid key 0: crypto/aes: invalid key size 5")
+}
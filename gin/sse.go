@@ -0,0 +1,170 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrSSEClosed is returned when sending on a stream whose client has disconnected.
+var ErrSSEClosed = errors.New("sse stream closed")
+
+// SSEEvent is a single server-sent event.
+type SSEEvent struct {
+	// ID of the event, sent back by the client as Last-Event-ID when reconnecting.
+	ID string
+	// Name of the event, the client default of "message" is used if empty.
+	Event string
+	// Data of the event, multiple lines are sent as multiple data fields.
+	Data string
+}
+
+type SSEConfig struct {
+	// Interval between heartbeat comments keeping the connection open through proxies, disabled if zero.
+	HeartbeatInterval time.Duration
+	// Number of events buffered before Send blocks.
+	BufferSize int
+	// Reconnection delay sent to the client, not sent if zero.
+	Retry time.Duration
+	// Called with the Last-Event-ID header when a client reconnects. Events returned are sent
+	// before any events sent by the handler.
+	OnResume func(ctx context.Context, lastEventID string) ([]SSEEvent, error)
+}
+
+func DefaultSSEConfig() SSEConfig {
+	return SSEConfig{
+		HeartbeatInterval: 15 * time.Second,
+		BufferSize:        16,
+		Retry:             0,
+		OnResume:          nil,
+	}
+}
+
+// SSEStream sends events to a connected client.
+type SSEStream struct {
+	lastEventID string
+	ctx         context.Context
+	events      chan SSEEvent
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting client.
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send queues an event, blocking while the buffer is full so that slow clients slow down the
+// handler instead of growing memory.
+func (s *SSEStream) Send(ctx context.Context, ev SSEEvent) error {
+	select {
+	case s.events <- ev:
+		return nil
+	case <-s.ctx.Done():
+		return ErrSSEClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SSEHandler produces events for a client, the stream is ended when it returns. The context is
+// cancelled when the client disconnects.
+type SSEHandler func(ctx context.Context, stream *SSEStream) error
+
+// SSE streams the events sent by handler as text/event-stream.
+func SSE(cfg SSEConfig, handler SSEHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		stream := &SSEStream{
+			lastEventID: c.GetHeader("Last-Event-ID"),
+			ctx:         ctx,
+			events:      make(chan SSEEvent, cfg.BufferSize),
+		}
+		resumed := []SSEEvent{}
+		if stream.lastEventID != "" && cfg.OnResume != nil {
+			var err error
+			resumed, err = cfg.OnResume(ctx, stream.lastEventID)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		// Disables response buffering in nginx.
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		if cfg.Retry > 0 {
+			fmt.Fprintf(c.Writer, "retry: %d\n\n", cfg.Retry.Milliseconds())
+		}
+		for _, ev := range resumed {
+			writeSSEEvent(c.Writer, ev)
+		}
+		c.Writer.Flush()
+
+		handlerErr := make(chan error, 1)
+		go func() {
+			handlerErr <- handler(ctx, stream)
+		}()
+
+		var heartbeat <-chan time.Time
+		if cfg.HeartbeatInterval > 0 {
+			ticker := time.NewTicker(cfg.HeartbeatInterval)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+		for {
+			select {
+			case ev := <-stream.events:
+				writeSSEEvent(c.Writer, ev)
+				c.Writer.Flush()
+			case <-heartbeat:
+				io.WriteString(c.Writer, ": heartbeat\n\n")
+				c.Writer.Flush()
+			case err := <-handlerErr:
+				// Events sent before the handler returned are written before ending the stream.
+				for len(stream.events) > 0 {
+					writeSSEEvent(c.Writer, <-stream.events)
+				}
+				c.Writer.Flush()
+				if err != nil && !isSSEClosed(err) {
+					c.Error(err)
+				}
+				return
+			case <-ctx.Done():
+				err := <-handlerErr
+				if err != nil && !isSSEClosed(err) {
+					c.Error(err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// isSSEClosed returns true for errors caused by the client disconnecting.
+func isSSEClosed(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, ErrSSEClosed)
+}
+
+func writeSSEEvent(w io.Writer, ev SSEEvent) {
+	b := strings.Builder{}
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + ev.Event + "\n")
+	}
+	for _, line := range strings.Split(ev.Data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,97 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	cfg := DefaultSSEConfig()
+	cfg.Retry = 5 * time.Second
+	engine := gin.New()
+	engine.GET("/events", SSE(cfg, func(ctx context.Context, stream *SSEStream) error {
+		err := stream.Send(ctx, SSEEvent{ID: "1", Event: "update", Data: "foo"})
+		if err != nil {
+			return err
+		}
+		return stream.Send(ctx, SSEEvent{Data: "bar\nbaz"})
+	}))
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	require.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t, "retry: 5000\n\nid: 1\nevent: update\ndata: foo\n\ndata: bar\ndata: baz\n\n", rec.Body.String())
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	cfg := DefaultSSEConfig()
+	cfg.HeartbeatInterval = 5 * time.Millisecond
+	engine := gin.New()
+	engine.GET("/events", SSE(cfg, func(ctx context.Context, stream *SSEStream) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}))
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	require.Contains(t, rec.Body.String(), ": heartbeat\n\n")
+}
+
+func TestSSEResume(t *testing.T) {
+	cfg := DefaultSSEConfig()
+	cfg.OnResume = func(ctx context.Context, lastEventID string) ([]SSEEvent, error) {
+		if lastEventID == "invalid" {
+			return nil, errors.New("unknown event id")
+		}
+		return []SSEEvent{{ID: "2", Data: "missed"}}, nil
+	}
+	engine := gin.New()
+	engine.GET("/events", SSE(cfg, func(ctx context.Context, stream *SSEStream) error {
+		require.Equal(t, "1", stream.LastEventID())
+		return stream.Send(ctx, SSEEvent{ID: "3", Data: "new"})
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, "id: 2\ndata: missed\n\nid: 3\ndata: new\n\n", rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "invalid")
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestSSEClientDisconnect(t *testing.T) {
+	cancelled := make(chan struct{})
+	engine := gin.New()
+	engine.GET("/events", SSE(DefaultSSEConfig(), func(ctx context.Context, stream *SSEStream) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.ServeHTTP(rec, req)
+	}()
+	cancel()
+	<-done
+	<-cancelled
+	require.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,230 @@
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ErrFileTooLarge   = errors.New("file exceeds maximum size")
+	ErrTypeNotAllowed = errors.New("file type is not allowed")
+)
+
+type UploadConfig struct {
+	// Maximum size of each file in bytes, zero disables the limit.
+	MaxFileSize int64
+	// MIME types allowed as detected from the file content, all types are allowed if empty.
+	AllowedTypes []string
+	// Directory temporary files are written to by SaveUploads, the default temporary directory is used if empty.
+	TempDir string
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		MaxFileSize:  32 << 20,
+		AllowedTypes: nil,
+		TempDir:      "",
+		Registerer:   nil,
+	}
+}
+
+// UploadPart is a file in a multipart request.
+type UploadPart struct {
+	FieldName string
+	FileName  string
+	// ContentType is detected from the file content rather than trusted from the request.
+	ContentType string
+	// Reader returns ErrFileTooLarge when the file exceeds the maximum size.
+	Reader io.Reader
+}
+
+// UploadedFile is a file written to a temporary file by SaveUploads.
+type UploadedFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Size        int64
+	// Path of the temporary file, which has to be removed by the caller.
+	Path string
+}
+
+// StreamUpload calls fn for each file in the multipart request body without buffering the files
+// in memory or on disk. Form fields which are not files are skipped. Reading stops at the first
+// error, ErrFileTooLarge and ErrTypeNotAllowed can be checked with errors.Is.
+func StreamUpload(c *gin.Context, cfg UploadConfig, fn func(part UploadPart) error) error {
+	var m *uploadMetrics
+	if cfg.Registerer != nil {
+		var err error
+		m, err = newUploadMetrics(cfg.Registerer)
+		if err != nil {
+			return err
+		}
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		err = streamPart(cfg, m, part.FormName(), part.FileName(), part, fn)
+		part.Close()
+		if m != nil {
+			m.files.WithLabelValues(uploadStatus(err)).Inc()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func streamPart(cfg UploadConfig, m *uploadMetrics, fieldName, fileName string, r io.Reader, fn func(part UploadPart) error) error {
+	r = &limitedReader{r: r, max: cfg.MaxFileSize, metrics: m}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !typeAllowed(contentType, cfg.AllowedTypes) {
+		return fmt.Errorf("%w: %s", ErrTypeNotAllowed, contentType)
+	}
+	return fn(UploadPart{
+		FieldName:   fieldName,
+		FileName:    fileName,
+		ContentType: contentType,
+		Reader:      io.MultiReader(bytes.NewReader(head), r),
+	})
+}
+
+// SaveUploads writes each file in the multipart request body to a temporary file. Temporary files
+// are removed if an error is returned, otherwise the caller is responsible for removing them.
+func SaveUploads(c *gin.Context, cfg UploadConfig) ([]UploadedFile, error) {
+	files := []UploadedFile{}
+	err := StreamUpload(c, cfg, func(part UploadPart) error {
+		f, err := os.CreateTemp(cfg.TempDir, "upload-*")
+		if err != nil {
+			return err
+		}
+		size, err := io.Copy(f, part.Reader)
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+		files = append(files, UploadedFile{
+			FieldName:   part.FieldName,
+			FileName:    part.FileName,
+			ContentType: part.ContentType,
+			Size:        size,
+			Path:        f.Name(),
+		})
+		return nil
+	})
+	if err != nil {
+		for _, f := range files {
+			os.Remove(f.Path)
+		}
+		return nil, err
+	}
+	return files, nil
+}
+
+func typeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if a == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+func uploadStatus(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrFileTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrTypeNotAllowed):
+		return "type_not_allowed"
+	default:
+		return "error"
+	}
+}
+
+// limitedReader returns ErrFileTooLarge once more than max bytes have been read, zero disables the limit.
+type limitedReader struct {
+	r       io.Reader
+	max     int64
+	read    int64
+	metrics *uploadMetrics
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.metrics != nil {
+		l.metrics.bytes.Add(float64(n))
+	}
+	if l.max > 0 && l.read > l.max {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+type uploadMetrics struct {
+	bytes prometheus.Counter
+	files *prometheus.CounterVec
+}
+
+func newUploadMetrics(reg prometheus.Registerer) (*uploadMetrics, error) {
+	received, err := register(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "upload_received_bytes_total",
+		Help: "Total number of bytes received in uploaded files.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	files, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "upload_files_total",
+		Help: "Total number of uploaded files by status.",
+	}, []string{"status"}))
+	if err != nil {
+		return nil, err
+	}
+	return &uploadMetrics{
+		bytes: received,
+		files: files,
+	}, nil
+}
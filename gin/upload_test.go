@@ -0,0 +1,94 @@
+package gin
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func newUploadContext(t *testing.T, files map[string][]byte) *gin.Context {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	err := w.WriteField("description", "foo")
+	require.NoError(t, err)
+	for name, content := range files {
+		fw, err := w.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	err = w.Close()
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/upload", body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	return c
+}
+
+func TestSaveUploads(t *testing.T) {
+	content := append(pngHeader, bytes.Repeat([]byte{0}, 1000)...)
+	c := newUploadContext(t, map[string][]byte{"image.png": content})
+	reg := prometheus.NewRegistry()
+	cfg := DefaultUploadConfig()
+	cfg.AllowedTypes = []string{"image/png"}
+	cfg.TempDir = t.TempDir()
+	cfg.Registerer = reg
+
+	files, err := SaveUploads(c, cfg)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "file", files[0].FieldName)
+	require.Equal(t, "image.png", files[0].FileName)
+	require.Equal(t, "image/png", files[0].ContentType)
+	require.Equal(t, int64(len(content)), files[0].Size)
+	b, err := os.ReadFile(files[0].Path)
+	require.NoError(t, err)
+	require.Equal(t, content, b)
+
+	m, err := newUploadMetrics(reg)
+	require.NoError(t, err)
+	require.Equal(t, float64(len(content)), testutil.ToFloat64(m.bytes))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.files.WithLabelValues("ok")))
+}
+
+func TestSaveUploadsTooLarge(t *testing.T) {
+	c := newUploadContext(t, map[string][]byte{"large.txt": bytes.Repeat([]byte("a"), 2000)})
+	cfg := DefaultUploadConfig()
+	cfg.MaxFileSize = 1000
+	cfg.TempDir = t.TempDir()
+
+	_, err := SaveUploads(c, cfg)
+	require.ErrorIs(t, err, ErrFileTooLarge)
+	entries, err := os.ReadDir(cfg.TempDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestStreamUploadTypeNotAllowed(t *testing.T) {
+	c := newUploadContext(t, map[string][]byte{"script.sh": []byte("#!/bin/sh\necho hello")})
+	cfg := DefaultUploadConfig()
+	cfg.AllowedTypes = []string{"image/png"}
+
+	called := false
+	err := StreamUpload(c, cfg, func(part UploadPart) error {
+		called = true
+		return nil
+	})
+	require.True(t, errors.Is(err, ErrTypeNotAllowed))
+	require.EqualError(t, err, "file type is not allowed: text/plain; charset=utf-8")
+	require.False(t, called)
+}
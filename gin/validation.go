@@ -0,0 +1,196 @@
+package gin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const validatedKey = "validation.value"
+
+// Bind binds the request with the binding for its method and content type, and validates the
+// result with the binding tags. Failures are written as problem details and false is returned,
+// in which case the handler should return without writing a response.
+func Bind(c *gin.Context, obj interface{}) bool {
+	return BindWith(c, obj, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+// BindWith is like Bind but uses the given binding, for example binding.Query.
+func BindWith(c *gin.Context, obj interface{}, b binding.Binding) bool {
+	err := c.ShouldBindWith(obj, b)
+	if err != nil {
+		AbortWithProblem(c, bindingProblem(err, obj, tagForBinding(b.Name())))
+		return false
+	}
+	return true
+}
+
+// Validate returns a middleware binding the uri parameters and the request into a new T. The
+// request is aborted with problem details if binding or validation fails, otherwise the value
+// is available to later handlers through Validated.
+func Validate[T any]() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		obj := new(T)
+		if len(c.Params) > 0 {
+			// Validation is done once the request has been bound, as it applies to the whole struct.
+			params := map[string][]string{}
+			for _, p := range c.Params {
+				params[p.Key] = []string{p.Value}
+			}
+			err := binding.MapFormWithTag(obj, params, "uri")
+			if err != nil {
+				AbortWithProblem(c, bindingProblem(err, obj, "uri"))
+				return
+			}
+		}
+		if !Bind(c, obj) {
+			return
+		}
+		c.Set(validatedKey, obj)
+		c.Next()
+	}
+}
+
+// Validated returns the value bound by Validate, nil is returned if Validate has not been run
+// for the same type.
+func Validated[T any](c *gin.Context) *T {
+	v, ok := c.Get(validatedKey)
+	if !ok {
+		return nil
+	}
+	obj, ok := v.(*T)
+	if !ok {
+		return nil
+	}
+	return obj
+}
+
+// bindingProblem returns 422 for validation errors and 400 for requests which could not be decoded.
+func bindingProblem(err error, obj interface{}, tag string) Problem {
+	validationErrs := validator.ValidationErrors{}
+	if !errors.As(err, &validationErrs) {
+		return Problem{
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+	}
+	fieldErrs := []FieldError{}
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fieldPath(reflect.TypeOf(obj), fe.StructNamespace(), tag),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+	return Problem{
+		Status: http.StatusUnprocessableEntity,
+		Detail: "request validation failed",
+		Errors: fieldErrs,
+	}
+}
+
+func tagForBinding(name string) string {
+	switch name {
+	case "json", "xml", "yaml", "toml", "uri":
+		return name
+	default:
+		return "form"
+	}
+}
+
+// fieldPath converts a struct namespace such as User.Items[0].Name to the names used in the
+// request, for example items[0].name.
+func fieldPath(t reflect.Type, namespace string, tag string) string {
+	segments := strings.Split(namespace, ".")
+	// The first segment is the name of the top level struct.
+	segments = segments[1:]
+	names := []string{}
+	for _, segment := range segments {
+		name, index, _ := strings.Cut(segment, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		t = indirectType(t)
+		if t.Kind() != reflect.Struct {
+			names = append(names, segment)
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			names = append(names, segment)
+			continue
+		}
+		names = append(names, fieldName(field, tag)+index)
+		t = field.Type
+		if index != "" {
+			t = indirectType(t)
+			if t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+				t = t.Elem()
+			}
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// fieldName returns the name of the field in the given tag, falling back to the uri tag for
+// fields bound from the path.
+func fieldName(field reflect.StructField, tag string) string {
+	for _, t := range []string{tag, "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(t), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if isLengthKind(fe.Kind()) {
+			return fmt.Sprintf("must contain at least %s items or characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		if isLengthKind(fe.Kind()) {
+			return fmt.Sprintf("must contain at most %s items or characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have a length of %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	default:
+		if fe.Param() != "" {
+			return fmt.Sprintf("failed the %s=%s rule", fe.Tag(), strconv.Quote(fe.Param()))
+		}
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+func isLengthKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
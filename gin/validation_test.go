@@ -0,0 +1,137 @@
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type testRequest struct {
+	ID    string     `uri:"id" json:"-" binding:"required,uuid"`
+	Email string     `json:"email" binding:"required,email"`
+	Role  string     `json:"role" binding:"oneof=admin user"`
+	Items []testItem `json:"items" binding:"min=1,dive"`
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	require.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+	problem := Problem{}
+	err := json.Unmarshal(rec.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	return problem
+}
+
+func TestValidate(t *testing.T) {
+	engine := gin.New()
+	engine.POST("/users/:id", Validate[testRequest](), func(c *gin.Context) {
+		req := Validated[testRequest](c)
+		c.String(http.StatusOK, req.ID+" "+req.Email)
+	})
+
+	body := `{"email":"foo@example.com","role":"admin","items":[{"name":"foo"}]}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/0b4c3f8e-52b0-4a56-9a44-2f1f33ab2e0a", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "0b4c3f8e-52b0-4a56-9a44-2f1f33ab2e0a foo@example.com", rec.Body.String())
+
+	body = `{"email":"foo","role":"owner","items":[{"name":""}]}`
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/users/0b4c3f8e-52b0-4a56-9a44-2f1f33ab2e0a", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, Problem{
+		Title:    "Unprocessable Entity",
+		Status:   http.StatusUnprocessableEntity,
+		Detail:   "request validation failed",
+		Instance: "/users/0b4c3f8e-52b0-4a56-9a44-2f1f33ab2e0a",
+		Errors: []FieldError{
+			{Field: "email", Rule: "email", Message: "must be a valid email address"},
+			{Field: "role", Rule: "oneof", Param: "admin user", Message: "must be one of admin, user"},
+			{Field: "items[0].name", Rule: "required", Message: "is required"},
+		},
+	}, problem)
+}
+
+func TestValidateURI(t *testing.T) {
+	engine := gin.New()
+	engine.POST("/users/:id", Validate[testRequest](), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	body := `{"email":"foo@example.com","role":"admin","items":[{"name":"foo"}]}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/foo", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, []FieldError{{Field: "id", Rule: "uuid", Message: "must be a valid UUID"}}, problem.Errors)
+}
+
+func TestBindMalformed(t *testing.T) {
+	engine := gin.New()
+	engine.POST("/", func(c *gin.Context) {
+		req := testRequest{}
+		if !Bind(c, &req) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, "Bad Request", problem.Title)
+	require.Equal(t, "unexpected EOF", problem.Detail)
+	require.Empty(t, problem.Errors)
+}
+
+func TestBindWithQuery(t *testing.T) {
+	type query struct {
+		Limit int `form:"limit" binding:"max=100"`
+	}
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		q := query{}
+		if !BindWith(c, &q, binding.Query) {
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?limit=1000", nil))
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, []FieldError{{Field: "limit", Rule: "max", Param: "100", Message: "must be at most 100"}}, problem.Errors)
+}
+
+func TestAbortWithProblem(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		AbortWithProblem(c, Problem{Status: http.StatusConflict, Detail: "already exists"})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.JSONEq(t, `{"title":"Conflict","status":409,"detail":"already exists","instance":"/"}`, rec.Body.String())
+}
@@ -0,0 +1,301 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// ErrWebSocketClosed is returned when sending on a closed WebSocket connection.
+var ErrWebSocketClosed = errors.New("websocket connection closed")
+
+type WebSocketConfig struct {
+	// Origins allowed to connect in addition to the origin of the request host. An origin of "*"
+	// allows all origins.
+	AllowedOrigins []string
+	// Context keys checked in order for the authenticated user.
+	UserKeys []string
+	// Size of the send and receive channel buffers.
+	ChannelSize int
+	// Maximum size of a received message in bytes, zero disables the limit.
+	MaxMessageSize int64
+	// Time allowed to write a message to the peer.
+	WriteTimeout time.Duration
+	// Time allowed between pongs from the peer, pings are sent at nine tenths of the timeout.
+	// Pings are disabled if zero.
+	PongTimeout time.Duration
+}
+
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		AllowedOrigins: nil,
+		UserKeys:       []string{APIKeyOwnerKey, BasicAuthUserKey},
+		ChannelSize:    16,
+		MaxMessageSize: 1 << 20,
+		WriteTimeout:   10 * time.Second,
+		PongTimeout:    60 * time.Second,
+	}
+}
+
+// WebSocketMessage is a message received from or sent to the peer. Type is one of
+// websocket.TextMessage or websocket.BinaryMessage.
+type WebSocketMessage struct {
+	Type int
+	Data []byte
+}
+
+// WebSocketConn is an upgraded connection, reading and writing is done through channels.
+type WebSocketConn struct {
+	// User which opened the connection, empty if the request is not authenticated.
+	User string
+
+	ctx     context.Context
+	send    chan WebSocketMessage
+	receive chan WebSocketMessage
+}
+
+// Receive returns messages received from the peer, the channel is closed when the connection is
+// closed.
+func (c *WebSocketConn) Receive() <-chan WebSocketMessage {
+	return c.receive
+}
+
+// Send queues a message to be written to the peer, blocking while the send buffer is full.
+func (c *WebSocketConn) Send(ctx context.Context, msg WebSocketMessage) error {
+	select {
+	case c.send <- msg:
+		return nil
+	case <-c.ctx.Done():
+		return ErrWebSocketClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WebSocketHandler handles an upgraded connection, the connection is closed when it returns.
+// The context is cancelled when the peer closes the connection or the server is stopped.
+type WebSocketHandler func(ctx context.Context, conn *WebSocketConn) error
+
+// WebSocketServer upgrades requests to WebSocket connections and keeps track of them so that
+// they can be closed gracefully on shutdown.
+type WebSocketServer struct {
+	cfg      WebSocketConfig
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	cancels map[*WebSocketConn]context.CancelFunc
+	stopped bool
+}
+
+func NewWebSocketServer(cfg WebSocketConfig) *WebSocketServer {
+	s := &WebSocketServer{
+		cfg:     cfg,
+		cancels: map[*WebSocketConn]context.CancelFunc{},
+	}
+	s.upgrader = websocket.Upgrader{
+		CheckOrigin: s.checkOrigin,
+	}
+	return s
+}
+
+// Handler upgrades the request and calls handler with the connection. Authentication middleware
+// should run before the handler so that the user is available on the connection.
+func (s *WebSocketServer) Handler(handler WebSocketHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		defer s.wg.Done()
+
+		ws, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already written an error response.
+			c.Abort()
+			return
+		}
+		defer ws.Close()
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		conn := &WebSocketConn{
+			ctx:     ctx,
+			send:    make(chan WebSocketMessage, s.cfg.ChannelSize),
+			receive: make(chan WebSocketMessage, s.cfg.ChannelSize),
+		}
+		for _, key := range s.cfg.UserKeys {
+			if user := c.GetString(key); user != "" {
+				conn.User = user
+				break
+			}
+		}
+		s.mu.Lock()
+		s.cancels[conn] = cancel
+		if s.stopped {
+			cancel()
+		}
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			delete(s.cancels, conn)
+			s.mu.Unlock()
+		}()
+
+		go s.read(ctx, cancel, ws, conn)
+		flush := make(chan struct{})
+		writeDone := make(chan struct{})
+		go func() {
+			defer close(writeDone)
+			s.write(ctx, flush, ws, conn)
+		}()
+
+		err = handler(ctx, conn)
+		closeCode := websocket.CloseNormalClosure
+		if err != nil {
+			c.Error(err)
+			closeCode = websocket.CloseInternalServerErr
+		}
+		if s.isStopped() {
+			closeCode = websocket.CloseGoingAway
+		}
+		// Messages queued before the handler returned are written before closing.
+		close(flush)
+		<-writeDone
+		cancel()
+		deadline := time.Now().Add(s.cfg.WriteTimeout)
+		// The peer may already have gone away, in which case the close message is not delivered.
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, ""), deadline)
+	}
+}
+
+// Stop stops accepting new connections, cancels the context of all open connections and waits
+// for their handlers to return.
+func (s *WebSocketServer) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *WebSocketServer) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+func (s *WebSocketServer) read(ctx context.Context, cancel context.CancelFunc, ws *websocket.Conn, conn *WebSocketConn) {
+	defer close(conn.receive)
+	defer cancel()
+
+	if s.cfg.MaxMessageSize > 0 {
+		ws.SetReadLimit(s.cfg.MaxMessageSize)
+	}
+	if s.cfg.PongTimeout > 0 {
+		ws.SetReadDeadline(time.Now().Add(s.cfg.PongTimeout))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(s.cfg.PongTimeout))
+		})
+	}
+	for {
+		msgType, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case conn.receive <- WebSocketMessage{Type: msgType, Data: data}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *WebSocketServer) write(ctx context.Context, flush <-chan struct{}, ws *websocket.Conn, conn *WebSocketConn) {
+	var ping <-chan time.Time
+	if s.cfg.PongTimeout > 0 {
+		ticker := time.NewTicker(s.cfg.PongTimeout * 9 / 10)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	for {
+		select {
+		case msg := <-conn.send:
+			err := s.writeMessage(ws, msg)
+			if err != nil {
+				return
+			}
+		case <-ping:
+			err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.cfg.WriteTimeout))
+			if err != nil {
+				return
+			}
+		case <-flush:
+			for {
+				select {
+				case msg := <-conn.send:
+					err := s.writeMessage(ws, msg)
+					if err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *WebSocketServer) writeMessage(ws *websocket.Conn, msg WebSocketMessage) error {
+	err := ws.SetWriteDeadline(time.Now().Add(s.cfg.WriteTimeout))
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(msg.Type, msg.Data)
+}
+
+func (s *WebSocketServer) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebSocketTestServer(t *testing.T, s *WebSocketServer, handler WebSocketHandler) string {
+	t.Helper()
+	engine := gin.New()
+	engine.GET("/ws", func(c *gin.Context) {
+		c.Set(APIKeyOwnerKey, "foo")
+	}, s.Handler(handler))
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	s := NewWebSocketServer(DefaultWebSocketConfig())
+	users := make(chan string, 1)
+	url := newWebSocketTestServer(t, s, func(ctx context.Context, conn *WebSocketConn) error {
+		users <- conn.User
+		for msg := range conn.Receive() {
+			err := conn.Send(ctx, WebSocketMessage{Type: msg.Type, Data: append([]byte("echo: "), msg.Data...)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	require.Equal(t, "foo", <-users)
+
+	err = ws.WriteMessage(websocket.TextMessage, []byte("hello"))
+	require.NoError(t, err)
+	msgType, data, err := ws.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, websocket.TextMessage, msgType)
+	require.Equal(t, "echo: hello", string(data))
+}
+
+func TestWebSocketHandlerReturn(t *testing.T) {
+	s := NewWebSocketServer(DefaultWebSocketConfig())
+	url := newWebSocketTestServer(t, s, func(ctx context.Context, conn *WebSocketConn) error {
+		return conn.Send(ctx, WebSocketMessage{Type: websocket.TextMessage, Data: []byte("bye")})
+	})
+
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	_, data, err := ws.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "bye", string(data))
+	_, _, err = ws.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+}
+
+func TestWebSocketStop(t *testing.T) {
+	s := NewWebSocketServer(DefaultWebSocketConfig())
+	started := make(chan struct{})
+	url := newWebSocketTestServer(t, s, func(ctx context.Context, conn *WebSocketConn) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = s.Stop(ctx)
+	require.NoError(t, err)
+	_, _, err = ws.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	cfg := DefaultWebSocketConfig()
+	cfg.AllowedOrigins = []string{"https://example.com"}
+	s := NewWebSocketServer(cfg)
+	url := newWebSocketTestServer(t, s, func(ctx context.Context, conn *WebSocketConn) error {
+		return nil
+	})
+
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://example.com"}})
+	require.NoError(t, err)
+	ws.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://evil.com"}})
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
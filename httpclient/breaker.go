@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets all requests through.
+	StateClosed State = iota
+	// StateOpen rejects all requests until the open timeout has passed.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through to test the downstream.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type BreakerConfig struct {
+	// Number of consecutive failed attempts which opens the circuit, zero disables the circuit breaker.
+	FailureThreshold int
+	// Duration the circuit stays open before probe requests are let through.
+	OpenTimeout time.Duration
+	// Number of successful probe requests required to close the circuit, this is also the maximum
+	// number of concurrent probe requests.
+	HalfOpenProbes int
+	// Keep a separate circuit per request host instead of one for the whole target.
+	PerHost bool
+	// Called when a circuit changes state, name is the target or the host when PerHost is set.
+	// The callback is called synchronously and must not block.
+	OnStateChange func(name string, from, to State)
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 0,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   1,
+		PerHost:          false,
+		OnStateChange:    nil,
+	}
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored releases a probe without affecting the state, used when the caller cancels.
+	outcomeIgnored
+)
+
+type breaker struct {
+	name     string
+	cfg      BreakerConfig
+	now      func() time.Time
+	onChange func(name string, from, to State)
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+	// generation is incremented on every state change so that results of requests allowed in a
+	// previous state are ignored.
+	generation uint64
+}
+
+// allow returns the generation the request was allowed in, or ErrCircuitOpen.
+func (b *breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return 0, ErrCircuitOpen
+		}
+		b.setState(StateHalfOpen)
+	case StateHalfOpen:
+	default:
+		return b.generation, nil
+	}
+	if b.probes >= b.probeLimit() {
+		return 0, ErrCircuitOpen
+	}
+	b.probes++
+	return b.generation, nil
+}
+
+func (b *breaker) done(generation uint64, o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		switch o {
+		case outcomeSuccess:
+			b.failures = 0
+		case outcomeFailure:
+			b.failures++
+			if b.failures >= b.cfg.FailureThreshold {
+				b.setState(StateOpen)
+			}
+		}
+	case StateHalfOpen:
+		b.probes--
+		switch o {
+		case outcomeSuccess:
+			b.successes++
+			if b.successes >= b.probeLimit() {
+				b.setState(StateClosed)
+			}
+		case outcomeFailure:
+			b.setState(StateOpen)
+		}
+	}
+}
+
+func (b *breaker) probeLimit() int {
+	if b.cfg.HalfOpenProbes < 1 {
+		return 1
+	}
+	return b.cfg.HalfOpenProbes
+}
+
+// setState has to be called with the lock held.
+func (b *breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	if b.onChange != nil {
+		b.onChange(b.name, from, state)
+	}
+}
+
+type breakers struct {
+	cfg      BreakerConfig
+	onChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func (bs *breakers) get(name string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.breakers[name]
+	if !ok {
+		b = &breaker{
+			name:     name,
+			cfg:      bs.cfg,
+			now:      time.Now,
+			onChange: bs.onChange,
+		}
+		bs.breakers[name] = b
+	}
+	return b
+}
@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerStates(t *testing.T) {
+	now := time.Now()
+	transitions := []State{}
+	b := &breaker{
+		name: "test",
+		cfg: BreakerConfig{
+			FailureThreshold: 2,
+			OpenTimeout:      time.Minute,
+			HalfOpenProbes:   2,
+		},
+		now: func() time.Time {
+			return now
+		},
+		onChange: func(_ string, _, to State) {
+			transitions = append(transitions, to)
+		},
+	}
+
+	gen, err := b.allow()
+	require.NoError(t, err)
+	b.done(gen, outcomeFailure)
+	gen, err = b.allow()
+	require.NoError(t, err)
+	b.done(gen, outcomeSuccess)
+	gen, err = b.allow()
+	require.NoError(t, err)
+	b.done(gen, outcomeFailure)
+	require.Equal(t, StateClosed, b.state)
+
+	stale, err := b.allow()
+	require.NoError(t, err)
+	gen, err = b.allow()
+	require.NoError(t, err)
+	b.done(gen, outcomeFailure)
+	require.Equal(t, StateOpen, b.state)
+	b.done(stale, outcomeSuccess)
+	require.Equal(t, StateOpen, b.state)
+	_, err = b.allow()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	first, err := b.allow()
+	require.NoError(t, err)
+	require.Equal(t, StateHalfOpen, b.state)
+	second, err := b.allow()
+	require.NoError(t, err)
+	_, err = b.allow()
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	b.done(first, outcomeSuccess)
+	b.done(second, outcomeFailure)
+	require.Equal(t, StateOpen, b.state)
+
+	now = now.Add(time.Minute)
+	first, err = b.allow()
+	require.NoError(t, err)
+	b.done(first, outcomeIgnored)
+	first, err = b.allow()
+	require.NoError(t, err)
+	second, err = b.allow()
+	require.NoError(t, err)
+	b.done(first, outcomeSuccess)
+	b.done(second, outcomeSuccess)
+	require.Equal(t, StateClosed, b.state)
+
+	require.Equal(t, []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}, transitions)
+}
+
+func TestTransportCircuitBreaker(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	cfg := testConfig()
+	cfg.Registerer = reg
+	cfg.Breaker.FailureThreshold = 3
+	cfg.Breaker.PerHost = true
+	client := newTestClient(t, cfg)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	_, err = client.Get(srv.URL)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	m := newTestMetrics(t, reg)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.rejected))
+	host := srv.Listener.Addr().String()
+	require.Equal(t, float64(StateOpen), testutil.ToFloat64(m.breakerState.WithLabelValues("test", host)))
+}
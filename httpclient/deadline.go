@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeadlineHeader carries the time remaining until the deadline of the request in milliseconds.
+const DeadlineHeader = "X-Request-Timeout"
+
+var timeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// ParseTimeout parses a timeout header value, either an integer number of milliseconds or the
+// grpc-timeout format of an integer followed by one of the units H, M, S, m, u or n.
+func ParseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("timeout cannot be empty")
+	}
+	unit := time.Millisecond
+	if u, ok := timeoutUnits[value[len(value)-1]]; ok {
+		unit = u
+		value = value[:len(value)-1]
+	}
+	// The grpc-timeout format allows at most 8 digits, which also prevents overflow.
+	if len(value) == 0 || len(value) > 8 {
+		return 0, errors.New("invalid timeout")
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid timeout")
+	}
+	return time.Duration(n) * unit, nil
+}
+
+type deadlineTransport struct {
+	next http.RoundTripper
+}
+
+// NewDeadlineTransport returns a round tripper which sets DeadlineHeader to the time remaining
+// until the deadline of the request context, so that the server can stop working on the request
+// once the client has given up. The header is not replaced if it is already set.
+func NewDeadlineTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &deadlineTransport{
+		next: next,
+	}
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline, ok := req.Context().Deadline()
+	if !ok || req.Header.Get(DeadlineHeader) != "" {
+		return t.next.RoundTrip(req)
+	}
+	// Round up so that a remaining fraction of a millisecond is not sent as no time remaining.
+	remaining := (time.Until(deadline) + time.Millisecond - 1) / time.Millisecond
+	if remaining <= 0 {
+		return nil, context.DeadlineExceeded
+	}
+	// Round trippers should not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set(DeadlineHeader, strconv.FormatInt(int64(remaining), 10))
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected time.Duration
+		err      bool
+	}{
+		{value: "1500", expected: 1500 * time.Millisecond},
+		{value: "2S", expected: 2 * time.Second},
+		{value: "100m", expected: 100 * time.Millisecond},
+		{value: "1H", expected: time.Hour},
+		{value: "", err: true},
+		{value: "S", err: true},
+		{value: "-1", err: true},
+		{value: "1.5S", err: true},
+		{value: "123456789", err: true},
+	} {
+		d, err := ParseTimeout(tt.value)
+		if tt.err {
+			require.Error(t, err, tt.value)
+			continue
+		}
+		require.NoError(t, err, tt.value)
+		require.Equal(t, tt.expected, d)
+	}
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(DeadlineHeader)
+	}))
+	defer srv.Close()
+
+	get := func(ctx context.Context, cfg Config) string {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := newTestClient(t, cfg).Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return <-received
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.Empty(t, get(ctx, testConfig()))
+
+	cfg := testConfig()
+	cfg.PropagateDeadline = true
+	ms, err := strconv.Atoi(get(ctx, cfg))
+	require.NoError(t, err)
+	require.LessOrEqual(t, ms, 5000)
+	require.Greater(t, ms, 4000)
+
+	// The attempt timeout is used when it is shorter than the remaining time.
+	cfg.AttemptTimeout = time.Second
+	ms, err = strconv.Atoi(get(ctx, cfg))
+	require.NoError(t, err)
+	require.LessOrEqual(t, ms, 1000)
+
+	cfg.AttemptTimeout = 0
+	require.Empty(t, get(context.Background(), cfg))
+}
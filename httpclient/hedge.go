@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// errHedgeLost is the cancellation cause of the request which lost a hedge.
+var errHedgeLost = errors.New("hedged request lost")
+
+type HedgeConfig struct {
+	// Delay after which a second request is sent if the first has not responded, zero disables
+	// hedging. Only idempotent requests are hedged.
+	Delay time.Duration
+}
+
+func DefaultHedgeConfig() HedgeConfig {
+	return HedgeConfig{
+		Delay: 0,
+	}
+}
+
+type hedgeResult struct {
+	resp *http.Response
+	err  error
+	// index of the request, the hedged request has index 1.
+	index int
+}
+
+// hedgedAttempt sends a second request when the first has not responded within the hedge delay
+// and returns the first result which should not be retried, cancelling the other request. The
+// last result is returned if both should be retried.
+func (t *transport) hedgedAttempt(req *http.Request, attempt int) (*http.Response, error) {
+	if t.cfg.Hedge.Delay <= 0 || !isIdempotent(req) {
+		return t.attempt(req, attempt)
+	}
+
+	results := make(chan hedgeResult, 2)
+	cancels := []context.CancelCauseFunc{}
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancelCause(req.Context())
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := t.attempt(r.WithContext(ctx), attempt)
+			results <- hedgeResult{resp: resp, err: err, index: index}
+		}()
+	}
+
+	launch(req)
+	pending := 1
+	timer := time.NewTimer(t.cfg.Hedge.Delay)
+	defer timer.Stop()
+	var res hedgeResult
+	for {
+		select {
+		case <-timer.C:
+			r, err := hedgeRequest(req)
+			if err != nil {
+				t.cfg.Logger.Error(err, "could not create hedged request", "target", t.cfg.Target, "method", req.Method, "url", req.URL.Redacted())
+				continue
+			}
+			t.cfg.Logger.V(1).Info("sending hedged request", "target", t.cfg.Target, "method", req.Method, "url", req.URL.Redacted(), "attempt", attempt)
+			if t.metrics != nil {
+				t.metrics.hedges.WithLabelValues(t.cfg.Target, req.Method).Inc()
+			}
+			launch(r)
+			pending++
+			continue
+		case res = <-results:
+			pending--
+		}
+		if pending == 0 || !shouldRetry(req.Context(), res.resp, res.err) {
+			break
+		}
+		// Wait for the other request as it may still succeed.
+		if res.resp != nil {
+			res.resp.Body.Close()
+		}
+		cancels[res.index](errHedgeLost)
+	}
+
+	for i, cancel := range cancels {
+		if i != res.index {
+			cancel(errHedgeLost)
+		}
+	}
+	if pending > 0 {
+		go func() {
+			for i := 0; i < pending; i++ {
+				if r := <-results; r.resp != nil {
+					r.resp.Body.Close()
+				}
+			}
+		}()
+	}
+
+	winner := cancels[res.index]
+	if res.err != nil {
+		winner(nil)
+		return nil, res.err
+	}
+	// The context of the winner has to outlive the response body.
+	res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: func() { winner(nil) }}
+	if res.index > 0 && res.resp.StatusCode < 500 && t.metrics != nil {
+		t.metrics.hedgeWins.WithLabelValues(t.cfg.Target, req.Method).Inc()
+	}
+	return res.resp, nil
+}
+
+// hedgeRequest returns a copy of the request with a new body.
+func hedgeRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil {
+		return r, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
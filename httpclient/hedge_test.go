@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgeWins(t *testing.T) {
+	var calls int32
+	cancelled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			close(cancelled)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	cfg := testConfig()
+	cfg.Registerer = reg
+	cfg.Hedge.Delay = 10 * time.Millisecond
+	client := newTestClient(t, cfg)
+	req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "hello world", string(body))
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing request was not cancelled")
+	}
+	m := newTestMetrics(t, reg)
+	require.Equal(t, float64(1), testutil.ToFloat64(prometheus.Collector(m.hedges)))
+	require.Equal(t, float64(1), testutil.ToFloat64(prometheus.Collector(m.hedgeWins)))
+}
+
+func TestHedgeNotSent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	cfg := testConfig()
+	cfg.Registerer = reg
+	cfg.Hedge.Delay = time.Second
+	client := newTestClient(t, cfg)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Non-idempotent requests are never hedged.
+	cfg.Hedge.Delay = time.Millisecond
+	client = newTestClient(t, cfg)
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("foo"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.Equal(t, 0, testutil.CollectAndCount(newTestMetrics(t, reg).hedges))
+}
+
+func TestHedgeServerError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.Retry.MaxAttempts = 1
+	cfg.Hedge.Delay = 10 * time.Millisecond
+	client := newTestClient(t, cfg)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,333 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xenitab/pkg/retry"
+)
+
+type Config struct {
+	// Logical name of the target service, used as label in logs and metrics.
+	Target string
+	// Logger instance to output request logs.
+	Logger logr.Logger
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+	// Retry policy for idempotent requests, non-idempotent requests are only attempted once.
+	Retry retry.Policy
+	// Timeout for each attempt, zero disables the timeout.
+	AttemptTimeout time.Duration
+	// Headers whose values are redacted in logs.
+	RedactHeaders []string
+	// Underlying transport used to send requests.
+	Transport http.RoundTripper
+	// TLS config set on the underlying transport when it is an *http.Transport, for example the
+	// client config of a tlsconfig.Reloader. The transport is cloned before it is modified.
+	TLSConfig *tls.Config
+	// Circuit breaker configuration, disabled by default.
+	Breaker BreakerConfig
+	// Hedging configuration for idempotent requests, disabled by default.
+	Hedge HedgeConfig
+	// Set the headers added with ContextWithPropagatedHeaders on outgoing requests. Only enable it
+	// for clients calling internal services, as the headers are sent to every host.
+	PropagateHeaders bool
+	// Set DeadlineHeader on outgoing requests from the deadline of the request context, taking
+	// the attempt timeout into account. Only enable it for clients calling internal services, as
+	// the header reveals the timeouts of the service to every host.
+	PropagateDeadline bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Target:     "",
+		Logger:     logr.Discard(),
+		Registerer: nil,
+		Retry: retry.Policy{
+			MaxAttempts:     3,
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     2 * time.Second,
+			Multiplier:      2,
+			Jitter:          0.5,
+		},
+		AttemptTimeout:    0,
+		RedactHeaders:     []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"},
+		Transport:         http.DefaultTransport,
+		TLSConfig:         nil,
+		Breaker:           DefaultBreakerConfig(),
+		Hedge:             DefaultHedgeConfig(),
+		PropagateHeaders:  false,
+		PropagateDeadline: false,
+	}
+}
+
+func NewClient(cfg Config) (*http.Client, error) {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: t,
+	}, nil
+}
+
+type transport struct {
+	cfg      Config
+	base     http.RoundTripper
+	redact   map[string]bool
+	metrics  *metrics
+	breakers *breakers
+}
+
+// NewTransport returns a round tripper which retries failed idempotent requests with exponential
+// backoff while logging and recording metrics for each attempt. Attempts are rejected with
+// ErrCircuitOpen while the circuit breaker is open. Propagated headers are set before the first
+// attempt and the deadline header on each attempt when enabled. Idempotent attempts are hedged
+// with a second request when hedging is enabled. An error is returned if the metrics cannot be
+// registered.
+func NewTransport(cfg Config) (http.RoundTripper, error) {
+	base := cfg.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if ht, ok := base.(*http.Transport); ok && cfg.TLSConfig != nil {
+		ht = ht.Clone()
+		ht.TLSClientConfig = cfg.TLSConfig
+		base = ht
+	}
+	if cfg.PropagateDeadline {
+		base = NewDeadlineTransport(base)
+	}
+	redact := map[string]bool{}
+	for _, h := range cfg.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+	t := &transport{
+		cfg:    cfg,
+		base:   base,
+		redact: redact,
+	}
+	if cfg.Registerer != nil {
+		m, err := newMetrics(cfg.Registerer)
+		if err != nil {
+			return nil, err
+		}
+		t.metrics = m
+	}
+	if cfg.Breaker.FailureThreshold > 0 {
+		t.breakers = &breakers{
+			cfg:      cfg.Breaker,
+			onChange: t.onStateChange,
+			breakers: map[string]*breaker{},
+		}
+	}
+	if cfg.PropagateHeaders {
+		return NewPropagationTransport(t), nil
+	}
+	return t, nil
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := t.cfg.Retry
+	if !isIdempotent(req) {
+		policy.MaxAttempts = 1
+	}
+	onRetry := policy.OnRetry
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		if t.metrics != nil {
+			t.metrics.retries.WithLabelValues(t.cfg.Target, req.Method).Inc()
+		}
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+	}
+
+	attempt := 0
+	var last *http.Response
+	resp, err := retry.DoValue(req.Context(), policy, func(ctx context.Context) (*http.Response, error) {
+		attempt++
+		if last != nil {
+			io.Copy(io.Discard, last.Body)
+			last.Body.Close()
+		}
+		r := req
+		if attempt > 1 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, retry.Permanent(err)
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		resp, err := t.hedgedAttempt(r, attempt)
+		if !shouldRetry(ctx, resp, err) {
+			return resp, retry.Permanent(err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		last = resp
+		return resp, &statusError{resp: resp}
+	})
+	if err != nil && req.Context().Err() != nil {
+		if last != nil {
+			last.Body.Close()
+		}
+		return nil, req.Context().Err()
+	}
+	// The response of the last attempt is returned when retries are exhausted for a status code.
+	statusErr := &statusError{}
+	if errors.As(err, &statusErr) {
+		return statusErr.resp, nil
+	}
+	return resp, err
+}
+
+func (t *transport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	kvs := []interface{}{"target", t.cfg.Target, "method", req.Method, "url", req.URL.Redacted(), "attempt", attempt}
+	if t.breakers == nil {
+		return t.send(req, kvs)
+	}
+
+	b := t.breakers.get(t.breakerName(req))
+	generation, err := b.allow()
+	if err != nil {
+		if t.metrics != nil {
+			t.metrics.rejected.WithLabelValues(t.cfg.Target).Inc()
+		}
+		t.cfg.Logger.V(1).Info("request rejected by circuit breaker", kvs...)
+		return nil, err
+	}
+	resp, err := t.send(req, kvs)
+	switch {
+	case req.Context().Err() != nil:
+		b.done(generation, outcomeIgnored)
+	case err != nil || resp.StatusCode >= 500:
+		b.done(generation, outcomeFailure)
+	default:
+		b.done(generation, outcomeSuccess)
+	}
+	return resp, err
+}
+
+func (t *transport) send(req *http.Request, kvs []interface{}) (*http.Response, error) {
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+	if t.cfg.AttemptTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.cfg.AttemptTimeout)
+	}
+	t.cfg.Logger.V(2).Info("sending request", append(kvs, "headers", t.redactHeaders(req.Header))...)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	latency := time.Since(start)
+	if err != nil {
+		cancel()
+		t.observe(req.Method, "error", latency)
+		if errors.Is(context.Cause(req.Context()), errHedgeLost) {
+			t.cfg.Logger.V(1).Info("hedged request cancelled", append(kvs, "latency", latency)...)
+			return nil, err
+		}
+		t.cfg.Logger.Error(err, "request failed", append(kvs, "latency", latency)...)
+		return nil, err
+	}
+	// The attempt context has to outlive the response body.
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	t.observe(req.Method, strconv.Itoa(resp.StatusCode), latency)
+	kvs = append(kvs, "status", resp.StatusCode, "latency", latency)
+	if resp.StatusCode >= 500 {
+		t.cfg.Logger.Error(errors.New(resp.Status), "request failed", kvs...)
+		return resp, nil
+	}
+	t.cfg.Logger.V(1).Info("received response", kvs...)
+	t.cfg.Logger.V(2).Info("received response headers", append(kvs, "headers", t.redactHeaders(resp.Header))...)
+	return resp, nil
+}
+
+func (t *transport) breakerName(req *http.Request) string {
+	if t.cfg.Breaker.PerHost {
+		return req.URL.Host
+	}
+	return t.cfg.Target
+}
+
+func (t *transport) onStateChange(name string, from, to State) {
+	t.cfg.Logger.Info("circuit breaker changed state", "target", t.cfg.Target, "name", name, "from", from.String(), "to", to.String())
+	if t.metrics != nil {
+		t.metrics.breakerState.WithLabelValues(t.cfg.Target, name).Set(float64(to))
+	}
+	if t.cfg.Breaker.OnStateChange != nil {
+		t.cfg.Breaker.OnStateChange(name, from, to)
+	}
+}
+
+func (t *transport) observe(method, status string, latency time.Duration) {
+	if t.metrics == nil {
+		return
+	}
+	t.metrics.duration.WithLabelValues(t.cfg.Target, method, status).Observe(latency.Seconds())
+}
+
+func (t *transport) redactHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for k, v := range header {
+		if t.redact[k] {
+			headers[k] = "[REDACTED]"
+			continue
+		}
+		headers[k] = strings.Join(v, ",")
+	}
+	return headers
+}
+
+func isIdempotent(req *http.Request) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// statusError signals that a response has a status code which should be retried.
+type statusError struct {
+	resp *http.Response
+}
+
+func (e *statusError) Error() string {
+	return e.resp.Status
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Target = "test"
+	cfg.Retry.InitialInterval = time.Millisecond
+	cfg.Retry.MaxInterval = 10 * time.Millisecond
+	return cfg
+}
+
+func newTestClient(t *testing.T, cfg Config) *http.Client {
+	t.Helper()
+	client, err := NewClient(cfg)
+	require.NoError(t, err)
+	return client
+}
+
+func newTestMetrics(t *testing.T, reg prometheus.Registerer) *metrics {
+	t.Helper()
+	m, err := newMetrics(reg)
+	require.NoError(t, err)
+	return m
+}
+
+func TestRetryServerError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	cfg := testConfig()
+	cfg.Registerer = reg
+	client := newTestClient(t, cfg)
+	req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("hello world"))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "hello world", string(body))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	require.Equal(t, float64(2), testutil.ToFloat64(prometheus.Collector(newTestMetrics(t, reg).retries)))
+}
+
+func TestNoRetryNonIdempotent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(t, testConfig())
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("foo"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.AttemptTimeout = 20 * time.Millisecond
+	client := newTestClient(t, cfg)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestLogRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	cfg := testConfig()
+	cfg.Logger = buflogr.NewWithBuffer(&buf).V(2)
+	client := newTestClient(t, cfg)
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Contains(t, buf.String(), "Authorization:[REDACTED]")
+	require.NotContains(t, buf.String(), "secret")
+}
+
+func TestTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	_, err := newTestClient(t, cfg).Get(srv.URL)
+	require.Error(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	cfg.TLSConfig = &tls.Config{RootCAs: pool}
+	resp, err := newTestClient(t, cfg).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	if tlsCfg := http.DefaultTransport.(*http.Transport).TLSClientConfig; tlsCfg != nil {
+		require.Nil(t, tlsCfg.RootCAs)
+	}
+}
+
+func TestNewTransportRegistrationError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "httpclient_retries_total", Help: "Other metric."}))
+	cfg := testConfig()
+	cfg.Registerer = reg
+	_, err := NewTransport(cfg)
+	require.ErrorContains(t, err, "could not register metrics")
+}
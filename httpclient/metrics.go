@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	duration *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	rejected *prometheus.CounterVec
+	// hedges counts the hedged requests sent and hedgeWins the ones which won.
+	hedges    *prometheus.CounterVec
+	hedgeWins *prometheus.CounterVec
+	// breakerState is the numeric value of the circuit breaker State.
+	breakerState *prometheus.GaugeVec
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	duration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "httpclient_request_duration_seconds",
+		Help:    "Duration of outgoing HTTP request attempts.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"target", "method", "status"}))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "httpclient_retries_total",
+		Help: "Total number of retried outgoing HTTP requests.",
+	}, []string{"target", "method"}))
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "httpclient_circuit_breaker_rejected_total",
+		Help: "Total number of outgoing HTTP requests rejected by an open circuit breaker.",
+	}, []string{"target"}))
+	if err != nil {
+		return nil, err
+	}
+	hedges, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "httpclient_hedged_requests_total",
+		Help: "Total number of hedged outgoing HTTP requests sent.",
+	}, []string{"target", "method"}))
+	if err != nil {
+		return nil, err
+	}
+	hedgeWins, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "httpclient_hedge_wins_total",
+		Help: "Total number of hedged outgoing HTTP requests which responded before the original request.",
+	}, []string{"target", "method"}))
+	if err != nil {
+		return nil, err
+	}
+	breakerState, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "httpclient_circuit_breaker_state",
+		Help: "State of the circuit breaker, 0 is closed, 1 is open and 2 is half-open.",
+	}, []string{"target", "name"}))
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{
+		duration:     duration,
+		retries:      retries,
+		rejected:     rejected,
+		hedges:       hedges,
+		hedgeWins:    hedgeWins,
+		breakerState: breakerState,
+	}, nil
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered. Other registration errors, such as a collector with the same name
+// but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
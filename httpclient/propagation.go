@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+type propagatedHeadersKey struct{}
+
+// ContextWithPropagatedHeaders returns a context with headers to set on outgoing requests, for
+// example the request ID and trace context of the inbound request. Headers already in the
+// context are kept unless replaced.
+func ContextWithPropagatedHeaders(ctx context.Context, header http.Header) context.Context {
+	merged := PropagatedHeaders(ctx)
+	for k, v := range header {
+		merged[http.CanonicalHeaderKey(k)] = append([]string{}, v...)
+	}
+	return context.WithValue(ctx, propagatedHeadersKey{}, merged)
+}
+
+// PropagatedHeaders returns a copy of the headers in the context.
+func PropagatedHeaders(ctx context.Context) http.Header {
+	header, ok := ctx.Value(propagatedHeadersKey{}).(http.Header)
+	if !ok {
+		return http.Header{}
+	}
+	return header.Clone()
+}
+
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+// NewPropagationTransport returns a round tripper which sets the headers in the request context
+// on outgoing requests. Headers already set on the request are not replaced.
+func NewPropagationTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &propagationTransport{
+		next: next,
+	}
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header, ok := req.Context().Value(propagatedHeadersKey{}).(http.Header)
+	if !ok || len(header) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	// Round trippers should not modify the request.
+	req = req.Clone(req.Context())
+	for k, v := range header {
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		req.Header[k] = append([]string{}, v...)
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagateHeaders(t *testing.T) {
+	received := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	ctx := ContextWithPropagatedHeaders(context.Background(), http.Header{
+		"X-Request-Id": []string{"foo"},
+		"Traceparent":  []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	})
+	ctx = ContextWithPropagatedHeaders(ctx, http.Header{"x-tenant-id": []string{"bar"}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "override")
+	cfg := testConfig()
+	cfg.PropagateHeaders = true
+	resp, err := newTestClient(t, cfg).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	header := <-received
+	require.Equal(t, "override", header.Get("X-Request-ID"))
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", header.Get("Traceparent"))
+	require.Equal(t, "bar", header.Get("X-Tenant-ID"))
+	require.Empty(t, req.Header.Get("Traceparent"))
+
+	// Headers are not propagated by default.
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err = newTestClient(t, testConfig()).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Empty(t, (<-received).Get("Traceparent"))
+}
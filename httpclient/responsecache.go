@@ -0,0 +1,351 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	CacheResultHit         = "hit"
+	CacheResultRevalidated = "revalidated"
+	CacheResultStale       = "stale"
+	CacheResultMiss        = "miss"
+)
+
+// cacheableStatus are the status codes which are cacheable by default.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+type CacheConfig struct {
+	// Name of the cache, used as label in metrics.
+	Name string
+	// Maximum number of cached responses, the least recently used response is evicted when
+	// exceeded. Each response is otherwise kept until it can no longer be served or revalidated.
+	// Zero disables the limit.
+	MaxEntries int
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+	// Maximum size in bytes of a cached response body, larger responses are not cached.
+	MaxBodySize int
+	// Duration after a response has expired it is returned if the request fails or the response
+	// is a server error. A stale-if-error directive in the response takes precedence.
+	StaleIfError time.Duration
+}
+
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		Name:         "",
+		MaxEntries:   1000,
+		Registerer:   nil,
+		MaxBodySize:  1 << 20,
+		StaleIfError: 0,
+	}
+}
+
+type cachedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	storedAt   time.Time
+	// vary contains the request header values of the headers listed in the Vary response header.
+	vary http.Header
+}
+
+type cacheTransport struct {
+	next     http.RoundTripper
+	cfg      CacheConfig
+	now      func() time.Time
+	cache    *memoryStore[*cachedResponse]
+	requests *prometheus.CounterVec
+}
+
+// NewCacheTransport returns a round tripper which caches responses to GET requests following
+// Cache-Control, Expires, ETag and Last-Modified. Fresh responses are returned from the cache and
+// expired responses with validators are revalidated with a conditional request. Responses to
+// requests with an Authorization header are only cached when the response allows it with public,
+// s-maxage or must-revalidate. The transport should wrap NewTransport so that cache hits are not
+// retried or recorded as attempts. An error is returned if the metrics cannot be registered.
+func NewCacheTransport(next http.RoundTripper, cfg CacheConfig) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &cacheTransport{
+		next:  next,
+		cfg:   cfg,
+		now:   time.Now,
+		cache: newMemoryStore[*cachedResponse](cfg.MaxEntries),
+	}
+	if cfg.Registerer != nil {
+		requests, err := register(cfg.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpclient_cache_requests_total",
+			Help: "Total number of outgoing HTTP requests handled by the response cache, by result.",
+		}, []string{"cache", "result"}))
+		if err != nil {
+			return nil, err
+		}
+		t.requests = requests
+	}
+	return t, nil
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header)
+	if req.Method != http.MethodGet || reqCC.has("no-store") || req.Header.Get("Range") != "" || isConditional(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	cached, ok := t.cache.get(key)
+	if ok && !cached.matches(req) {
+		ok = false
+	}
+	if !ok {
+		t.observe(CacheResultMiss)
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		return t.store(key, req, resp), nil
+	}
+
+	now := t.now()
+	respCC := parseCacheControl(cached.header)
+	if !reqCC.has("no-cache") && !respCC.has("no-cache") && cached.age(now) < cached.freshness() {
+		t.observe(CacheResultHit)
+		return cached.response(req, now), nil
+	}
+
+	r := req
+	if cached.hasValidators() {
+		r = req.Clone(req.Context())
+		if etag := cached.header.Get("ETag"); etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.header.Get("Last-Modified"); lastModified != "" {
+			r.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+	resp, err := t.next.RoundTrip(r)
+	if (err != nil || resp.StatusCode >= 500) && cached.age(now) < cached.freshness()+t.staleIfError(respCC) && !respCC.has("must-revalidate") {
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		t.observe(CacheResultStale)
+		return cached.response(req, now), nil
+	}
+	if err != nil {
+		t.observe(CacheResultMiss)
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && r != req {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		updated := &cachedResponse{
+			statusCode: cached.statusCode,
+			header:     cached.header.Clone(),
+			body:       cached.body,
+			storedAt:   now,
+			vary:       cached.vary,
+		}
+		for k, v := range resp.Header {
+			updated.header[k] = v
+		}
+		updated.header.Del("Age")
+		t.cache.set(key, updated, updated.ttl(t.staleIfError(parseCacheControl(updated.header))))
+		t.observe(CacheResultRevalidated)
+		return updated.response(req, now), nil
+	}
+	t.observe(CacheResultMiss)
+	return t.store(key, req, resp), nil
+}
+
+// store caches the response if it is cacheable and returns a response with an unread body.
+func (t *cacheTransport) store(key string, req *http.Request, resp *http.Response) *http.Response {
+	respCC := parseCacheControl(resp.Header)
+	if !cacheableStatus[resp.StatusCode] || respCC.has("no-store") || resp.Header.Get("Vary") == "*" {
+		t.cache.delete(key)
+		return resp
+	}
+	if req.Header.Get("Authorization") != "" && !respCC.has("public") && !respCC.has("s-maxage") && !respCC.has("must-revalidate") {
+		t.cache.delete(key)
+		return resp
+	}
+
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, resp.Body, int64(t.cfg.MaxBodySize)+1)
+	if err != nil && err != io.EOF {
+		resp.Body = &readCloser{Reader: io.MultiReader(buf, errReader{err: err}), Closer: resp.Body}
+		return resp
+	}
+	if n > int64(t.cfg.MaxBodySize) {
+		resp.Body = &readCloser{Reader: io.MultiReader(buf, resp.Body), Closer: resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+
+	cached := &cachedResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       buf.Bytes(),
+		storedAt:   t.now(),
+		vary:       http.Header{},
+	}
+	// The stored age is the age of the response when it was received.
+	if age, err := strconv.Atoi(resp.Header.Get("Age")); err == nil && age > 0 {
+		cached.storedAt = cached.storedAt.Add(-time.Duration(age) * time.Second)
+	}
+	cached.header.Del("Age")
+	for _, name := range strings.Split(resp.Header.Get("Vary"), ",") {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name != "" {
+			cached.vary[name] = req.Header.Values(name)
+		}
+	}
+	if cached.freshness() <= 0 && !cached.hasValidators() {
+		t.cache.delete(key)
+		return resp
+	}
+	t.cache.set(key, cached, cached.ttl(t.staleIfError(respCC)))
+	return resp
+}
+
+func (t *cacheTransport) staleIfError(cc cacheControl) time.Duration {
+	if v, ok := cc.seconds("stale-if-error"); ok {
+		return v
+	}
+	return t.cfg.StaleIfError
+}
+
+func (t *cacheTransport) observe(result string) {
+	if t.requests == nil {
+		return
+	}
+	t.requests.WithLabelValues(t.cfg.Name, result).Inc()
+}
+
+// freshness returns the duration the response is fresh for from max-age or Expires.
+func (c *cachedResponse) freshness() time.Duration {
+	cc := parseCacheControl(c.header)
+	if v, ok := cc.seconds("max-age"); ok {
+		return v
+	}
+	expires, err := http.ParseTime(c.header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(c.header.Get("Date"))
+	if err != nil {
+		date = c.storedAt
+	}
+	return expires.Sub(date)
+}
+
+func (c *cachedResponse) age(now time.Time) time.Duration {
+	return now.Sub(c.storedAt)
+}
+
+// ttl returns the duration the response is kept in the cache, zero if it can be revalidated.
+func (c *cachedResponse) ttl(staleIfError time.Duration) time.Duration {
+	if c.hasValidators() {
+		return 0
+	}
+	return c.freshness() + staleIfError
+}
+
+func (c *cachedResponse) hasValidators() bool {
+	return c.header.Get("ETag") != "" || c.header.Get("Last-Modified") != ""
+}
+
+// matches returns true if the request has the same values for the headers the response varies on.
+func (c *cachedResponse) matches(req *http.Request) bool {
+	for name, values := range c.vary {
+		if strings.Join(values, ",") != strings.Join(req.Header.Values(name), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *cachedResponse) response(req *http.Request, now time.Time) *http.Response {
+	header := c.header.Clone()
+	header.Set("Age", strconv.Itoa(int(c.age(now).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(c.statusCode) + " " + http.StatusText(c.statusCode),
+		StatusCode:    c.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// cacheControl contains the directives of a Cache-Control header, directives without a value
+// have an empty value.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	v, err := strconv.Atoi(cc[name])
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return time.Duration(v) * time.Second, true
+}
+
+func isConditional(req *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since"} {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
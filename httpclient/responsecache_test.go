@@ -0,0 +1,151 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCacheTransport(t *testing.T, cfg CacheConfig) http.RoundTripper {
+	t.Helper()
+	transport, err := NewCacheTransport(nil, cfg)
+	require.NoError(t, err)
+	return transport
+}
+
+func cacheGet(t *testing.T, client *http.Client, url string, header http.Header) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestCacheTransportMaxAge(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	cfg := DefaultCacheConfig()
+	cfg.Name = "test"
+	cfg.Registerer = reg
+	transport := newTestCacheTransport(t, cfg).(*cacheTransport)
+	client := &http.Client{Transport: transport}
+
+	_, body := cacheGet(t, client, srv.URL, nil)
+	require.Equal(t, "1", body)
+	_, body = cacheGet(t, client, srv.URL, nil)
+	require.Equal(t, "1", body)
+	_, body = cacheGet(t, client, srv.URL, http.Header{"Accept-Language": []string{"sv"}})
+	require.Equal(t, "2", body)
+	_, body = cacheGet(t, client, srv.URL, http.Header{"Cache-Control": []string{"no-cache"}})
+	require.Equal(t, "3", body)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(transport.requests.WithLabelValues("test", CacheResultHit)))
+	require.Equal(t, float64(3), testutil.ToFloat64(transport.requests.WithLabelValues("test", CacheResultMiss)))
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestCacheTransportRevalidate(t *testing.T) {
+	var calls, notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newTestCacheTransport(t, DefaultCacheConfig())}
+	for i := 0; i < 3; i++ {
+		status, body := cacheGet(t, client, srv.URL, nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "hello world", body)
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	require.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+}
+
+func TestCacheTransportStaleIfError(t *testing.T) {
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60, stale-if-error=60")
+		w.Write([]byte("hello world"))
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	transport := newTestCacheTransport(t, DefaultCacheConfig()).(*cacheTransport)
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	_, body := cacheGet(t, client, srv.URL, nil)
+	require.Equal(t, "hello world", body)
+	fail.Store(true)
+	now = now.Add(90 * time.Second)
+	status, body := cacheGet(t, client, srv.URL, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "hello world", body)
+	now = now.Add(time.Minute)
+	status, _ = cacheGet(t, client, srv.URL, nil)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestCacheTransportNotCached(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/large":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Write(make([]byte, 16))
+			return
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer srv.Close()
+
+	cfg := DefaultCacheConfig()
+	cfg.MaxBodySize = 15
+	client := &http.Client{Transport: newTestCacheTransport(t, cfg)}
+	for i := 0; i < 2; i++ {
+		cacheGet(t, client, srv.URL+"/no-store", nil)
+		_, body := cacheGet(t, client, srv.URL+"/large", nil)
+		require.Len(t, body, 16)
+		cacheGet(t, client, srv.URL+"/private", http.Header{"Authorization": []string{"Bearer foo"}})
+	}
+	require.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,55 @@
+package jwtissuer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Claims builds the claims of a token.
+type Claims struct {
+	claims jwt.MapClaims
+}
+
+func NewClaims() *Claims {
+	return &Claims{claims: jwt.MapClaims{}}
+}
+
+// Subject sets the sub claim.
+func (c *Claims) Subject(sub string) *Claims {
+	return c.Set("sub", sub)
+}
+
+// Audience sets the aud claim, a single audience is set as a string.
+func (c *Claims) Audience(aud ...string) *Claims {
+	if len(aud) == 1 {
+		return c.Set("aud", aud[0])
+	}
+	return c.Set("aud", aud)
+}
+
+// Scope sets the scope claim as a space separated list.
+func (c *Claims) Scope(scopes ...string) *Claims {
+	return c.Set("scope", strings.Join(scopes, " "))
+}
+
+// ExpiresIn overrides the token lifetime of the issuer.
+func (c *Claims) ExpiresIn(d time.Duration) *Claims {
+	return c.Set("exp", time.Now().Add(d).Unix())
+}
+
+// Set sets a claim.
+func (c *Claims) Set(key string, value interface{}) *Claims {
+	c.claims[key] = value
+	return c
+}
+
+// Map returns the built claims.
+func (c *Claims) Map() jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	for k, v := range c.claims {
+		claims[k] = v
+	}
+	return claims
+}
@@ -0,0 +1,14 @@
+module github.com/xenitab/pkg/jwtissuer
+
+go 1.20
+
+require (
+	github.com/golang-jwt/jwt/v4 v4.5.0
+	github.com/stretchr/testify v1.8.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,340 @@
+package jwtissuer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	DiscoveryPath = "/.well-known/openid-configuration"
+	JWKSPath      = "/jwks"
+)
+
+type Config struct {
+	// Issuer set as the iss claim, also the base URL of the discovery and key set handlers.
+	Issuer string
+	// Signing algorithm, one of RS256, RS384, RS512, ES256, ES384 or ES512.
+	Algorithm string
+	// Signing key, a key is generated if nil. Replicas issuing tokens for the same issuer have
+	// to share the key, as each replica only serves its own keys.
+	Key crypto.Signer
+	// Lifetime of tokens which do not set the exp claim.
+	TokenLifetime time.Duration
+	// Duration a key remains in the key set after it has been rotated out, it should be longer
+	// than the token lifetime so that issued tokens remain valid.
+	RetiredKeyTTL time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Issuer:        "",
+		Algorithm:     "RS256",
+		Key:           nil,
+		TokenLifetime: 15 * time.Minute,
+		RetiredKeyTTL: time.Hour,
+	}
+}
+
+type signingKey struct {
+	kid       string
+	key       crypto.Signer
+	retiredAt time.Time
+}
+
+// Issuer mints signed JWTs and serves the public keys needed to validate them.
+type Issuer struct {
+	cfg    Config
+	method jwt.SigningMethod
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	current *signingKey
+	retired []*signingKey
+}
+
+func New(cfg Config) (*Issuer, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("issuer cannot be empty")
+	}
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil || !strings.HasPrefix(cfg.Algorithm, "RS") && !strings.HasPrefix(cfg.Algorithm, "ES") {
+		return nil, fmt.Errorf("unsupported algorithm %s", cfg.Algorithm)
+	}
+	i := &Issuer{
+		cfg:    cfg,
+		method: method,
+		now:    time.Now,
+	}
+	key, err := i.newSigningKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	i.current = key
+	return i, nil
+}
+
+// Rotate replaces the signing key with key, a key is generated if nil. The previous key is kept
+// in the key set for RetiredKeyTTL.
+func (i *Issuer) Rotate(key crypto.Signer) error {
+	signingKey, err := i.newSigningKey(key)
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.current.retiredAt = i.now()
+	i.retired = append(i.retired, i.current)
+	i.current = signingKey
+	return nil
+}
+
+func (i *Issuer) newSigningKey(key crypto.Signer) (*signingKey, error) {
+	if key == nil {
+		var err error
+		key, err = generateKey(i.cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(i.cfg.Algorithm, "RS") {
+			return nil, fmt.Errorf("algorithm %s requires an ECDSA key", i.cfg.Algorithm)
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(i.cfg.Algorithm, "ES") {
+			return nil, fmt.Errorf("algorithm %s requires an RSA key", i.cfg.Algorithm)
+		}
+	default:
+		return nil, errors.New("key has to be an RSA or ECDSA key")
+	}
+	jwk := toJWK("", i.cfg.Algorithm, key.Public())
+	return &signingKey{
+		kid: jwk.Thumbprint(),
+		key: key,
+	}, nil
+}
+
+func generateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case "ES256":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	default:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+}
+
+// Issue returns a token with the claims signed with the current key. The iss, iat, nbf, exp and
+// jti claims are added unless set.
+func (i *Issuer) Issue(claims jwt.MapClaims) (string, error) {
+	c := jwt.MapClaims{}
+	for k, v := range claims {
+		c[k] = v
+	}
+	now := i.now()
+	jti, err := randomID()
+	if err != nil {
+		return "", err
+	}
+	setDefault(c, "iss", i.cfg.Issuer)
+	setDefault(c, "iat", now.Unix())
+	setDefault(c, "nbf", now.Unix())
+	setDefault(c, "exp", now.Add(i.cfg.TokenLifetime).Unix())
+	setDefault(c, "jti", jti)
+
+	i.mu.RLock()
+	key := i.current
+	i.mu.RUnlock()
+	token := jwt.NewWithClaims(i.method, c)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.key)
+}
+
+// KeySet returns the current key and the retired keys which have not expired.
+func (i *Issuer) KeySet() JWKS {
+	now := i.now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	retired := []*signingKey{}
+	for _, key := range i.retired {
+		if now.Sub(key.retiredAt) < i.cfg.RetiredKeyTTL {
+			retired = append(retired, key)
+		}
+	}
+	i.retired = retired
+	jwks := JWKS{Keys: []JWK{toJWK(i.current.kid, i.cfg.Algorithm, i.current.key.Public())}}
+	for _, key := range retired {
+		jwks.Keys = append(jwks.Keys, toJWK(key.kid, i.cfg.Algorithm, key.key.Public()))
+	}
+	return jwks
+}
+
+// JWKSHandler serves the key set.
+func (i *Issuer) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Clients should refetch the key set regularly to pick up rotated keys.
+		w.Header().Set("Cache-Control", "max-age=300")
+		writeJSON(w, i.KeySet())
+	})
+}
+
+// DiscoveryHandler serves a discovery document pointing to the key set, so that validators which
+// discover keys from the issuer can validate the tokens.
+func (i *Issuer) DiscoveryHandler() http.Handler {
+	issuer := strings.TrimSuffix(i.cfg.Issuer, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                i.cfg.Issuer,
+			"jwks_uri":                              issuer + JWKSPath,
+			"response_types_supported":              []string{"token"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{i.cfg.Algorithm},
+		})
+	})
+}
+
+// Handler serves the discovery document and key set at DiscoveryPath and JWKSPath.
+func (i *Issuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(DiscoveryPath, i.DiscoveryHandler())
+	mux.Handle(JWKSPath, i.JWKSHandler())
+	return mux
+}
+
+// JWK is a public key in the key set.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of the key, which is used as the key ID so that
+// replicas sharing a key use the same key ID.
+func (k JWK) Thumbprint() string {
+	var b []byte
+	switch k.KeyType {
+	case "RSA":
+		b = []byte(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N))
+	case "EC":
+		b = []byte(fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Curve, k.X, k.Y))
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKey returns the RSA or ECDSA public key.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.KeyType)
+	}
+}
+
+// JWKS is the key set served by the issuer.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the key with the key ID, false is returned if it is not in the set.
+func (s JWKS) Key(kid string) (JWK, bool) {
+	for _, key := range s.Keys {
+		if key.KeyID == kid {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+func toJWK(kid, alg string, pub crypto.PublicKey) JWK {
+	jwk := JWK{
+		KeyID:     kid,
+		Algorithm: alg,
+		Use:       "sig",
+	}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	}
+	return jwk
+}
+
+func setDefault(claims jwt.MapClaims, key string, value interface{}) {
+	if _, ok := claims[key]; !ok {
+		claims[key] = value
+	}
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,137 @@
+package jwtissuer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func fetchKeySet(t *testing.T, srv *httptest.Server) JWKS {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL + DiscoveryPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	discovery := struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&discovery))
+	require.Equal(t, srv.URL, discovery.Issuer)
+
+	resp, err = srv.Client().Get(discovery.JWKSURI)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	jwks := JWKS{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	return jwks
+}
+
+func parse(t *testing.T, jwks JWKS, token string) (jwt.MapClaims, error) {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		key, ok := jwks.Key(token.Header["kid"].(string))
+		if !ok {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key.PublicKey()
+	})
+	return claims, err
+}
+
+func TestIssuer(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "ES384"} {
+		t.Run(alg, func(t *testing.T) {
+			var issuer *Issuer
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				issuer.Handler().ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+			cfg := DefaultConfig()
+			cfg.Issuer = srv.URL
+			cfg.Algorithm = alg
+			var err error
+			issuer, err = New(cfg)
+			require.NoError(t, err)
+
+			token, err := issuer.Issue(NewClaims().Subject("service-a").Audience("service-b").Scope("read", "write").Map())
+			require.NoError(t, err)
+			claims, err := parse(t, fetchKeySet(t, srv), token)
+			require.NoError(t, err)
+			require.Equal(t, srv.URL, claims["iss"])
+			require.Equal(t, "service-a", claims["sub"])
+			require.Equal(t, "service-b", claims["aud"])
+			require.Equal(t, "read write", claims["scope"])
+			require.NotEmpty(t, claims["jti"])
+		})
+	}
+}
+
+func TestIssuerRotate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Issuer = "https://issuer.example.com"
+	cfg.Algorithm = "ES256"
+	issuer, err := New(cfg)
+	require.NoError(t, err)
+	now := time.Now()
+	issuer.now = func() time.Time {
+		return now
+	}
+	srv := httptest.NewServer(issuer.Handler())
+	defer srv.Close()
+
+	oldToken, err := issuer.Issue(NewClaims().Subject("foo").Map())
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, issuer.Rotate(key))
+	newToken, err := issuer.Issue(NewClaims().Subject("foo").Map())
+	require.NoError(t, err)
+
+	jwks := issuer.KeySet()
+	require.Len(t, jwks.Keys, 2)
+	_, err = parse(t, jwks, oldToken)
+	require.NoError(t, err)
+	_, err = parse(t, jwks, newToken)
+	require.NoError(t, err)
+
+	// The key ID is derived from the key, so replicas sharing the key use the same key ID.
+	other, err := New(Config{Issuer: cfg.Issuer, Algorithm: "ES256", Key: key, TokenLifetime: time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, jwks.Keys[0].KeyID, other.KeySet().Keys[0].KeyID)
+
+	now = now.Add(cfg.RetiredKeyTTL)
+	jwks = issuer.KeySet()
+	require.Len(t, jwks.Keys, 1)
+	_, err = parse(t, jwks, oldToken)
+	require.Error(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	err = issuer.Rotate(edKey)
+	require.EqualError(t, err, "key has to be an RSA or ECDSA key")
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	_, err := New(DefaultConfig())
+	require.EqualError(t, err, "issuer cannot be empty")
+	cfg := DefaultConfig()
+	cfg.Issuer = "https://issuer.example.com"
+	cfg.Algorithm = "HS256"
+	_, err = New(cfg)
+	require.EqualError(t, err, "unsupported algorithm HS256")
+	cfg.Algorithm = "RS256"
+	cfg.Key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = New(cfg)
+	require.EqualError(t, err, "algorithm RS256 requires an RSA key")
+}
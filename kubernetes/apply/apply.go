@@ -0,0 +1,136 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xenitab/pkg/retry"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+type Config struct {
+	// Name of the field manager owning the applied fields, usually the name of the operator.
+	FieldManager string
+	// Take ownership of fields managed by other field managers instead of failing with a conflict.
+	Force bool
+	// Validate the apply without persisting the result.
+	DryRun bool
+	// Retry policy for conflicts and transient API errors.
+	Retry retry.Policy
+}
+
+func DefaultConfig() Config {
+	policy := retry.DefaultPolicy()
+	policy.Retryable = IsRetryable
+	return Config{
+		FieldManager: "",
+		Force:        false,
+		DryRun:       false,
+		Retry:        policy,
+	}
+}
+
+// IsRetryable returns true for conflicts and errors caused by an unavailable or overloaded API server.
+func IsRetryable(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// Applier performs server-side apply of typed or unstructured objects.
+type Applier struct {
+	cfg    Config
+	client dynamic.Interface
+	mapper meta.RESTMapper
+}
+
+// NewApplier returns an applier using the mapper to find the resource of each object, for example
+// restmapper.NewDeferredDiscoveryRESTMapper.
+func NewApplier(client dynamic.Interface, mapper meta.RESTMapper, cfg Config) (*Applier, error) {
+	if cfg.FieldManager == "" {
+		return nil, errors.New("field manager cannot be empty")
+	}
+	return &Applier{
+		cfg:    cfg,
+		client: client,
+		mapper: mapper,
+	}, nil
+}
+
+// Apply sends obj as an apply patch and returns the resulting object. Typed objects without a
+// group version kind are looked up in the client-go scheme. Only the fields set in obj are owned
+// by the field manager, so obj should contain the desired fields and nothing else.
+func (a *Applier) Apply(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	gvk := u.GroupVersionKind()
+	mapping, err := a.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	var resource dynamic.ResourceInterface = a.client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if u.GetNamespace() == "" {
+			return nil, fmt.Errorf("namespace is required for %s %s", gvk.Kind, u.GetName())
+		}
+		resource = a.client.Resource(mapping.Resource).Namespace(u.GetNamespace())
+	}
+
+	opts := a.applyOptions()
+	return retry.DoValue(ctx, a.cfg.Retry, func(ctx context.Context) (*unstructured.Unstructured, error) {
+		return resource.Apply(ctx, u.GetName(), u, opts)
+	})
+}
+
+func (a *Applier) applyOptions() metav1.ApplyOptions {
+	opts := metav1.ApplyOptions{
+		FieldManager: a.cfg.FieldManager,
+		Force:        a.cfg.Force,
+	}
+	if a.cfg.DryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	return opts
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		u = &unstructured.Unstructured{Object: content}
+		if u.GetKind() == "" {
+			gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+			if err != nil {
+				return nil, err
+			}
+			u.SetGroupVersionKind(gvks[0])
+		}
+	} else {
+		u = u.DeepCopy()
+	}
+	if u.GetKind() == "" || u.GetAPIVersion() == "" {
+		return nil, errors.New("object is missing apiVersion or kind")
+	}
+	if u.GetName() == "" {
+		return nil, errors.New("object name cannot be empty")
+	}
+	// Managed fields cannot be set in an apply patch.
+	u.SetManagedFields(nil)
+	u.SetResourceVersion("")
+	return u, nil
+}
@@ -0,0 +1,115 @@
+package apply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testApplier(t *testing.T, objects ...runtime.Object) (*Applier, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objects...)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	cfg := DefaultConfig()
+	cfg.FieldManager = "test"
+	cfg.Retry.InitialInterval = time.Millisecond
+	a, err := NewApplier(client, mapper, cfg)
+	require.NoError(t, err)
+	return a, client
+}
+
+func TestApplyTyped(t *testing.T) {
+	a, client := testApplier(t)
+	// The fake client does not implement apply patches, return the patch as the result instead.
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		u := &unstructured.Unstructured{}
+		err := u.UnmarshalJSON(action.(k8stesting.PatchAction).GetPatch())
+		return true, u, err
+	})
+	u, err := a.Apply(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ConfigMap", u.GetKind())
+
+	actions := client.Actions()
+	require.Len(t, actions, 1)
+	patch, ok := actions[0].(k8stesting.PatchAction)
+	require.True(t, ok)
+	require.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+	require.Equal(t, "configmaps", patch.GetResource().Resource)
+	require.Equal(t, "default", patch.GetNamespace())
+	require.Contains(t, string(patch.GetPatch()), `"key":"value"`)
+}
+
+func TestApplyRetryConflict(t *testing.T) {
+	a, client := testApplier(t)
+	attempts := 0
+	client.PrependReactor("patch", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		attempts++
+		if attempts == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, "foo", nil)
+		}
+		return true, &unstructured.Unstructured{}, nil
+	})
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("foo")
+	_, err := a.Apply(context.Background(), ns)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+}
+
+func TestApplyNotRetryable(t *testing.T) {
+	a, client := testApplier(t)
+	attempts := 0
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		attempts++
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "foo", nil)
+	})
+	_, err := a.Apply(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+	})
+	require.True(t, apierrors.IsForbidden(err))
+	require.Equal(t, 1, attempts)
+}
+
+func TestApplyValidation(t *testing.T) {
+	a, _ := testApplier(t)
+	_, err := a.Apply(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	})
+	require.EqualError(t, err, "namespace is required for ConfigMap foo")
+	_, err = a.Apply(context.Background(), &corev1.ConfigMap{})
+	require.EqualError(t, err, "object name cannot be empty")
+
+	_, err = NewApplier(nil, nil, DefaultConfig())
+	require.EqualError(t, err, "field manager cannot be empty")
+}
+
+func TestApplyOptions(t *testing.T) {
+	a, _ := testApplier(t)
+	a.cfg.Force = true
+	a.cfg.DryRun = true
+	opts := a.applyOptions()
+	require.Equal(t, "test", opts.FieldManager)
+	require.True(t, opts.Force)
+	require.Equal(t, []string{metav1.DryRunAll}, opts.DryRun)
+}
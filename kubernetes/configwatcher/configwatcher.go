@@ -0,0 +1,269 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+type Config[T any] struct {
+	// Name of the Secret or ConfigMap to watch.
+	Name string
+	// Namespace of the Secret or ConfigMap to watch.
+	Namespace string
+	// Key containing a JSON or YAML document to unmarshal. When empty each key in the
+	// object is unmarshaled as a string field using its JSON tag.
+	Key string
+	// Duration to wait for further changes before OnChange is called.
+	Debounce time.Duration
+	// Called with the new value every time the object changes.
+	OnChange func(value T)
+	// Called when the object can not be unmarshaled or has been deleted.
+	OnError func(err error)
+}
+
+func DefaultConfig[T any]() Config[T] {
+	return Config[T]{
+		Name:      "",
+		Namespace: "",
+		Key:       "",
+		Debounce:  time.Second,
+		OnChange:  nil,
+		OnError:   nil,
+	}
+}
+
+type Watcher[T any] struct {
+	cfg      Config[T]
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	toData   func(obj interface{}) (map[string][]byte, bool)
+	notify   chan struct{}
+
+	mu     sync.RWMutex
+	data   map[string][]byte
+	value  T
+	loaded bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSecretWatcher returns a watcher which unmarshals the data of the named Secret.
+func NewSecretWatcher[T any](client kubernetes.Interface, cfg Config[T]) (*Watcher[T], error) {
+	factory := newInformerFactory(client, cfg.Name, cfg.Namespace)
+	informer := factory.Core().V1().Secrets().Informer()
+	toData := func(obj interface{}) (map[string][]byte, bool) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil, false
+		}
+		return secret.Data, true
+	}
+	return newWatcher(cfg, factory, informer, toData)
+}
+
+// NewConfigMapWatcher returns a watcher which unmarshals the data of the named ConfigMap.
+func NewConfigMapWatcher[T any](client kubernetes.Interface, cfg Config[T]) (*Watcher[T], error) {
+	factory := newInformerFactory(client, cfg.Name, cfg.Namespace)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	toData := func(obj interface{}) (map[string][]byte, bool) {
+		configMap, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return nil, false
+		}
+		data := map[string][]byte{}
+		for k, v := range configMap.BinaryData {
+			data[k] = v
+		}
+		for k, v := range configMap.Data {
+			data[k] = []byte(v)
+		}
+		return data, true
+	}
+	return newWatcher(cfg, factory, informer, toData)
+}
+
+func newInformerFactory(client kubernetes.Interface, name, namespace string) informers.SharedInformerFactory {
+	tweak := func(opts *metav1.ListOptions) {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+	return informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace), informers.WithTweakListOptions(tweak))
+}
+
+func newWatcher[T any](cfg Config[T], factory informers.SharedInformerFactory, informer cache.SharedIndexInformer, toData func(obj interface{}) (map[string][]byte, bool)) (*Watcher[T], error) {
+	if cfg.Name == "" || cfg.Namespace == "" {
+		return nil, errors.New("name and namespace cannot be empty")
+	}
+	w := &Watcher[T]{
+		cfg:      cfg,
+		factory:  factory,
+		informer: informer,
+		toData:   toData,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.update,
+		UpdateFunc: func(_, newObj interface{}) {
+			w.update(newObj)
+		},
+		DeleteFunc: func(_ interface{}) {
+			w.onError(fmt.Errorf("%s/%s has been deleted", cfg.Namespace, cfg.Name))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Start watches the object until Stop is called or ctx is cancelled.
+func (w *Watcher[T]) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.mu.Unlock()
+		return errors.New("watcher has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.mu.Unlock()
+	defer close(w.done)
+
+	w.factory.Start(ctx.Done())
+	defer w.factory.Shutdown()
+
+	// Changes are debounced so that a burst of updates results in a single reload.
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.notify:
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(w.cfg.Debounce)
+			timeout = timer.C
+		case <-timeout:
+			timeout = nil
+			w.reload()
+		}
+	}
+}
+
+// Stop stops watching the object.
+func (w *Watcher[T]) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get returns the latest value, false is returned if no value has been loaded yet.
+func (w *Watcher[T]) Get() (T, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.value, w.loaded
+}
+
+func (w *Watcher[T]) update(obj interface{}) {
+	data, ok := w.toData(obj)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	if w.loaded && reflect.DeepEqual(data, w.data) {
+		w.mu.Unlock()
+		return
+	}
+	w.data = data
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher[T]) reload() {
+	w.mu.RLock()
+	data := w.data
+	w.mu.RUnlock()
+
+	value, err := unmarshal[T](data, w.cfg.Key)
+	if err != nil {
+		w.onError(fmt.Errorf("could not unmarshal %s/%s: %w", w.cfg.Namespace, w.cfg.Name, err))
+		return
+	}
+
+	w.mu.Lock()
+	w.value = value
+	w.loaded = true
+	w.mu.Unlock()
+	if w.cfg.OnChange != nil {
+		w.cfg.OnChange(value)
+	}
+}
+
+func (w *Watcher[T]) onError(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+func unmarshal[T any](data map[string][]byte, key string) (T, error) {
+	var value T
+	if key != "" {
+		b, ok := data[key]
+		if !ok {
+			return value, fmt.Errorf("key %q not found", key)
+		}
+		err := yaml.Unmarshal(b, &value)
+		if err != nil {
+			return value, err
+		}
+		return value, nil
+	}
+
+	m := map[string]string{}
+	for k, v := range data {
+		m[k] = string(v)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(b, &value)
+	if err != nil {
+		return value, err
+	}
+	return value, nil
+}
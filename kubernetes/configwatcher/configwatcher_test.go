@@ -0,0 +1,86 @@
+package configwatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestSecretWatcher(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data: map[string][]byte{
+			"username": []byte("admin"),
+			"password": []byte("secret"),
+		},
+	}
+	client := fake.NewSimpleClientset(secret)
+
+	changes := make(chan credentials)
+	cfg := DefaultConfig[credentials]()
+	cfg.Name = "foo"
+	cfg.Namespace = "bar"
+	cfg.Debounce = 10 * time.Millisecond
+	cfg.OnChange = func(value credentials) {
+		changes <- value
+	}
+	w, err := NewSecretWatcher(client, cfg)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, w.Start(context.Background()))
+	}()
+
+	require.Equal(t, credentials{Username: "admin", Password: "secret"}, <-changes)
+	value, ok := w.Get()
+	require.True(t, ok)
+	require.Equal(t, "secret", value.Password)
+
+	secret.Data["password"] = []byte("rotated")
+	_, err = client.CoreV1().Secrets("bar").Update(context.Background(), secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, credentials{Username: "admin", Password: "rotated"}, <-changes)
+
+	require.NoError(t, w.Stop(context.Background()))
+}
+
+func TestConfigMapWatcherKey(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Data: map[string]string{
+			"config.yaml": "username: admin\npassword: secret\n",
+		},
+	}
+	client := fake.NewSimpleClientset(configMap)
+
+	changes := make(chan credentials)
+	cfg := DefaultConfig[credentials]()
+	cfg.Name = "foo"
+	cfg.Namespace = "bar"
+	cfg.Key = "config.yaml"
+	cfg.Debounce = 0
+	cfg.OnChange = func(value credentials) {
+		changes <- value
+	}
+	w, err := NewConfigMapWatcher(client, cfg)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, w.Start(context.Background()))
+	}()
+	require.Equal(t, credentials{Username: "admin", Password: "secret"}, <-changes)
+	require.NoError(t, w.Stop(context.Background()))
+}
+
+func TestUnmarshalMissingKey(t *testing.T) {
+	_, err := unmarshal[credentials](map[string][]byte{}, "config.yaml")
+	require.EqualError(t, err, "key \"config.yaml\" not found")
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xenitab/pkg/kubernetes/informer"
+	"github.com/xenitab/pkg/kubernetes/leaderelection"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// ReconcileFunc reconciles the object with the namespace/name key. It is called for every change
+// to the object, including deletion, so it should read the current state from the informer store
+// rather than relying on the event. Returning an error retries the key according to the policy.
+type ReconcileFunc func(ctx context.Context, key string) error
+
+type Config struct {
+	// Name of the controller, used as queue name in metrics.
+	Name string
+	// Number of keys reconciled concurrently, a key is never reconciled concurrently.
+	Workers int
+	// Retry policy for keys which fail reconciliation.
+	Retry RetryPolicy
+	// Called when a key is dropped after failing all attempts.
+	OnError func(key string, err error)
+	// Registry to register queue metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+	// Leader election config, keys are only reconciled while leading. Leader election is
+	// disabled if nil.
+	LeaderElection *leaderelection.Config
+}
+
+type RetryPolicy struct {
+	// Maximum number of attempts per key, including the first attempt.
+	MaxAttempts int
+	// Delay before the first retry, the delay is doubled after each retry.
+	InitialInterval time.Duration
+	// Upper bound for the delay between retries.
+	MaxInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Name:    "",
+		Workers: 1,
+		Retry: RetryPolicy{
+			MaxAttempts:     5,
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     10 * time.Second,
+		},
+		OnError:        nil,
+		Registerer:     nil,
+		LeaderElection: nil,
+	}
+}
+
+// Controller reconciles the objects of an informer. Changes observed by the informer are added to
+// a work queue by key and reconciled by the workers, optionally only while holding a lease.
+type Controller struct {
+	cfg       Config
+	reconcile ReconcileFunc
+	adapter   *informer.Adapter[interface{}]
+	queue     workqueue.RateLimitingInterface
+	elector   *leaderelection.LeaderElector
+	leading   chan struct{}
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a controller for the informer, for example factory.Core().V1().Pods().Informer().
+// The controller runs the informer, which should not be started by anyone else.
+func New(client kubernetes.Interface, inf cache.SharedIndexInformer, reconcile ReconcileFunc, cfg Config) (*Controller, error) {
+	if reconcile == nil {
+		return nil, errors.New("reconcile func cannot be nil")
+	}
+	adapter, err := informer.New[interface{}](inf, informer.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	queueCfg := workqueue.RateLimitingQueueConfig{
+		Name: cfg.Name,
+	}
+	if cfg.Registerer != nil {
+		queueCfg.MetricsProvider, err = newMetricsProvider(cfg.Registerer, cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(cfg.Retry.InitialInterval, cfg.Retry.MaxInterval)
+	c := &Controller{
+		cfg:       cfg,
+		reconcile: reconcile,
+		adapter:   adapter,
+		queue:     workqueue.NewRateLimitingQueueWithConfig(rateLimiter, queueCfg),
+		leading:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if cfg.LeaderElection != nil {
+		leCfg := *cfg.LeaderElection
+		onStartedLeading := leCfg.OnStartedLeading
+		leCfg.OnStartedLeading = func(ctx context.Context) {
+			close(c.leading)
+			if onStartedLeading != nil {
+				onStartedLeading(ctx)
+			}
+		}
+		c.elector, err = leaderelection.NewLeaderElector(client, leCfg)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		close(c.leading)
+	}
+	return c, nil
+}
+
+// Start runs the controller until Stop is called or ctx is cancelled. The informer cache is kept
+// in sync while waiting for leadership, and an error wrapping leaderelection.ErrLeadershipLost is
+// returned if leadership is lost.
+func (c *Controller) Start(ctx context.Context) error {
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.mu.Unlock()
+		return errors.New("controller has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.mu.Unlock()
+	defer close(c.done)
+	defer c.queue.ShutDown()
+
+	var firstErr error
+	errOnce := sync.Once{}
+	wg := sync.WaitGroup{}
+	// The first component to return stops the others.
+	run := func(fn func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn(ctx)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+				})
+			}
+			cancel()
+		}()
+	}
+	run(c.adapter.Start)
+	run(func(ctx context.Context) error {
+		for event := range c.adapter.Events() {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(event.Object)
+			if err != nil {
+				continue
+			}
+			c.queue.Add(key)
+		}
+		return nil
+	})
+	if c.elector != nil {
+		run(c.elector.Start)
+	}
+	run(func(ctx context.Context) error {
+		select {
+		case <-c.leading:
+		case <-ctx.Done():
+			return nil
+		}
+		c.runWorkers(ctx)
+		return nil
+	})
+	wg.Wait()
+	if firstErr != nil {
+		return fmt.Errorf("controller stopped: %w", firstErr)
+	}
+	return nil
+}
+
+// Stop stops the controller and waits for the reconciliations in progress to return.
+func (c *Controller) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runWorkers reconciles keys until ctx is cancelled, waiting for the reconciliations in progress
+// to return.
+func (c *Controller) runWorkers(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	workers := c.cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNextKey(ctx) {
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Controller) processNextKey(ctx context.Context) bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	key := item.(string)
+	err := c.reconcile(ctx, key)
+	if err == nil {
+		c.queue.Forget(item)
+		return true
+	}
+	if c.queue.NumRequeues(item)+1 >= c.cfg.Retry.MaxAttempts || ctx.Err() != nil {
+		c.queue.Forget(item)
+		if c.cfg.OnError != nil {
+			c.cfg.OnError(key, err)
+		}
+		return true
+	}
+	c.queue.AddRateLimited(item)
+	return true
+}
+
+// Enqueue adds the key to the queue, for example to reconcile an object when a related object
+// changes.
+func (c *Controller) Enqueue(key string) {
+	c.queue.Add(key)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/kubernetes/leaderelection"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func receiveKey(t *testing.T, keys <-chan string) string {
+	t.Helper()
+	select {
+	case key := <-keys:
+		return key
+	case <-time.After(5 * time.Second):
+		t.Fatal("key was not reconciled")
+		return ""
+	}
+}
+
+func TestController(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+	})
+	factory := informers.NewSharedInformerFactory(client, 0)
+	keys := make(chan string, 10)
+	reconcile := func(ctx context.Context, key string) error {
+		keys <- key
+		return nil
+	}
+	c, err := New(client, factory.Core().V1().ConfigMaps().Informer(), reconcile, DefaultConfig())
+	require.NoError(t, err)
+	errCh := make(chan error)
+	go func() {
+		errCh <- c.Start(context.Background())
+	}()
+	require.Equal(t, "default/foo", receiveKey(t, keys))
+
+	_, err = client.CoreV1().ConfigMaps("default").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "default/bar", receiveKey(t, keys))
+
+	c.Enqueue("other/baz")
+	require.Equal(t, "other/baz", receiveKey(t, keys))
+
+	require.NoError(t, c.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+}
+
+func TestControllerOnError(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+	})
+	factory := informers.NewSharedInformerFactory(client, 0)
+	keys := make(chan string, 1)
+	cfg := DefaultConfig()
+	cfg.Retry.MaxAttempts = 2
+	cfg.Retry.InitialInterval = time.Millisecond
+	cfg.OnError = func(key string, err error) {
+		keys <- key
+	}
+	reconcile := func(ctx context.Context, key string) error {
+		return errors.New("failed")
+	}
+	c, err := New(client, factory.Core().V1().ConfigMaps().Informer(), reconcile, cfg)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, c.Start(context.Background()))
+	}()
+	require.Equal(t, "default/foo", receiveKey(t, keys))
+	require.NoError(t, c.Stop(context.Background()))
+}
+
+func TestControllerMetrics(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(client, 0)
+	reconcile := func(ctx context.Context, key string) error {
+		return nil
+	}
+	cfg := DefaultConfig()
+	cfg.Name = "configmaps"
+	cfg.Registerer = prometheus.NewRegistry()
+	_, err := New(client, factory.Core().V1().ConfigMaps().Informer(), reconcile, cfg)
+	require.NoError(t, err)
+	// Metrics are labeled with the controller name, so the name has to be unique per registry.
+	_, err = New(client, factory.Core().V1().Secrets().Informer(), reconcile, cfg)
+	require.Error(t, err)
+}
+
+func TestControllerLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+	})
+	factory := informers.NewSharedInformerFactory(client, 0)
+	keys := make(chan string, 10)
+	reconcile := func(ctx context.Context, key string) error {
+		keys <- key
+		return nil
+	}
+	leCfg := leaderelection.DefaultConfig()
+	leCfg.Name = "controller"
+	leCfg.Namespace = "default"
+	leCfg.Identity = "instance"
+	leCfg.LeaseDuration = time.Second
+	leCfg.RenewDeadline = 500 * time.Millisecond
+	leCfg.RetryPeriod = 100 * time.Millisecond
+	cfg := DefaultConfig()
+	cfg.LeaderElection = &leCfg
+	c, err := New(client, factory.Core().V1().ConfigMaps().Informer(), reconcile, cfg)
+	require.NoError(t, err)
+	errCh := make(chan error)
+	go func() {
+		errCh <- c.Start(context.Background())
+	}()
+	require.Equal(t, "default/foo", receiveKey(t, keys))
+
+	lease, err := client.CoordinationV1().Leases("default").Get(context.Background(), "controller", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "instance", *lease.Spec.HolderIdentity)
+
+	require.NoError(t, c.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// metricsProvider exposes the metrics of the client-go work queue with the controller name as label.
+type metricsProvider struct {
+	depth          prometheus.Gauge
+	adds           prometheus.Counter
+	latency        prometheus.Histogram
+	workDuration   prometheus.Histogram
+	unfinished     prometheus.Gauge
+	longestRunning prometheus.Gauge
+	retries        prometheus.Counter
+}
+
+func newMetricsProvider(reg prometheus.Registerer, name string) (*metricsProvider, error) {
+	p := &metricsProvider{
+		depth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "workqueue_depth",
+			Help: "Current number of keys waiting to be reconciled.",
+		}),
+		adds: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "workqueue_adds_total",
+			Help: "Total number of keys added to the queue.",
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "workqueue_queue_duration_seconds",
+			Help:    "Time a key waits in the queue before being reconciled.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		workDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "workqueue_work_duration_seconds",
+			Help:    "Time spent reconciling a key.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		}),
+		unfinished: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "workqueue_unfinished_work_seconds",
+			Help: "Time spent on reconciliations which are still in progress.",
+		}),
+		longestRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "workqueue_longest_running_processor_seconds",
+			Help: "Time spent on the longest running reconciliation which is still in progress.",
+		}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "workqueue_retries_total",
+			Help: "Total number of keys retried after failing.",
+		}),
+	}
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"name": name}, reg)
+	for _, c := range []prometheus.Collector{p.depth, p.adds, p.latency, p.workDuration, p.unfinished, p.longestRunning, p.retries} {
+		err := reg.Register(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *metricsProvider) NewDepthMetric(string) workqueue.GaugeMetric {
+	return p.depth
+}
+
+func (p *metricsProvider) NewAddsMetric(string) workqueue.CounterMetric {
+	return p.adds
+}
+
+func (p *metricsProvider) NewLatencyMetric(string) workqueue.HistogramMetric {
+	return p.latency
+}
+
+func (p *metricsProvider) NewWorkDurationMetric(string) workqueue.HistogramMetric {
+	return p.workDuration
+}
+
+func (p *metricsProvider) NewUnfinishedWorkSecondsMetric(string) workqueue.SettableGaugeMetric {
+	return p.unfinished
+}
+
+func (p *metricsProvider) NewLongestRunningProcessorSecondsMetric(string) workqueue.SettableGaugeMetric {
+	return p.longestRunning
+}
+
+func (p *metricsProvider) NewRetriesMetric(string) workqueue.CounterMetric {
+	return p.retries
+}
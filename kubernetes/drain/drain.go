@@ -0,0 +1,225 @@
+package drain
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/xenitab/pkg/kubernetes/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reason describes why the pod is about to be shut down.
+type Reason string
+
+const (
+	// ReasonPodDeleted is used when the pod has a deletion timestamp or has been deleted.
+	ReasonPodDeleted Reason = "PodDeleted"
+	// ReasonNodeCordoned is used when the node has been marked unschedulable.
+	ReasonNodeCordoned Reason = "NodeCordoned"
+	// ReasonNodeTainted is used when the node has one of the configured taints.
+	ReasonNodeTainted Reason = "NodeTainted"
+)
+
+type Config struct {
+	// Pod to watch for deletion, usually detected with pod.IdentityFromEnv.
+	Pod pod.Identity
+	// Name of the node to watch, usually set through the downward API. The node of the pod is
+	// used if empty. Watching the node requires permission to list and watch nodes.
+	NodeName string
+	// Should the node be watched for cordoning and taints.
+	WatchNode bool
+	// Taint keys signaling that the node is about to be drained.
+	TaintKeys []string
+	// Called once when the pod is about to be shut down.
+	OnDrain func(reason Reason)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Pod:       pod.Identity{},
+		NodeName:  "",
+		WatchNode: true,
+		TaintKeys: []string{
+			"node.kubernetes.io/unschedulable",
+			"ToBeDeletedByClusterAutoscaler",
+			"karpenter.sh/disruption",
+		},
+		OnDrain: nil,
+	}
+}
+
+// Watcher detects that the running pod is about to be shut down before SIGTERM is received,
+// either because the pod is being deleted or because its node is being drained. Stateful
+// consumers can use it to start handing off work early.
+type Watcher struct {
+	cfg    Config
+	client kubernetes.Interface
+	// Closed when draining has been detected.
+	draining chan struct{}
+
+	mu     sync.Mutex
+	reason Reason
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(client kubernetes.Interface, cfg Config) (*Watcher, error) {
+	if cfg.Pod.Name == "" || cfg.Pod.Namespace == "" {
+		return nil, errors.New("pod name and namespace cannot be empty")
+	}
+	return &Watcher{
+		cfg:      cfg,
+		client:   client,
+		draining: make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Draining returns a channel which is closed when draining has been detected.
+func (w *Watcher) Draining() <-chan struct{} {
+	return w.draining
+}
+
+// Reason returns why draining was detected, empty if it has not been detected.
+func (w *Watcher) Reason() Reason {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason
+}
+
+// Start watches the pod and node until Stop is called or ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.mu.Unlock()
+		return errors.New("watcher has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.mu.Unlock()
+	defer close(w.done)
+
+	podFactory := newInformerFactory(w.client, w.cfg.Pod.Name, w.cfg.Pod.Namespace)
+	_, err := podFactory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.updatePod,
+		UpdateFunc: func(_, newObj interface{}) {
+			w.updatePod(newObj)
+		},
+		DeleteFunc: func(_ interface{}) {
+			w.trigger(ReasonPodDeleted)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	podFactory.Start(ctx.Done())
+	defer podFactory.Shutdown()
+
+	if w.cfg.WatchNode {
+		nodeName := w.cfg.NodeName
+		if nodeName == "" {
+			p, err := w.client.CoreV1().Pods(w.cfg.Pod.Namespace).Get(ctx, w.cfg.Pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			nodeName = p.Spec.NodeName
+		}
+		if nodeName == "" {
+			return errors.New("pod has not been scheduled to a node")
+		}
+		nodeFactory := newInformerFactory(w.client, nodeName, "")
+		_, err := nodeFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: w.updateNode,
+			UpdateFunc: func(_, newObj interface{}) {
+				w.updateNode(newObj)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		nodeFactory.Start(ctx.Done())
+		defer nodeFactory.Shutdown()
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// Stop stops watching the pod and node.
+func (w *Watcher) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Watcher) updatePod(obj interface{}) {
+	p, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if p.DeletionTimestamp != nil {
+		w.trigger(ReasonPodDeleted)
+	}
+}
+
+func (w *Watcher) updateNode(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	if node.Spec.Unschedulable {
+		w.trigger(ReasonNodeCordoned)
+		return
+	}
+	for _, taint := range node.Spec.Taints {
+		for _, key := range w.cfg.TaintKeys {
+			if taint.Key == key {
+				w.trigger(ReasonNodeTainted)
+				return
+			}
+		}
+	}
+}
+
+// trigger records the reason and calls OnDrain the first time draining is detected.
+func (w *Watcher) trigger(reason Reason) {
+	w.mu.Lock()
+	if w.reason != "" {
+		w.mu.Unlock()
+		return
+	}
+	w.reason = reason
+	close(w.draining)
+	w.mu.Unlock()
+	if w.cfg.OnDrain != nil {
+		w.cfg.OnDrain(reason)
+	}
+}
+
+func newInformerFactory(client kubernetes.Interface, name, namespace string) informers.SharedInformerFactory {
+	tweak := func(opts *metav1.ListOptions) {
+		opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+	opts := []informers.SharedInformerOption{informers.WithTweakListOptions(tweak)}
+	if namespace != "" {
+		opts = append(opts, informers.WithNamespace(namespace))
+	}
+	return informers.NewSharedInformerFactoryWithOptions(client, 0, opts...)
+}
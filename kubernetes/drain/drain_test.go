@@ -0,0 +1,115 @@
+package drain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/kubernetes/pod"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newObjects() (*corev1.Pod, *corev1.Node) {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+	}
+	return p, node
+}
+
+func startWatcher(t *testing.T, w *Watcher) {
+	t.Helper()
+	go func() {
+		require.NoError(t, w.Start(context.Background()))
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, w.Stop(context.Background()))
+	})
+}
+
+func waitDraining(t *testing.T, w *Watcher) {
+	t.Helper()
+	select {
+	case <-w.Draining():
+	case <-time.After(5 * time.Second):
+		t.Fatal("draining was not detected")
+	}
+}
+
+func TestWatcherNodeTainted(t *testing.T) {
+	p, node := newObjects()
+	client := fake.NewSimpleClientset(p, node)
+
+	reasons := make(chan Reason, 1)
+	cfg := DefaultConfig()
+	cfg.Pod = pod.Identity{Name: "foo", Namespace: "bar"}
+	cfg.OnDrain = func(reason Reason) {
+		reasons <- reason
+	}
+	w, err := New(client, cfg)
+	require.NoError(t, err)
+	startWatcher(t, w)
+
+	// Wait for the informers to sync before changing the node.
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, w.Reason())
+	node.Spec.Taints = []corev1.Taint{{Key: "karpenter.sh/disruption", Effect: corev1.TaintEffectNoSchedule}}
+	_, err = client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	waitDraining(t, w)
+	require.Equal(t, ReasonNodeTainted, <-reasons)
+	require.Equal(t, ReasonNodeTainted, w.Reason())
+
+	// Only the first reason is reported.
+	err = client.CoreV1().Pods("bar").Delete(context.Background(), "foo", metav1.DeleteOptions{})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, reasons)
+}
+
+func TestWatcherPodDeleted(t *testing.T) {
+	p, _ := newObjects()
+	client := fake.NewSimpleClientset(p)
+
+	cfg := DefaultConfig()
+	cfg.Pod = pod.Identity{Name: "foo", Namespace: "bar"}
+	cfg.WatchNode = false
+	w, err := New(client, cfg)
+	require.NoError(t, err)
+	startWatcher(t, w)
+
+	now := metav1.Now()
+	p.DeletionTimestamp = &now
+	_, err = client.CoreV1().Pods("bar").Update(context.Background(), p, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	waitDraining(t, w)
+	require.Equal(t, ReasonPodDeleted, w.Reason())
+}
+
+func TestWatcherNodeCordoned(t *testing.T) {
+	p, node := newObjects()
+	node.Spec.Unschedulable = true
+	client := fake.NewSimpleClientset(p, node)
+
+	cfg := DefaultConfig()
+	cfg.Pod = pod.Identity{Name: "foo", Namespace: "bar"}
+	w, err := New(client, cfg)
+	require.NoError(t, err)
+	startWatcher(t, w)
+
+	waitDraining(t, w)
+	require.Equal(t, ReasonNodeCordoned, w.Reason())
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	_, err := New(fake.NewSimpleClientset(), DefaultConfig())
+	require.EqualError(t, err, "pod name and namespace cannot be empty")
+}
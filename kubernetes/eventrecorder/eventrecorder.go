@@ -0,0 +1,130 @@
+package eventrecorder
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+)
+
+type Config struct {
+	// Component reported as the source of events.
+	Component string
+	// Logger instance to also output events to.
+	Logger logr.Logger
+	// Duration during which identical events are only recorded once.
+	DeduplicationInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Component:             "",
+		Logger:                logr.Discard(),
+		DeduplicationInterval: time.Minute,
+	}
+}
+
+// Recorder records Kubernetes events while also writing them to a logger. Identical events
+// recorded within the deduplication interval are dropped.
+type Recorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	log         logr.Logger
+	interval    time.Duration
+	now         func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+var _ record.EventRecorder = &Recorder{}
+
+func NewRecorder(client kubernetes.Interface, cfg Config) *Recorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: cfg.Component})
+	r := newRecorder(recorder, cfg)
+	r.broadcaster = broadcaster
+	return r
+}
+
+func newRecorder(recorder record.EventRecorder, cfg Config) *Recorder {
+	return &Recorder{
+		recorder: recorder,
+		log:      cfg.Logger,
+		interval: cfg.DeduplicationInterval,
+		now:      time.Now,
+		seen:     map[string]time.Time{},
+	}
+}
+
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if !r.record(object, eventtype, reason, message) {
+		return
+	}
+	r.recorder.Event(object, eventtype, reason, message)
+}
+
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *Recorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if !r.record(object, eventtype, reason, message) {
+		return
+	}
+	r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+}
+
+// Shutdown stops sending events to the API server.
+func (r *Recorder) Shutdown() {
+	if r.broadcaster == nil {
+		return
+	}
+	r.broadcaster.Shutdown()
+}
+
+// record logs the event and returns false if the event is a duplicate which should be dropped.
+func (r *Recorder) record(object runtime.Object, eventtype, reason, message string) bool {
+	kvs := []interface{}{"type", eventtype, "reason", reason}
+	key := fmt.Sprintf("%s/%s/%s", eventtype, reason, message)
+	ref, err := reference.GetReference(scheme.Scheme, object)
+	if err == nil {
+		kvs = append(kvs, "kind", ref.Kind, "namespace", ref.Namespace, "name", ref.Name)
+		key = fmt.Sprintf("%s/%s/%s/%s/%s", ref.Kind, ref.Namespace, ref.Name, ref.UID, key)
+	}
+
+	if r.interval > 0 {
+		r.mu.Lock()
+		now := r.now()
+		for k, t := range r.seen {
+			if now.Sub(t) >= r.interval {
+				delete(r.seen, k)
+			}
+		}
+		_, ok := r.seen[key]
+		if !ok {
+			r.seen[key] = now
+		}
+		r.mu.Unlock()
+		if ok {
+			return false
+		}
+	}
+
+	if eventtype == corev1.EventTypeWarning {
+		r.log.Error(nil, message, kvs...)
+		return true
+	}
+	r.log.Info(message, kvs...)
+	return true
+}
@@ -0,0 +1,41 @@
+package eventrecorder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Logger = buflogr.NewWithBuffer(&buf)
+	fake := record.NewFakeRecorder(10)
+	recorder := newRecorder(fake, cfg)
+	now := time.Now()
+	recorder.now = func() time.Time {
+		return now
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+	recorder.Event(pod, corev1.EventTypeNormal, "Started", "hello world")
+	recorder.Eventf(pod, corev1.EventTypeNormal, "Started", "hello %s", "world")
+	recorder.Event(pod, corev1.EventTypeWarning, "Failed", "something broke")
+	now = now.Add(time.Minute)
+	recorder.Event(pod, corev1.EventTypeNormal, "Started", "hello world")
+
+	require.Len(t, fake.Events, 3)
+	require.Equal(t, "Normal Started hello world", <-fake.Events)
+	require.Equal(t, "Warning Failed something broke", <-fake.Events)
+	require.Equal(t, "Normal Started hello world", <-fake.Events)
+	expected := "INFO hello world type Normal reason Started kind Pod namespace bar name foo\n" +
+		"ERROR <nil> something broke type Warning reason Failed kind Pod namespace bar name foo\n" +
+		"INFO hello world type Normal reason Started kind Pod namespace bar name foo\n"
+	require.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,183 @@
+package informer
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+type EventType string
+
+const (
+	Added   EventType = "Added"
+	Updated EventType = "Updated"
+	Deleted EventType = "Deleted"
+)
+
+// Event describes a change to an object observed by the informer.
+type Event[T any] struct {
+	Type EventType
+	// Object after the change, or the last known state for deleted objects.
+	Object T
+	// Object before the change, only set for updates.
+	OldObject T
+	// True for updates caused by a periodic resync where the object has not changed.
+	Resync bool
+}
+
+type Config struct {
+	// Number of events buffered before the informer is blocked.
+	BufferSize int
+	// Drop updates caused by periodic resyncs.
+	IgnoreResync bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BufferSize:   100,
+		IgnoreResync: false,
+	}
+}
+
+// Adapter sends the changes observed by an informer to a channel, allowing them to be
+// processed with the channels package. The adapter runs the informer, which should not be
+// started by anyone else.
+type Adapter[T any] struct {
+	cfg      Config
+	informer cache.SharedIndexInformer
+	events   chan Event[T]
+
+	mu      sync.RWMutex
+	closed  bool
+	stopCh  chan struct{}
+	stopped sync.Once
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New returns an adapter for an informer of objects of type T, for example
+// factory.Core().V1().Pods().Informer() with *corev1.Pod. Objects of other types are ignored.
+func New[T any](informer cache.SharedIndexInformer, cfg Config) (*Adapter[T], error) {
+	a := &Adapter[T]{
+		cfg:      cfg,
+		informer: informer,
+		events:   make(chan Event[T], cfg.BufferSize),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			a.send(Added, obj, nil, false)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			resync := isResync(oldObj, newObj)
+			if resync && cfg.IgnoreResync {
+				return
+			}
+			a.send(Updated, newObj, oldObj, resync)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			a.send(Deleted, obj, nil, false)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Events returns the channel events are sent to, it is closed when the adapter stops.
+func (a *Adapter[T]) Events() <-chan Event[T] {
+	return a.events
+}
+
+// Start runs the informer until Stop is called or ctx is cancelled.
+func (a *Adapter[T]) Start(ctx context.Context) error {
+	a.mu.Lock()
+	if a.cancel != nil {
+		a.mu.Unlock()
+		return errors.New("adapter has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancel = cancel
+	a.mu.Unlock()
+	defer close(a.done)
+
+	go func() {
+		<-ctx.Done()
+		a.stopped.Do(func() {
+			close(a.stopCh)
+		})
+	}()
+	a.informer.Run(ctx.Done())
+
+	// Handlers blocked on a full channel hold the read lock until they see the stop channel.
+	a.mu.Lock()
+	a.closed = true
+	close(a.events)
+	a.mu.Unlock()
+	return nil
+}
+
+// Stop stops the informer and closes the events channel.
+func (a *Adapter[T]) Stop(ctx context.Context) error {
+	a.mu.RLock()
+	cancel := a.cancel
+	a.mu.RUnlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *Adapter[T]) send(eventType EventType, obj, oldObj interface{}, resync bool) {
+	object, ok := obj.(T)
+	if !ok {
+		return
+	}
+	event := Event[T]{
+		Type:   eventType,
+		Object: object,
+		Resync: resync,
+	}
+	if oldObj != nil {
+		if old, ok := oldObj.(T); ok {
+			event.OldObject = old
+		}
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.events <- event:
+	case <-a.stopCh:
+	}
+}
+
+func isResync(oldObj, newObj interface{}) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return false
+	}
+	return oldMeta.GetResourceVersion() == newMeta.GetResourceVersion()
+}
@@ -0,0 +1,95 @@
+package informer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAdapter(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", ResourceVersion: "1"},
+	})
+	factory := informers.NewSharedInformerFactory(client, 0)
+	a, err := New[*corev1.ConfigMap](factory.Core().V1().ConfigMaps().Informer(), DefaultConfig())
+	require.NoError(t, err)
+	errCh := make(chan error)
+	go func() {
+		errCh <- a.Start(context.Background())
+	}()
+
+	event := <-a.Events()
+	require.Equal(t, Added, event.Type)
+	require.Equal(t, "foo", event.Object.Name)
+
+	ctx := context.Background()
+	_, err = client.CoreV1().ConfigMaps("default").Update(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", ResourceVersion: "2"},
+		Data:       map[string]string{"key": "value"},
+	}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	event = <-a.Events()
+	require.Equal(t, Updated, event.Type)
+	require.False(t, event.Resync)
+	require.Equal(t, "value", event.Object.Data["key"])
+	require.Empty(t, event.OldObject.Data)
+
+	err = client.CoreV1().ConfigMaps("default").Delete(ctx, "foo", metav1.DeleteOptions{})
+	require.NoError(t, err)
+	event = <-a.Events()
+	require.Equal(t, Deleted, event.Type)
+	require.Equal(t, "foo", event.Object.Name)
+
+	err = a.Stop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	_, ok := <-a.Events()
+	require.False(t, ok)
+}
+
+func TestAdapterResync(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", ResourceVersion: "1"},
+	})
+	factory := informers.NewSharedInformerFactory(client, 10*time.Millisecond)
+	a, err := New[*corev1.ConfigMap](factory.Core().V1().ConfigMaps().Informer(), DefaultConfig())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.Start(ctx)
+
+	require.Equal(t, Added, (<-a.Events()).Type)
+	event := <-a.Events()
+	require.Equal(t, Updated, event.Type)
+	require.True(t, event.Resync)
+}
+
+func TestAdapterStopBlocked(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}},
+	)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	cfg := DefaultConfig()
+	cfg.BufferSize = 0
+	a, err := New[*corev1.ConfigMap](factory.Core().V1().ConfigMaps().Informer(), cfg)
+	require.NoError(t, err)
+	errCh := make(chan error)
+	go func() {
+		errCh <- a.Start(context.Background())
+	}()
+	<-a.Events()
+
+	// The handler is blocked sending the second event, which must not prevent stopping.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = a.Stop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+}
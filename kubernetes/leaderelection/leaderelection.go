@@ -0,0 +1,157 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	kubeleaderelection "k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+var ErrLeadershipLost = errors.New("leadership lost")
+
+type Config struct {
+	// Name of the Lease used as lock.
+	Name string
+	// Namespace of the Lease used as lock.
+	Namespace string
+	// Identity of the instance, defaults to the hostname.
+	Identity string
+	// Duration non leaders wait before attempting to acquire the lease.
+	LeaseDuration time.Duration
+	// Duration the leader retries renewing the lease before giving up.
+	RenewDeadline time.Duration
+	// Duration to wait between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+	// Called when the instance starts leading, the context is cancelled when leadership is lost.
+	OnStartedLeading func(ctx context.Context)
+	// Called when the instance stops leading.
+	OnStoppedLeading func()
+	// Called when a new leader is observed.
+	OnNewLeader func(identity string)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Name:             "",
+		Namespace:        "",
+		Identity:         "",
+		LeaseDuration:    15 * time.Second,
+		RenewDeadline:    10 * time.Second,
+		RetryPeriod:      2 * time.Second,
+		OnStartedLeading: nil,
+		OnStoppedLeading: nil,
+		OnNewLeader:      nil,
+	}
+}
+
+type LeaderElector struct {
+	elector *kubeleaderelection.LeaderElector
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopping bool
+}
+
+func NewLeaderElector(client kubernetes.Interface, cfg Config) (*LeaderElector, error) {
+	if cfg.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Identity = hostname
+	}
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, cfg.Namespace, cfg.Name, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: cfg.Identity})
+	if err != nil {
+		return nil, err
+	}
+	callbacks := kubeleaderelection.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context) {},
+		OnStoppedLeading: func() {},
+		OnNewLeader:      cfg.OnNewLeader,
+	}
+	if cfg.OnStartedLeading != nil {
+		callbacks.OnStartedLeading = cfg.OnStartedLeading
+	}
+	if cfg.OnStoppedLeading != nil {
+		callbacks.OnStoppedLeading = cfg.OnStoppedLeading
+	}
+	elector, err := kubeleaderelection.NewLeaderElector(kubeleaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		Callbacks:       callbacks,
+		ReleaseOnCancel: true,
+		Name:            cfg.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LeaderElector{
+		elector: elector,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Start runs leader election until Stop is called or ctx is cancelled. ErrLeadershipLost
+// is returned if leadership is lost while running.
+func (l *LeaderElector) Start(ctx context.Context) error {
+	l.mu.Lock()
+	if l.stopping {
+		l.mu.Unlock()
+		return nil
+	}
+	if l.cancel != nil {
+		l.mu.Unlock()
+		return errors.New("leader elector has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	l.cancel = cancel
+	l.mu.Unlock()
+
+	defer close(l.done)
+	l.elector.Run(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopping || ctx.Err() != nil {
+		return nil
+	}
+	cancel()
+	return ErrLeadershipLost
+}
+
+// Stop cancels leader election and waits for the lease to be released.
+func (l *LeaderElector) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	l.stopping = true
+	cancel := l.cancel
+	l.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsLeader returns true if the instance currently holds the lease.
+func (l *LeaderElector) IsLeader() bool {
+	return l.elector.IsLeader()
+}
+
+// GetLeader returns the identity of the last observed leader.
+func (l *LeaderElector) GetLeader() string {
+	return l.elector.GetLeader()
+}
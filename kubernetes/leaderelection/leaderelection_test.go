@@ -0,0 +1,58 @@
+package leaderelection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLeaderElector(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	cfg := DefaultConfig()
+	cfg.Name = "foo"
+	cfg.Namespace = "bar"
+	cfg.Identity = "baz"
+	cfg.LeaseDuration = time.Second
+	cfg.RenewDeadline = 500 * time.Millisecond
+	cfg.RetryPeriod = 100 * time.Millisecond
+	cfg.OnStartedLeading = func(ctx context.Context) {
+		close(started)
+	}
+	cfg.OnStoppedLeading = func() {
+		close(stopped)
+	}
+	le, err := NewLeaderElector(client, cfg)
+	require.NoError(t, err)
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- le.Start(context.Background())
+	}()
+	<-started
+	require.True(t, le.IsLeader())
+	require.Equal(t, "baz", le.GetLeader())
+
+	err = le.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	<-stopped
+
+	lease, err := client.CoordinationV1().Leases("bar").Get(context.Background(), "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, *lease.Spec.HolderIdentity)
+}
+
+func TestLeaderElectorInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Name = "foo"
+	cfg.Namespace = "bar"
+	cfg.RenewDeadline = cfg.LeaseDuration
+	_, err := NewLeaderElector(fake.NewSimpleClientset(), cfg)
+	require.EqualError(t, err, "leaseDuration must be greater than renewDeadline")
+}
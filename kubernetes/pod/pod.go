@@ -0,0 +1,163 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	pkgkubernetes "github.com/xenitab/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type Identity struct {
+	Name      string
+	Namespace string
+}
+
+// IdentityFromEnv detects the name and namespace of the running pod. The POD_NAME and
+// POD_NAMESPACE environment variables are expected to be set through the downward API,
+// falling back to the hostname and the service account namespace.
+func IdentityFromEnv() (Identity, error) {
+	name := os.Getenv("POD_NAME")
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return Identity{}, err
+		}
+		name = hostname
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		b, err := os.ReadFile(namespaceFile)
+		if err != nil {
+			return Identity{}, errors.New("could not detect pod namespace, POD_NAMESPACE is not set")
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+	return Identity{Name: name, Namespace: namespace}, nil
+}
+
+// Patcher updates the metadata and status of the running pod.
+type Patcher struct {
+	client   kubernetes.Interface
+	identity Identity
+}
+
+func NewPatcher(client kubernetes.Interface, identity Identity) *Patcher {
+	return &Patcher{
+		client:   client,
+		identity: identity,
+	}
+}
+
+// NewPatcherFromEnv creates a patcher for the running pod, using the kubeconfig path if set
+// or the in cluster configuration.
+func NewPatcherFromEnv(kubeconfigPath string) (*Patcher, error) {
+	identity, err := IdentityFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client, err := pkgkubernetes.GetKubernetesClientset(kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewPatcher(client, identity), nil
+}
+
+// SetAnnotations adds or updates the given annotations on the pod.
+func (p *Patcher) SetAnnotations(ctx context.Context, annotations map[string]string) error {
+	return p.patchMetadata(ctx, "annotations", toPatchValues(annotations))
+}
+
+// RemoveAnnotations removes the given annotation keys from the pod.
+func (p *Patcher) RemoveAnnotations(ctx context.Context, keys ...string) error {
+	return p.patchMetadata(ctx, "annotations", toRemoveValues(keys))
+}
+
+// SetLabels adds or updates the given labels on the pod.
+func (p *Patcher) SetLabels(ctx context.Context, labels map[string]string) error {
+	return p.patchMetadata(ctx, "labels", toPatchValues(labels))
+}
+
+// RemoveLabels removes the given label keys from the pod.
+func (p *Patcher) RemoveLabels(ctx context.Context, keys ...string) error {
+	return p.patchMetadata(ctx, "labels", toRemoveValues(keys))
+}
+
+// SetCondition sets the status of a pod condition, which can be used together with a
+// readiness gate to control the readiness of the pod.
+func (p *Patcher) SetCondition(ctx context.Context, conditionType corev1.PodConditionType, status corev1.ConditionStatus, reason, message string) error {
+	pod, err := p.client.CoreV1().Pods(p.identity.Namespace).Get(ctx, p.identity.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == status && condition.Reason == reason && condition.Message == message {
+			return nil
+		}
+	}
+
+	condition := corev1.PodCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.PodCondition{condition},
+		},
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CoreV1().Pods(p.identity.Namespace).Patch(ctx, p.identity.Name, types.StrategicMergePatchType, b, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Patcher) patchMetadata(ctx context.Context, field string, values map[string]*string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			field: values,
+		},
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = p.client.CoreV1().Pods(p.identity.Namespace).Patch(ctx, p.identity.Name, types.MergePatchType, b, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func toPatchValues(m map[string]string) map[string]*string {
+	values := map[string]*string{}
+	for k, v := range m {
+		v := v
+		values[k] = &v
+	}
+	return values
+}
+
+// toRemoveValues returns null values which remove the keys in a merge patch.
+func toRemoveValues(keys []string) map[string]*string {
+	values := map[string]*string{}
+	for _, k := range keys {
+		values[k] = nil
+	}
+	return values
+}
@@ -0,0 +1,48 @@
+package pod
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIdentityFromEnv(t *testing.T) {
+	t.Setenv("POD_NAME", "foo")
+	t.Setenv("POD_NAMESPACE", "bar")
+	identity, err := IdentityFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, Identity{Name: "foo", Namespace: "bar"}, identity)
+}
+
+func TestPatcher(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "bar",
+			Annotations: map[string]string{"existing": "true"},
+		},
+	})
+	patcher := NewPatcher(client, Identity{Name: "foo", Namespace: "bar"})
+
+	err := patcher.SetAnnotations(ctx, map[string]string{"version": "v1.0.0"})
+	require.NoError(t, err)
+	err = patcher.SetLabels(ctx, map[string]string{"leader": "true"})
+	require.NoError(t, err)
+	err = patcher.RemoveAnnotations(ctx, "existing")
+	require.NoError(t, err)
+	err = patcher.SetCondition(ctx, "example.com/leader", corev1.ConditionTrue, "Elected", "")
+	require.NoError(t, err)
+
+	pod, err := client.CoreV1().Pods("bar").Get(ctx, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"version": "v1.0.0"}, pod.Annotations)
+	require.Equal(t, map[string]string{"leader": "true"}, pod.Labels)
+	require.Len(t, pod.Status.Conditions, 1)
+	require.Equal(t, corev1.PodConditionType("example.com/leader"), pod.Status.Conditions[0].Type)
+	require.Equal(t, corev1.ConditionTrue, pod.Status.Conditions[0].Status)
+}
@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/zapr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// level is shared by all loggers so that verbosity can be changed at runtime.
+	level = zap.NewAtomicLevel()
+)
+
+type Config struct {
+	// Level to log at, either a level name or a logr verbosity like 2.
+	Level string
+	// Output format, either json or console.
+	Format string
+	// Number of entries with the same message logged each second before sampling starts, zero disables sampling.
+	SamplingInitial int
+	// Every nth entry logged after sampling has started.
+	SamplingThereafter int
+	// Writer to output logs to.
+	Output io.Writer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Level:              "info",
+		Format:             "json",
+		SamplingInitial:    100,
+		SamplingThereafter: 100,
+		Output:             os.Stderr,
+	}
+}
+
+// NewLogger returns a zap backed logger. All loggers share the same global level which
+// is set to the configured level.
+func NewLogger(cfg Config) (logr.Logger, error) {
+	err := SetLevel(cfg.Level)
+	if err != nil {
+		return logr.Discard(), err
+	}
+
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	case "console":
+		encoderCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderCfg)
+	default:
+		return logr.Discard(), fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	output := cfg.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	// The level is checked by levelCore so that it can be overridden for a single logger.
+	core := zapcore.NewCore(encoder, zapcore.AddSync(output), zap.LevelEnablerFunc(func(zapcore.Level) bool { return true }))
+	if cfg.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
+	}
+	return zapr.NewLogger(zap.New(&levelCore{Core: core})), nil
+}
+
+// WithVerbosity returns a logger which logs entries up to the logr verbosity v even if the
+// level of all loggers is less verbose, such as to debug a single request. Loggers not created
+// by NewLogger are returned unchanged.
+func WithVerbosity(log logr.Logger, v int) logr.Logger {
+	u, ok := log.GetSink().(zapr.Underlier)
+	if !ok {
+		return log
+	}
+	override := zapcore.Level(-v)
+	zl := u.GetUnderlying().WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		lc, ok := c.(*levelCore)
+		if !ok {
+			return c
+		}
+		return &levelCore{Core: lc.Core, override: &override}
+	}))
+	return zapr.NewLogger(zl)
+}
+
+// SetLevel changes the level of all loggers.
+func SetLevel(s string) error {
+	l, err := parseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// GetLevel returns the current level of all loggers.
+func GetLevel() string {
+	return level.String()
+}
+
+// LevelHandler returns a handler which reports the current level on GET requests and
+// changes it on PUT requests with a body like {"level":"debug"}.
+func LevelHandler() http.Handler {
+	return level
+}
+
+// ToggleDebugOnSignal switches between the debug level and the current level every time
+// one of the signals is received, until ctx is cancelled.
+func ToggleDebugOnSignal(ctx context.Context, sig ...os.Signal) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sig...)
+	go func() {
+		defer signal.Stop(sigCh)
+		previous := level.Level()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				current := level.Level()
+				if current > zapcore.DebugLevel {
+					previous = current
+					level.SetLevel(zapcore.DebugLevel)
+					continue
+				}
+				level.SetLevel(previous)
+			}
+		}
+	}()
+}
+
+// levelCore enables entries at the shared level or at the override level if set.
+type levelCore struct {
+	zapcore.Core
+	override *zapcore.Level
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	if c.override != nil && l >= *c.override {
+		return true
+	}
+	return level.Enabled(l)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), override: c.override}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func parseLevel(s string) (zapcore.Level, error) {
+	v, err := strconv.Atoi(s)
+	if err == nil {
+		if v < 0 {
+			return zapcore.InfoLevel, fmt.Errorf("verbosity %d cannot be negative", v)
+		}
+		// logr verbosity levels map to negative zap levels.
+		return zapcore.Level(-v), nil
+	}
+	return zapcore.ParseLevel(strings.ToLower(s))
+}
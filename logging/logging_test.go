@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Output = &buf
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	log.Info("hello world", "foo", "bar")
+	log.V(1).Info("hidden")
+	require.Contains(t, buf.String(), `"msg":"hello world","foo":"bar"`)
+	require.NotContains(t, buf.String(), "hidden")
+
+	require.NoError(t, SetLevel("1"))
+	log.V(1).Info("visible")
+	require.Contains(t, buf.String(), "visible")
+	require.Equal(t, "debug", GetLevel())
+}
+
+func TestNewLoggerInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Format = "xml"
+	_, err := NewLogger(cfg)
+	require.EqualError(t, err, "unknown log format \"xml\"")
+
+	cfg = DefaultConfig()
+	cfg.Level = "loud"
+	_, err = NewLogger(cfg)
+	require.Error(t, err)
+}
+
+func TestLevelHandler(t *testing.T) {
+	require.NoError(t, SetLevel("info"))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"error"}`))
+	LevelHandler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "error", GetLevel())
+}
+
+func TestToggleDebugOnSignal(t *testing.T) {
+	require.NoError(t, SetLevel("warn"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ToggleDebugOnSignal(ctx, syscall.SIGUSR1)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	require.Eventually(t, func() bool { return GetLevel() == "debug" }, time.Second, 10*time.Millisecond)
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	require.Eventually(t, func() bool { return GetLevel() == "warn" }, time.Second, 10*time.Millisecond)
+}
+
+func TestWithVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Output = &buf
+	log, err := NewLogger(cfg)
+	require.NoError(t, err)
+
+	debugLog := WithVerbosity(log.WithValues("foo", "bar"), 2)
+	debugLog.V(2).Info("visible")
+	debugLog.V(3).Info("hidden")
+	log.V(1).Info("hidden")
+	require.Contains(t, buf.String(), `"msg":"visible","foo":"bar"`)
+	require.NotContains(t, buf.String(), "hidden")
+
+	require.Equal(t, logr.Discard(), WithVerbosity(logr.Discard(), 2))
+}
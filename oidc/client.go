@@ -0,0 +1,306 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an access token obtained from the token endpoint.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// Time the token expires, zero if the token endpoint did not return an expiry.
+	Expiry time.Time
+}
+
+func (t Token) valid(now time.Time, refreshBefore time.Duration) bool {
+	if t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || now.Add(refreshBefore).Before(t.Expiry)
+}
+
+// TokenSource returns access tokens for outgoing requests.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// TokenError is an error response from the token endpoint.
+type TokenError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("token request failed with status code %d: %s", e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("token request failed with status code %d: %s: %s", e.StatusCode, e.Code, e.Description)
+}
+
+type ClientConfig struct {
+	// Issuer used to discover the token endpoint.
+	Issuer string
+	// Token endpoint, discovered from the issuer if empty.
+	TokenURL string
+	// Client ID and secret used to authenticate with the token endpoint.
+	ClientID     string
+	ClientSecret string
+	// Scopes requested, such as api://my-api/.default.
+	Scopes []string
+	// Duration before expiry tokens are refreshed.
+	RefreshBefore time.Duration
+	// HTTP client used for discovery and token requests.
+	HTTPClient *http.Client
+}
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Issuer:        "",
+		TokenURL:      "",
+		ClientID:      "",
+		ClientSecret:  "",
+		Scopes:        nil,
+		RefreshBefore: time.Minute,
+		HTTPClient:    http.DefaultClient,
+	}
+}
+
+type tokenClient struct {
+	cfg ClientConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	tokenURL string
+}
+
+func newTokenClient(cfg ClientConfig) (*tokenClient, error) {
+	if cfg.ClientID == "" {
+		return nil, errors.New("client id cannot be empty")
+	}
+	if cfg.Issuer == "" && cfg.TokenURL == "" {
+		return nil, errors.New("issuer or token url has to be set")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &tokenClient{
+		cfg:      cfg,
+		now:      time.Now,
+		tokenURL: cfg.TokenURL,
+	}, nil
+}
+
+func (c *tokenClient) endpoint(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokenURL != "" {
+		return c.tokenURL, nil
+	}
+	discovery, err := Discover(ctx, c.cfg.HTTPClient, c.cfg.Issuer)
+	if err != nil {
+		return "", err
+	}
+	if discovery.TokenEndpoint == "" {
+		return "", errors.New("discovery document does not contain a token endpoint")
+	}
+	c.tokenURL = discovery.TokenEndpoint
+	return c.tokenURL, nil
+}
+
+func (c *tokenClient) request(ctx context.Context, form url.Values) (Token, error) {
+	endpoint, err := c.endpoint(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	issuedAt := c.now()
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{StatusCode: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(tokenErr)
+		return Token{}, tokenErr
+	}
+	body := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return Token{}, fmt.Errorf("could not decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return Token{}, errors.New("token response does not contain an access token")
+	}
+	token := Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+	}
+	if body.ExpiresIn > 0 {
+		token.Expiry = issuedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// ClientCredentials obtains tokens for the client itself using the client credentials grant.
+type ClientCredentials struct {
+	client *tokenClient
+
+	mu    sync.Mutex
+	token Token
+}
+
+func NewClientCredentials(cfg ClientConfig) (*ClientCredentials, error) {
+	client, err := newTokenClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientCredentials{
+		client: client,
+	}, nil
+}
+
+// Token returns the cached token, a new token is requested when the cached token expires within
+// RefreshBefore. Concurrent calls share a single token request.
+func (s *ClientCredentials) Token(ctx context.Context) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.valid(s.client.now(), s.client.cfg.RefreshBefore) {
+		return s.token, nil
+	}
+	token, err := s.client.request(ctx, url.Values{"grant_type": []string{"client_credentials"}})
+	if err != nil {
+		return Token{}, err
+	}
+	s.token = token
+	return token, nil
+}
+
+type assertionKey struct{}
+
+// ContextWithAssertion returns a context with the token of the inbound request, which is
+// exchanged by OnBehalfOf for a token to call downstream services as the same user.
+func ContextWithAssertion(ctx context.Context, assertion string) context.Context {
+	return context.WithValue(ctx, assertionKey{}, assertion)
+}
+
+// AssertionFromContext returns the token of the inbound request, empty if not set.
+func AssertionFromContext(ctx context.Context) string {
+	assertion, _ := ctx.Value(assertionKey{}).(string)
+	return assertion
+}
+
+// OnBehalfOf exchanges the token of the inbound request for a token to call downstream services
+// as the same user, using the on-behalf-of flow supported by Azure AD. Tokens are cached per
+// inbound token.
+type OnBehalfOf struct {
+	client *tokenClient
+
+	mu     sync.Mutex
+	tokens map[[sha256.Size]byte]Token
+}
+
+func NewOnBehalfOf(cfg ClientConfig) (*OnBehalfOf, error) {
+	client, err := newTokenClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &OnBehalfOf{
+		client: client,
+		tokens: map[[sha256.Size]byte]Token{},
+	}, nil
+}
+
+// Token exchanges the assertion in the context, an error is returned if the context does not
+// contain an assertion.
+func (s *OnBehalfOf) Token(ctx context.Context) (Token, error) {
+	assertion := AssertionFromContext(ctx)
+	if assertion == "" {
+		return Token{}, errors.New("context does not contain an assertion")
+	}
+	key := sha256.Sum256([]byte(assertion))
+	now := s.client.now()
+
+	s.mu.Lock()
+	token, ok := s.tokens[key]
+	s.mu.Unlock()
+	if ok && token.valid(now, s.client.cfg.RefreshBefore) {
+		return token, nil
+	}
+
+	token, err := s.client.request(ctx, url.Values{
+		"grant_type":          []string{"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":           []string{assertion},
+		"requested_token_use": []string{"on_behalf_of"},
+	})
+	if err != nil {
+		return Token{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, t := range s.tokens {
+		if !t.valid(now, 0) {
+			delete(s.tokens, k)
+		}
+	}
+	s.tokens[key] = token
+	return token, nil
+}
+
+type transport struct {
+	source TokenSource
+	next   http.RoundTripper
+}
+
+// NewTransport returns a round tripper which sets a bearer token from the source on outgoing
+// requests. Requests which already have an Authorization header are sent unchanged.
+func NewTransport(source TokenSource, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{
+		source: source,
+		next:   next,
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.next.RoundTrip(req)
+	}
+	token, err := t.source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	// Round trippers should not modify the request.
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,170 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	t.Helper()
+	calls := int32(0)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:        srv.URL,
+			TokenEndpoint: srv.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		subject := "client"
+		switch r.PostFormValue("grant_type") {
+		case "client_credentials":
+		case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+			if r.PostFormValue("requested_token_use") != "on_behalf_of" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			subject = r.PostFormValue("assertion")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("%s-%s-%d", subject, r.PostFormValue("scope"), n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	})
+	return srv, &calls
+}
+
+func TestClientCredentials(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600)
+	cfg := DefaultClientConfig()
+	cfg.Issuer = srv.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+	cfg.Scopes = []string{"api"}
+	source, err := NewClientCredentials(cfg)
+	require.NoError(t, err)
+
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "client-api-1", token.AccessToken)
+	require.Equal(t, "Bearer", token.TokenType)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "client-api-1", token.AccessToken)
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// The token is refreshed before it expires.
+	source.client.now = func() time.Time {
+		return time.Now().Add(time.Hour - 30*time.Second)
+	}
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "client-api-2", token.AccessToken)
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	srv, _ := newTokenServer(t, 3600)
+	cfg := DefaultClientConfig()
+	cfg.TokenURL = srv.URL + "/token"
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "wrong"
+	source, err := NewClientCredentials(cfg)
+	require.NoError(t, err)
+	_, err = source.Token(context.Background())
+	tokenErr := &TokenError{}
+	require.ErrorAs(t, err, &tokenErr)
+	require.Equal(t, http.StatusUnauthorized, tokenErr.StatusCode)
+	require.Equal(t, "invalid_client", tokenErr.Code)
+
+	_, err = NewClientCredentials(DefaultClientConfig())
+	require.EqualError(t, err, "client id cannot be empty")
+	cfg = DefaultClientConfig()
+	cfg.ClientID = "client"
+	_, err = NewClientCredentials(cfg)
+	require.EqualError(t, err, "issuer or token url has to be set")
+}
+
+func TestOnBehalfOf(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600)
+	cfg := DefaultClientConfig()
+	cfg.Issuer = srv.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+	source, err := NewOnBehalfOf(cfg)
+	require.NoError(t, err)
+
+	_, err = source.Token(context.Background())
+	require.EqualError(t, err, "context does not contain an assertion")
+
+	alice := ContextWithAssertion(context.Background(), "alice")
+	bob := ContextWithAssertion(context.Background(), "bob")
+	token, err := source.Token(alice)
+	require.NoError(t, err)
+	require.Equal(t, "alice--1", token.AccessToken)
+	token, err = source.Token(bob)
+	require.NoError(t, err)
+	require.Equal(t, "bob--2", token.AccessToken)
+	token, err = source.Token(alice)
+	require.NoError(t, err)
+	require.Equal(t, "alice--1", token.AccessToken)
+	require.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestTransport(t *testing.T) {
+	srv, _ := newTokenServer(t, 3600)
+	cfg := DefaultClientConfig()
+	cfg.Issuer = srv.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+	source, err := NewClientCredentials(cfg)
+	require.NoError(t, err)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer api.Close()
+	client := &http.Client{Transport: NewTransport(source, nil)}
+
+	req, err := http.NewRequest(http.MethodGet, api.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	require.Equal(t, "Bearer client--1", string(body[:n]))
+	require.Empty(t, req.Header.Get("Authorization"))
+
+	req, err = http.NewRequest(http.MethodGet, api.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Basic foo")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	n, _ = resp.Body.Read(body)
+	require.Equal(t, "Basic foo", string(body[:n]))
+}
@@ -0,0 +1,51 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Discovery is the subset of the OpenID provider metadata used by this package.
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	EndSessionEndpoint    string   `json:"end_session_endpoint"`
+	ScopesSupported       []string `json:"scopes_supported"`
+	GrantTypesSupported   []string `json:"grant_types_supported"`
+}
+
+// Discover fetches the provider metadata from the well-known discovery endpoint of the issuer.
+// An error is returned if the issuer in the document does not match.
+func Discover(ctx context.Context, client *http.Client, issuer string) (Discovery, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Discovery{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Discovery{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Discovery{}, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+	discovery := Discovery{}
+	err = json.NewDecoder(resp.Body).Decode(&discovery)
+	if err != nil {
+		return Discovery{}, fmt.Errorf("could not decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return Discovery{}, fmt.Errorf("issuer %s in discovery document does not match %s", discovery.Issuer, issuer)
+	}
+	return discovery, nil
+}
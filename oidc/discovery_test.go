@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	issuer := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/.well-known/openid-configuration", r.URL.Path)
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:        issuer,
+			TokenEndpoint: issuer + "/token",
+		})
+	}))
+	defer srv.Close()
+
+	issuer = srv.URL
+	discovery, err := Discover(context.Background(), nil, srv.URL+"/")
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/token", discovery.TokenEndpoint)
+
+	issuer = "https://example.com"
+	_, err = Discover(context.Background(), nil, srv.URL)
+	require.EqualError(t, err, "issuer https://example.com in discovery document does not match "+srv.URL)
+}
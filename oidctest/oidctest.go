@@ -0,0 +1,338 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	DiscoveryPath = "/.well-known/openid-configuration"
+	JWKSPath      = "/jwks"
+)
+
+type Config struct {
+	// Algorithm used to sign tokens unless overridden, one of RS256, RS384, RS512, ES256, ES384 or ES512.
+	Algorithm string
+	// Key ID of the default signing key, keys of other algorithms use the key ID suffixed with the algorithm.
+	KeyID string
+	// Audience added to tokens which do not set the aud claim, not added if empty.
+	Audience string
+	// Lifetime of tokens which do not set the exp claim.
+	TokenLifetime time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Algorithm:     "RS256",
+		KeyID:         "test",
+		Audience:      "",
+		TokenLifetime: time.Hour,
+	}
+}
+
+// TokenConfig overrides how a single token is signed.
+type TokenConfig struct {
+	// Claims of the token, iss, iat and exp are added unless set.
+	Claims jwt.MapClaims
+	// Signing algorithm, the default algorithm is used if empty. The algorithm none creates an
+	// unsigned token.
+	Algorithm string
+	// Key ID set in the header, the key ID of the signing key is used if empty. Setting a key ID
+	// which is not in the key set allows testing unknown keys.
+	KeyID string
+	// Omit the key ID from the header.
+	OmitKeyID bool
+}
+
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+// Server is a local OpenID provider serving a discovery document and a key set, which mints
+// tokens for testing handlers behind OIDC authentication.
+type Server struct {
+	cfg    Config
+	server *httptest.Server
+
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	retired    []*signingKey
+	generation int
+}
+
+// NewServer generates signing keys and starts the server, which has to be closed with Close.
+func NewServer(cfg Config) (*Server, error) {
+	s := &Server{
+		cfg: cfg,
+	}
+	err := s.generateKeys()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.keys[cfg.Algorithm]; !ok {
+		return nil, fmt.Errorf("unsupported algorithm %s", cfg.Algorithm)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, s.discovery)
+	mux.HandleFunc(JWKSPath, s.jwks)
+	s.server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Issuer returns the issuer URL, which is also the base URL of the server.
+func (s *Server) Issuer() string {
+	return s.server.URL
+}
+
+// Client returns a HTTP client for the server.
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Token returns a token with the given claims signed with the default algorithm.
+func (s *Server) Token(claims jwt.MapClaims) (string, error) {
+	return s.Sign(TokenConfig{Claims: claims})
+}
+
+// Sign returns a token signed according to cfg.
+func (s *Server) Sign(cfg TokenConfig) (string, error) {
+	claims := jwt.MapClaims{}
+	for k, v := range cfg.Claims {
+		claims[k] = v
+	}
+	now := time.Now()
+	setDefault(claims, "iss", s.Issuer())
+	setDefault(claims, "iat", now.Unix())
+	setDefault(claims, "exp", now.Add(s.cfg.TokenLifetime).Unix())
+	if s.cfg.Audience != "" {
+		setDefault(claims, "aud", s.cfg.Audience)
+	}
+
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = s.cfg.Algorithm
+	}
+	if alg == jwt.SigningMethodNone.Alg() {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+		if cfg.KeyID != "" && !cfg.OmitKeyID {
+			token.Header["kid"] = cfg.KeyID
+		}
+		return token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	}
+
+	s.mu.RLock()
+	key, ok := s.keys[alg]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	if cfg.KeyID != "" {
+		token.Header["kid"] = cfg.KeyID
+	}
+	if cfg.OmitKeyID {
+		delete(token.Header, "kid")
+	}
+	return token.SignedString(key.key)
+}
+
+// RotateKeys replaces the signing keys. The previous keys remain in the key set so that tokens
+// signed before the rotation stay valid.
+func (s *Server) RotateKeys() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.keys
+	err := s.generateKeysLocked(fmt.Sprintf("%s-%d", s.cfg.KeyID, s.generation+1))
+	if err != nil {
+		return err
+	}
+	s.generation++
+	for _, key := range previous {
+		s.retired = append(s.retired, key)
+	}
+	return nil
+}
+
+func (s *Server) generateKeys() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generateKeysLocked(s.cfg.KeyID)
+}
+
+func (s *Server) generateKeysLocked(kid string) error {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	keys := map[string]*signingKey{
+		"RS256": {method: jwt.SigningMethodRS256, key: rsaKey},
+		"RS384": {method: jwt.SigningMethodRS384, key: rsaKey},
+		"RS512": {method: jwt.SigningMethodRS512, key: rsaKey},
+	}
+	curves := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}
+	for alg, curve := range curves {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return err
+		}
+		keys[alg] = &signingKey{method: jwt.GetSigningMethod(alg), key: ecKey}
+	}
+	for alg, key := range keys {
+		key.kid = kid + "-" + alg
+		if alg == s.cfg.Algorithm {
+			key.kid = kid
+		}
+	}
+	s.keys = keys
+	return nil
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	algs := []string{}
+	for alg := range s.keys {
+		algs = append(algs, alg)
+	}
+	s.mu.RUnlock()
+	sort.Strings(algs)
+	// The authorization and token endpoints are required by the specification but not served.
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                s.Issuer(),
+		"jwks_uri":                              s.Issuer() + JWKSPath,
+		"authorization_endpoint":                s.Issuer() + "/authorize",
+		"token_endpoint":                        s.Issuer() + "/token",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": algs,
+	})
+}
+
+// JWK is a public key in the key set.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// PublicKey returns the RSA or ECDSA public key.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.KeyType)
+	}
+}
+
+// JWKS is the key set served by the server.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet returns the public keys served by the server, including keys retired by RotateKeys.
+func (s *Server) KeySet() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := []*signingKey{}
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	keys = append(keys, s.retired...)
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, toJWK(key))
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID
+	})
+	return jwks
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.KeySet())
+}
+
+func toJWK(key *signingKey) JWK {
+	jwk := JWK{
+		KeyID:     key.kid,
+		Algorithm: key.method.Alg(),
+		Use:       "sig",
+	}
+	switch pub := key.key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	}
+	return jwk
+}
+
+func setDefault(claims jwt.MapClaims, key string, value interface{}) {
+	if _, ok := claims[key]; !ok {
+		claims[key] = value
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
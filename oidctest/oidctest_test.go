@@ -0,0 +1,119 @@
+package oidctest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func keyFunc(t *testing.T, s *Server) jwt.Keyfunc {
+	t.Helper()
+	resp, err := s.Client().Get(s.Issuer() + JWKSPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	jwks := JWKS{}
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	require.NoError(t, err)
+	return func(token *jwt.Token) (interface{}, error) {
+		for _, key := range jwks.Keys {
+			if key.KeyID == token.Header["kid"] && key.Algorithm == token.Method.Alg() {
+				return key.PublicKey()
+			}
+		}
+		return nil, errors.New("key not found")
+	}
+}
+
+func TestServer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Audience = "api"
+	s, err := NewServer(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.Issuer() + DiscoveryPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	discovery := map[string]interface{}{}
+	err = json.NewDecoder(resp.Body).Decode(&discovery)
+	require.NoError(t, err)
+	require.Equal(t, s.Issuer(), discovery["issuer"])
+	require.Equal(t, s.Issuer()+JWKSPath, discovery["jwks_uri"])
+
+	token, err := s.Token(jwt.MapClaims{"sub": "foo"})
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, keyFunc(t, s))
+	require.NoError(t, err)
+	require.Equal(t, "test", parsed.Header["kid"])
+	require.Equal(t, "foo", claims["sub"])
+	require.Equal(t, s.Issuer(), claims["iss"])
+	require.Equal(t, "api", claims["aud"])
+}
+
+func TestServerSign(t *testing.T) {
+	s, err := NewServer(DefaultConfig())
+	require.NoError(t, err)
+	defer s.Close()
+
+	for _, alg := range []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"} {
+		t.Run(alg, func(t *testing.T) {
+			token, err := s.Sign(TokenConfig{Algorithm: alg, Claims: jwt.MapClaims{"sub": "foo"}})
+			require.NoError(t, err)
+			parsed, err := jwt.Parse(token, keyFunc(t, s))
+			require.NoError(t, err)
+			require.Equal(t, alg, parsed.Method.Alg())
+		})
+	}
+
+	token, err := s.Sign(TokenConfig{KeyID: "unknown"})
+	require.NoError(t, err)
+	_, err = jwt.Parse(token, keyFunc(t, s))
+	require.ErrorContains(t, err, "key not found")
+
+	token, err = s.Sign(TokenConfig{Claims: jwt.MapClaims{"exp": 1}})
+	require.NoError(t, err)
+	_, err = jwt.Parse(token, keyFunc(t, s))
+	require.ErrorIs(t, err, jwt.ErrTokenExpired)
+
+	token, err = s.Sign(TokenConfig{Algorithm: "none"})
+	require.NoError(t, err)
+	parsed, _ := jwt.Parse(token, keyFunc(t, s))
+	require.Equal(t, "none", parsed.Method.Alg())
+
+	_, err = s.Sign(TokenConfig{Algorithm: "HS256"})
+	require.EqualError(t, err, "unsupported algorithm HS256")
+}
+
+func TestServerRotateKeys(t *testing.T) {
+	s, err := NewServer(DefaultConfig())
+	require.NoError(t, err)
+	defer s.Close()
+
+	before, err := s.Token(nil)
+	require.NoError(t, err)
+	err = s.RotateKeys()
+	require.NoError(t, err)
+	after, err := s.Token(nil)
+	require.NoError(t, err)
+
+	keyFunc := keyFunc(t, s)
+	parsed, err := jwt.Parse(before, keyFunc)
+	require.NoError(t, err)
+	require.Equal(t, "test", parsed.Header["kid"])
+	parsed, err = jwt.Parse(after, keyFunc)
+	require.NoError(t, err)
+	require.Equal(t, "test-1", parsed.Header["kid"])
+}
+
+func TestNewServerUnsupportedAlgorithm(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Algorithm = "HS256"
+	_, err := NewServer(cfg)
+	require.EqualError(t, err, "unsupported algorithm HS256")
+}
@@ -0,0 +1,249 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/xenitab/pkg/channels"
+)
+
+// ErrClosed is returned when publishing to a closed topic.
+var ErrClosed = errors.New("topic is closed")
+
+// SlowSubscriberPolicy decides what happens when a subscriber buffer is full.
+type SlowSubscriberPolicy int
+
+const (
+	// Block waits for the subscriber to receive the value, slowing down the publisher.
+	Block SlowSubscriberPolicy = iota
+	// DropNewest discards the value being published.
+	DropNewest
+	// DropOldest discards the oldest buffered value to make room for the value being published.
+	DropOldest
+	// Disconnect removes the subscriber and closes its channel.
+	Disconnect
+)
+
+func (p SlowSubscriberPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+type SubscribeConfig struct {
+	// Number of values buffered for the subscriber. DropOldest requires a buffer of at least one.
+	BufferSize int
+	// What to do when the buffer is full.
+	Policy SlowSubscriberPolicy
+}
+
+func DefaultSubscribeConfig() SubscribeConfig {
+	return SubscribeConfig{
+		BufferSize: 16,
+		Policy:     Block,
+	}
+}
+
+type subscriber[T any] struct {
+	ch       chan T
+	policy   SlowSubscriberPolicy
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func (s *subscriber[T]) stop() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Topic delivers every published value to all current subscribers.
+type Topic[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber[T]]struct{}
+	closed      bool
+	// done is closed before Close takes the lock, so that publishers blocked on a subscriber
+	// while holding the read lock return.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewTopic[T any]() *Topic[T] {
+	return &Topic[T]{
+		subscribers: map[*subscriber[T]]struct{}{},
+		done:        make(chan struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving values published after the call. The channel is closed
+// when ctx is cancelled, when the topic is closed or when the subscriber is disconnected for
+// being slow.
+func (t *Topic[T]) Subscribe(ctx context.Context, cfg SubscribeConfig) <-chan T {
+	bufferSize := cfg.BufferSize
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	if cfg.Policy == DropOldest && bufferSize < 1 {
+		bufferSize = 1
+	}
+	sub := &subscriber[T]{
+		ch:     make(chan T, bufferSize),
+		policy: cfg.Policy,
+		done:   make(chan struct{}),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		close(sub.ch)
+		return sub.ch
+	}
+	t.subscribers[sub] = struct{}{}
+	go func() {
+		select {
+		case <-ctx.Done():
+			t.unsubscribe(sub)
+		case <-sub.done:
+		}
+	}()
+	return sub.ch
+}
+
+// Publish sends the value to all subscribers. It only blocks for subscribers with the Block
+// policy, in which case ctx can be used to give up waiting. ErrClosed is returned if the topic
+// is closed while waiting.
+func (t *Topic[T]) Publish(ctx context.Context, v T) error {
+	t.mu.RLock()
+	if t.closed {
+		t.mu.RUnlock()
+		return ErrClosed
+	}
+	disconnected := []*subscriber[T]{}
+	for sub := range t.subscribers {
+		ok, err := t.send(ctx, sub, v)
+		if err != nil {
+			t.mu.RUnlock()
+			return err
+		}
+		if !ok {
+			disconnected = append(disconnected, sub)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, sub := range disconnected {
+		t.unsubscribe(sub)
+	}
+	return nil
+}
+
+// PublishFrom publishes all values received from in until it is closed or ctx is cancelled.
+func (t *Topic[T]) PublishFrom(ctx context.Context, in <-chan T) error {
+	// Cancelling stops the OrDone goroutine when returning early on an error.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for v := range channels.OrDone(ctx, in) {
+		err := t.Publish(ctx, v)
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// Subscribers returns the number of current subscribers.
+func (t *Topic[T]) Subscribers() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.subscribers)
+}
+
+// Close closes the channels of all subscribers, publishing after Close returns ErrClosed.
+func (t *Topic[T]) Close() {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	for sub := range t.subscribers {
+		sub.stop()
+		close(sub.ch)
+		delete(t.subscribers, sub)
+	}
+}
+
+// send has to be called with the read lock held, false is returned if the subscriber should be
+// disconnected.
+func (t *Topic[T]) send(ctx context.Context, sub *subscriber[T], v T) (bool, error) {
+	select {
+	case <-sub.done:
+		return true, nil
+	default:
+	}
+
+	switch sub.policy {
+	case DropNewest:
+		select {
+		case sub.ch <- v:
+		default:
+		}
+		return true, nil
+	case DropOldest:
+		for {
+			select {
+			case sub.ch <- v:
+				return true, nil
+			default:
+			}
+			select {
+			case <-sub.ch:
+			default:
+			}
+		}
+	case Disconnect:
+		select {
+		case sub.ch <- v:
+			return true, nil
+		default:
+			sub.stop()
+			return false, nil
+		}
+	default:
+		select {
+		case sub.ch <- v:
+			return true, nil
+		case <-sub.done:
+			return true, nil
+		case <-t.done:
+			return false, ErrClosed
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+func (t *Topic[T]) unsubscribe(sub *subscriber[T]) {
+	// Stopping before taking the lock releases any publisher blocked on the subscriber.
+	sub.stop()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.subscribers[sub]; !ok {
+		return
+	}
+	delete(t.subscribers, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,146 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/channels"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[int]()
+	a := topic.Subscribe(ctx, DefaultSubscribeConfig())
+	b := topic.Subscribe(ctx, DefaultSubscribeConfig())
+	require.Equal(t, 2, topic.Subscribers())
+
+	for i := 1; i <= 3; i++ {
+		err := topic.Publish(ctx, i)
+		require.NoError(t, err)
+	}
+	topic.Close()
+
+	require.Equal(t, []int{1, 2, 3}, channels.ToSlice(ctx, a))
+	require.Equal(t, []int{1, 2, 3}, channels.ToSlice(ctx, b))
+	require.Equal(t, 0, topic.Subscribers())
+	err := topic.Publish(ctx, 4)
+	require.ErrorIs(t, err, ErrClosed)
+	_, ok := <-topic.Subscribe(ctx, DefaultSubscribeConfig())
+	require.False(t, ok)
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	topic := NewTopic[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := topic.Subscribe(ctx, DefaultSubscribeConfig())
+	cancel()
+
+	_, ok := <-sub
+	require.False(t, ok)
+	require.Equal(t, 0, topic.Subscribers())
+	err := topic.Publish(context.Background(), 1)
+	require.NoError(t, err)
+}
+
+func TestBlock(t *testing.T) {
+	topic := NewTopic[int]()
+	sub := topic.Subscribe(context.Background(), SubscribeConfig{BufferSize: 1, Policy: Block})
+	err := topic.Publish(context.Background(), 1)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = topic.Publish(ctx, 2)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, <-sub)
+}
+
+func TestBlockUnsubscribe(t *testing.T) {
+	topic := NewTopic[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	topic.Subscribe(ctx, SubscribeConfig{BufferSize: 0, Policy: Block})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- topic.Publish(context.Background(), 1)
+	}()
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestCloseWhileBlocked(t *testing.T) {
+	topic := NewTopic[int]()
+	sub := topic.Subscribe(context.Background(), SubscribeConfig{BufferSize: 0, Policy: Block})
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- topic.Publish(context.Background(), 1)
+	}()
+	// Wait for the publisher to block on the subscriber while holding the read lock.
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		topic.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close did not return while a publisher was blocked")
+	}
+	require.ErrorIs(t, <-errCh, ErrClosed)
+	_, ok := <-sub
+	require.False(t, ok)
+}
+
+func TestDropNewest(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[int]()
+	sub := topic.Subscribe(ctx, SubscribeConfig{BufferSize: 2, Policy: DropNewest})
+	for i := 1; i <= 4; i++ {
+		err := topic.Publish(ctx, i)
+		require.NoError(t, err)
+	}
+	topic.Close()
+	require.Equal(t, []int{1, 2}, channels.ToSlice(ctx, sub))
+}
+
+func TestDropOldest(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[int]()
+	sub := topic.Subscribe(ctx, SubscribeConfig{BufferSize: 2, Policy: DropOldest})
+	for i := 1; i <= 4; i++ {
+		err := topic.Publish(ctx, i)
+		require.NoError(t, err)
+	}
+	topic.Close()
+	require.Equal(t, []int{3, 4}, channels.ToSlice(ctx, sub))
+}
+
+func TestDisconnect(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[int]()
+	slow := topic.Subscribe(ctx, SubscribeConfig{BufferSize: 1, Policy: Disconnect})
+	other := topic.Subscribe(ctx, DefaultSubscribeConfig())
+	for i := 1; i <= 3; i++ {
+		err := topic.Publish(ctx, i)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, topic.Subscribers())
+	require.Equal(t, []int{1}, channels.ToSlice(ctx, slow))
+	topic.Close()
+	require.Equal(t, []int{1, 2, 3}, channels.ToSlice(ctx, other))
+}
+
+func TestPublishFrom(t *testing.T) {
+	ctx := context.Background()
+	topic := NewTopic[string]()
+	sub := topic.Subscribe(ctx, DefaultSubscribeConfig())
+	err := topic.PublishFrom(ctx, channels.FromSlice(ctx, []string{"foo", "bar"}))
+	require.NoError(t, err)
+	topic.Close()
+	require.Equal(t, []string{"foo", "bar"}, channels.ToSlice(ctx, sub))
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryConfig struct {
+	// Limit applied to each key.
+	Limit Limit
+	// Maximum number of keys tracked, the least recently used key is forgotten when exceeded
+	// which resets its limit. Zero disables the limit.
+	MaxKeys int
+}
+
+func DefaultMemoryConfig() MemoryConfig {
+	return MemoryConfig{
+		Limit:   DefaultLimit(),
+		MaxKeys: 10000,
+	}
+}
+
+type memoryState struct {
+	key       string
+	tat       time.Time
+	window    window
+	expiresAt time.Time
+}
+
+// MemoryLimiter keeps the state of each key in memory, so limits are not shared between replicas.
+type MemoryLimiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu      sync.Mutex
+	maxKeys int
+	states  map[string]*list.Element
+	lru     *list.List
+}
+
+func NewMemoryLimiter(cfg MemoryConfig) (*MemoryLimiter, error) {
+	if err := cfg.Limit.Validate(); err != nil {
+		return nil, err
+	}
+	return &MemoryLimiter{
+		limit:   cfg.Limit,
+		now:     time.Now,
+		maxKeys: cfg.MaxKeys,
+		states:  map[string]*list.Element{},
+		lru:     list.New(),
+	}, nil
+}
+
+func (l *MemoryLimiter) AllowN(ctx context.Context, key string, n int) (Result, error) {
+	if n > l.limit.Capacity() {
+		return Result{}, ErrExceedsCapacity
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	state := l.get(key, now)
+	// States are removed once they no longer limit events.
+	if l.limit.Algorithm == SlidingWindow {
+		res, w := slideWindow(l.limit, state.window, now, n)
+		if res.Allowed {
+			l.set(memoryState{key: key, window: w, expiresAt: now.Add(2 * l.limit.Period)})
+		}
+		return res, nil
+	}
+	res, tat := takeTokens(l.limit, state.tat, now, n)
+	if res.Allowed && tat.After(now) {
+		l.set(memoryState{key: key, tat: tat, expiresAt: tat})
+	}
+	return res, nil
+}
+
+// get returns the state of the key, or the zero state if it does not exist or has expired. It
+// has to be called with the lock held.
+func (l *MemoryLimiter) get(key string, now time.Time) memoryState {
+	elem, ok := l.states[key]
+	if !ok {
+		return memoryState{}
+	}
+	state := elem.Value.(*memoryState)
+	if !now.Before(state.expiresAt) {
+		l.lru.Remove(elem)
+		delete(l.states, key)
+		return memoryState{}
+	}
+	l.lru.MoveToFront(elem)
+	return *state
+}
+
+// set stores the state, evicting the least recently used keys when there are more than
+// MaxKeys. It has to be called with the lock held.
+func (l *MemoryLimiter) set(state memoryState) {
+	if elem, ok := l.states[state.key]; ok {
+		*elem.Value.(*memoryState) = state
+		l.lru.MoveToFront(elem)
+		return
+	}
+	l.states[state.key] = l.lru.PushFront(&state)
+	for l.maxKeys > 0 && l.lru.Len() > l.maxKeys {
+		elem := l.lru.Back()
+		l.lru.Remove(elem)
+		delete(l.states, elem.Value.(*memoryState).key)
+	}
+}
@@ -0,0 +1,188 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrExceedsCapacity is returned when more events are requested at once than the limit can ever allow.
+var ErrExceedsCapacity = errors.New("events exceed the capacity of the limit")
+
+// Algorithm used to limit events.
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts of up to Burst events and refills Rate events per period, evenly
+	// spaced over the period.
+	TokenBucket Algorithm = "token-bucket"
+	// SlidingWindow allows Rate events in any period, approximated by weighting the count of the
+	// previous fixed window by how much of it overlaps the sliding window.
+	SlidingWindow Algorithm = "sliding-window"
+)
+
+type Limit struct {
+	// Algorithm used to limit events, TokenBucket if empty.
+	Algorithm Algorithm
+	// Number of events allowed per period.
+	Rate int
+	// Duration of the period.
+	Period time.Duration
+	// Maximum number of events allowed at once by the token bucket, values below one are treated
+	// as one. The sliding window allows Rate events at once.
+	Burst int
+}
+
+func DefaultLimit() Limit {
+	return Limit{
+		Algorithm: TokenBucket,
+		Rate:      100,
+		Period:    time.Second,
+		Burst:     100,
+	}
+}
+
+// Validate returns an error if the limit cannot be used.
+func (l Limit) Validate() error {
+	switch l.Algorithm {
+	case "", TokenBucket, SlidingWindow:
+	default:
+		return fmt.Errorf("unknown algorithm %q", l.Algorithm)
+	}
+	if l.Rate < 1 {
+		return errors.New("rate has to be larger than zero")
+	}
+	if l.Period <= 0 {
+		return errors.New("period has to be larger than zero")
+	}
+	if l.Interval() == 0 {
+		return errors.New("rate cannot be larger than the period in nanoseconds")
+	}
+	return nil
+}
+
+// Capacity returns the maximum number of events allowed at once.
+func (l Limit) Capacity() int {
+	if l.Algorithm == SlidingWindow {
+		return l.Rate
+	}
+	if l.Burst < 1 {
+		return 1
+	}
+	return l.Burst
+}
+
+// Interval returns the duration between evenly spaced events.
+func (l Limit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+type Result struct {
+	// Allowed is true if the events were allowed and recorded.
+	Allowed bool
+	// Remaining number of events allowed right now.
+	Remaining int
+	// Duration until the events would be allowed, zero if they were allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter limits the rate of events per key, such as requests per client IP. Implementations
+// have to be safe for concurrent use.
+type Limiter interface {
+	// AllowN reports whether n events for the key may happen now and records them if they are
+	// allowed. ErrExceedsCapacity is returned if n is larger than the capacity of the limit.
+	AllowN(ctx context.Context, key string, n int) (Result, error)
+}
+
+// Wait blocks until an event for the key is allowed or ctx is cancelled.
+func Wait(ctx context.Context, l Limiter, key string) error {
+	return WaitN(ctx, l, key, 1)
+}
+
+// WaitN blocks until n events for the key are allowed or ctx is cancelled.
+func WaitN(ctx context.Context, l Limiter, key string, n int) error {
+	for {
+		res, err := l.AllowN(ctx, key, n)
+		if err != nil {
+			return err
+		}
+		if res.Allowed {
+			return nil
+		}
+		timer := time.NewTimer(res.RetryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// takeTokens returns the result of taking n tokens from the bucket and the new theoretical
+// arrival time of the next event, which is unchanged if the tokens are not allowed.
+func takeTokens(l Limit, tat, now time.Time, n int) (Result, time.Time) {
+	interval := l.Interval()
+	if tat.Before(now) {
+		tat = now
+	}
+	limit := interval * time.Duration(l.Capacity())
+	next := tat.Add(interval * time.Duration(n))
+	if diff := next.Sub(now); diff > limit {
+		return Result{
+			Remaining:  int((limit - tat.Sub(now)) / interval),
+			RetryAfter: diff - limit,
+		}, tat
+	}
+	return Result{
+		Allowed:   true,
+		Remaining: int((limit - next.Sub(now)) / interval),
+	}, next
+}
+
+// window contains the event counts of the current and previous fixed windows.
+type window struct {
+	index int64
+	curr  int
+	prev  int
+}
+
+// slideWindow returns the result of adding n events to the window and the updated window, the
+// counts are only changed if the events are allowed.
+func slideWindow(l Limit, w window, now time.Time, n int) (Result, window) {
+	index := now.UnixNano() / int64(l.Period)
+	switch w.index {
+	case index:
+	case index - 1:
+		w.prev, w.curr = w.curr, 0
+	default:
+		w.prev, w.curr = 0, 0
+	}
+	w.index = index
+
+	rate := float64(l.Rate)
+	elapsed := float64(now.UnixNano()%int64(l.Period)) / float64(l.Period)
+	count := float64(w.prev)*(1-elapsed) + float64(w.curr)
+	if count+float64(n) <= rate {
+		w.curr += n
+		return Result{
+			Allowed:   true,
+			Remaining: int(math.Floor(rate - count - float64(n))),
+		}, w
+	}
+
+	// Wait until the weight of the previous window has decreased enough, or until the current
+	// window has become the previous window and its weight has decreased enough.
+	var wait float64
+	if free := rate - float64(w.curr+n); free >= 0 {
+		wait = 1 - free/float64(w.prev) - elapsed
+	} else {
+		wait = 2 - (rate-float64(n))/float64(w.curr) - elapsed
+	}
+	return Result{
+		Remaining:  int(math.Max(0, math.Floor(rate-count))),
+		RetryAfter: time.Duration(math.Ceil(wait * float64(l.Period))),
+	}, w
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitValidate(t *testing.T) {
+	require.NoError(t, DefaultLimit().Validate())
+	require.EqualError(t, Limit{Algorithm: "leaky", Rate: 1, Period: time.Second}.Validate(), `unknown algorithm "leaky"`)
+	require.EqualError(t, Limit{Period: time.Second}.Validate(), "rate has to be larger than zero")
+	require.EqualError(t, Limit{Rate: 1}.Validate(), "period has to be larger than zero")
+	require.EqualError(t, Limit{Rate: 2, Period: 1}.Validate(), "rate cannot be larger than the period in nanoseconds")
+}
+
+func TestTakeTokens(t *testing.T) {
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 3}
+	now := time.Now()
+	tat := time.Time{}
+	for i := 2; i >= 0; i-- {
+		var res Result
+		res, tat = takeTokens(limit, tat, now, 1)
+		require.Equal(t, Result{Allowed: true, Remaining: i}, res)
+	}
+	res, _ := takeTokens(limit, tat, now, 1)
+	require.Equal(t, Result{Allowed: false, Remaining: 0, RetryAfter: 100 * time.Millisecond}, res)
+
+	res, _ = takeTokens(limit, tat, now.Add(250*time.Millisecond), 2)
+	require.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+	res, _ = takeTokens(limit, tat, now.Add(150*time.Millisecond), 2)
+	require.Equal(t, Result{Allowed: false, Remaining: 1, RetryAfter: 50 * time.Millisecond}, res)
+}
+
+func TestSlideWindow(t *testing.T) {
+	limit := Limit{Algorithm: SlidingWindow, Rate: 10, Period: time.Second}
+	start := time.Unix(1000, 0)
+	w := window{}
+	res, w := slideWindow(limit, w, start, 10)
+	require.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+	res, _ = slideWindow(limit, w, start.Add(500*time.Millisecond), 1)
+	require.False(t, res.Allowed)
+	require.InDelta(t, 600*time.Millisecond, res.RetryAfter, float64(time.Microsecond))
+
+	// Half of the previous window overlaps the sliding window.
+	res, w = slideWindow(limit, w, start.Add(1500*time.Millisecond), 5)
+	require.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+	res, _ = slideWindow(limit, w, start.Add(1500*time.Millisecond), 1)
+	require.False(t, res.Allowed)
+	require.InDelta(t, 100*time.Millisecond, res.RetryAfter, float64(time.Microsecond))
+	res, _ = slideWindow(limit, w, start.Add(1600*time.Millisecond), 1)
+	require.True(t, res.Allowed)
+
+	res, _ = slideWindow(limit, w, start.Add(3*time.Second), 10)
+	require.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	for _, algorithm := range []Algorithm{TokenBucket, SlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			cfg := DefaultMemoryConfig()
+			cfg.Limit = Limit{Algorithm: algorithm, Rate: 2, Period: time.Minute, Burst: 2}
+			l, err := NewMemoryLimiter(cfg)
+			require.NoError(t, err)
+			now := time.Unix(1000, 0)
+			l.now = func() time.Time { return now }
+
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				res, err := l.AllowN(ctx, "foo", 1)
+				require.NoError(t, err)
+				require.True(t, res.Allowed)
+			}
+			res, err := l.AllowN(ctx, "foo", 1)
+			require.NoError(t, err)
+			require.False(t, res.Allowed)
+			require.Greater(t, res.RetryAfter, time.Duration(0))
+
+			res, err = l.AllowN(ctx, "bar", 2)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+			_, err = l.AllowN(ctx, "bar", 3)
+			require.ErrorIs(t, err, ErrExceedsCapacity)
+
+			now = now.Add(2 * time.Minute)
+			res, err = l.AllowN(ctx, "foo", 2)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	cfg := DefaultMemoryConfig()
+	cfg.Limit = Limit{Rate: 1, Period: 50 * time.Millisecond}
+	l, err := NewMemoryLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	start := time.Now()
+	require.NoError(t, Wait(ctx, l, "foo"))
+	require.NoError(t, Wait(ctx, l, "foo"))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, Wait(ctx, l, "foo"), context.Canceled)
+}
+
+func TestMemoryLimiterMaxKeys(t *testing.T) {
+	cfg := DefaultMemoryConfig()
+	cfg.Limit = Limit{Rate: 1, Period: time.Minute}
+	cfg.MaxKeys = 1
+	l, err := NewMemoryLimiter(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, key := range []string{"foo", "bar", "foo"} {
+		res, err := l.AllowN(ctx, key, 1)
+		require.NoError(t, err)
+		require.True(t, res.Allowed, key)
+	}
+	res, err := l.AllowN(ctx, "foo", 1)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+}
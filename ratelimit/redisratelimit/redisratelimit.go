@@ -0,0 +1,138 @@
+package redisratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xenitab/pkg/ratelimit"
+)
+
+// The scripts use the time of the Redis server so that replicas with skewed clocks share the
+// same limit. Times and durations are in microseconds and each key is a single Redis key, so
+// the scripts can be used with Redis Cluster.
+var (
+	// tokenBucketScript stores the theoretical arrival time of the next event.
+	tokenBucketScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+local limit = interval * burst
+local diff = tat + interval * n - now
+if diff > limit then
+	return {0, math.floor((limit - (tat - now)) / interval), math.ceil(diff - limit)}
+end
+redis.call("SET", KEYS[1], string.format("%.0f", tat + interval * n), "PX", math.max(1, math.ceil(diff / 1000)))
+return {1, math.floor((limit - diff) / interval), 0}
+`)
+	// slidingWindowScript stores the index of the current fixed window and the counts of the
+	// current and previous windows.
+	slidingWindowScript = redis.NewScript(`
+local period = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local index = math.floor(now / period)
+local elapsed = (now - index * period) / period
+local state = redis.call("HMGET", KEYS[1], "index", "curr", "prev")
+local stored = tonumber(state[1])
+local curr = tonumber(state[2]) or 0
+local prev = tonumber(state[3]) or 0
+if stored == index - 1 then
+	prev = curr
+	curr = 0
+elseif stored ~= index then
+	prev = 0
+	curr = 0
+end
+local count = prev * (1 - elapsed) + curr
+if count + n <= rate then
+	redis.call("HSET", KEYS[1], "index", string.format("%.0f", index), "curr", curr + n, "prev", prev)
+	redis.call("PEXPIRE", KEYS[1], math.ceil(2 * period / 1000))
+	return {1, math.floor(rate - count - n), 0}
+end
+local wait
+if rate - curr - n >= 0 then
+	wait = 1 - (rate - curr - n) / prev - elapsed
+else
+	wait = 2 - (rate - n) / curr - elapsed
+end
+return {0, math.max(0, math.floor(rate - count)), math.ceil(wait * period)}
+`)
+)
+
+type Config struct {
+	// Client used to run the limiter scripts, such as a *redis.Client or *redis.ClusterClient.
+	Client redis.Scripter
+	// Prefix added to the keys stored in Redis, so that multiple limits can share a database.
+	KeyPrefix string
+	// Limit applied to each key.
+	Limit ratelimit.Limit
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Client:    nil,
+		KeyPrefix: "ratelimit:",
+		Limit:     ratelimit.DefaultLimit(),
+	}
+}
+
+// Limiter keeps the state of each key in Redis, so that limits are shared between replicas. It
+// implements ratelimit.Limiter.
+type Limiter struct {
+	cfg Config
+}
+
+func NewLimiter(cfg Config) (*Limiter, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("client cannot be nil")
+	}
+	if err := cfg.Limit.Validate(); err != nil {
+		return nil, err
+	}
+	return &Limiter{
+		cfg: cfg,
+	}, nil
+}
+
+func (l *Limiter) AllowN(ctx context.Context, key string, n int) (ratelimit.Result, error) {
+	if n > l.cfg.Limit.Capacity() {
+		return ratelimit.Result{}, ratelimit.ErrExceedsCapacity
+	}
+
+	keys := []string{l.cfg.KeyPrefix + key}
+	var res []int64
+	var err error
+	if l.cfg.Limit.Algorithm == ratelimit.SlidingWindow {
+		res, err = slidingWindowScript.Run(ctx, l.cfg.Client, keys, micros(l.cfg.Limit.Period), l.cfg.Limit.Rate, n).Int64Slice()
+	} else {
+		res, err = tokenBucketScript.Run(ctx, l.cfg.Client, keys, micros(l.cfg.Limit.Interval()), l.cfg.Limit.Capacity(), n).Int64Slice()
+	}
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("could not run rate limit script: %w", err)
+	}
+	if len(res) != 3 {
+		return ratelimit.Result{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	return ratelimit.Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+	}, nil
+}
+
+// micros formats the duration in microseconds, keeping fractions of a microsecond.
+func micros(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Microsecond), 'f', -1, 64)
+}
@@ -0,0 +1,68 @@
+package redisratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/xenitab/pkg/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		algorithm  ratelimit.Algorithm
+		retryAfter time.Duration
+	}{
+		{algorithm: ratelimit.TokenBucket, retryAfter: 30 * time.Second},
+		// The window started 40 seconds ago and the next window has to be 50% elapsed.
+		{algorithm: ratelimit.SlidingWindow, retryAfter: 50 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			s := miniredis.RunT(t)
+			now := time.Unix(1000, 0)
+			s.SetTime(now)
+			cfg := DefaultConfig()
+			cfg.Client = redis.NewClient(&redis.Options{Addr: s.Addr()})
+			cfg.Limit = ratelimit.Limit{Algorithm: tt.algorithm, Rate: 2, Period: time.Minute, Burst: 2}
+			l, err := NewLimiter(cfg)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			for i := 1; i >= 0; i-- {
+				res, err := l.AllowN(ctx, "foo", 1)
+				require.NoError(t, err)
+				require.Equal(t, ratelimit.Result{Allowed: true, Remaining: i}, res)
+			}
+			res, err := l.AllowN(ctx, "foo", 1)
+			require.NoError(t, err)
+			require.Equal(t, ratelimit.Result{Allowed: false, Remaining: 0, RetryAfter: tt.retryAfter}, res)
+			require.True(t, s.Exists("ratelimit:foo"))
+
+			res, err = l.AllowN(ctx, "bar", 2)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+			_, err = l.AllowN(ctx, "bar", 3)
+			require.ErrorIs(t, err, ratelimit.ErrExceedsCapacity)
+
+			s.SetTime(now.Add(2 * time.Minute))
+			res, err = l.AllowN(ctx, "foo", 2)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+		})
+	}
+}
+
+func TestNewLimiterInvalidConfig(t *testing.T) {
+	_, err := NewLimiter(DefaultConfig())
+	require.EqualError(t, err, "client cannot be nil")
+
+	cfg := DefaultConfig()
+	cfg.Client = redis.NewClient(&redis.Options{})
+	cfg.Limit.Rate = 0
+	_, err = NewLimiter(cfg)
+	require.EqualError(t, err, "rate has to be larger than zero")
+}
@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type Policy struct {
+	// Maximum number of attempts, including the first attempt. Values below one result in a single attempt.
+	MaxAttempts int
+	// Maximum time spent retrying, measured from the first attempt. Zero disables the limit.
+	MaxElapsedTime time.Duration
+	// Delay before the first retry.
+	InitialInterval time.Duration
+	// Upper bound for the delay between retries.
+	MaxInterval time.Duration
+	// Factor the delay is multiplied with after each retry.
+	Multiplier float64
+	// Randomization factor between 0 and 1 applied to each delay.
+	Jitter float64
+	// Decides if an error should be retried, all errors are retried if nil.
+	Retryable func(error) bool
+	// Called before waiting for the next attempt, useful for logging and metrics.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:     5,
+		MaxElapsedTime:  0,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		Retryable:       nil,
+		OnRetry:         nil,
+	}
+}
+
+// Delay returns the delay before the given retry, where zero is the first retry.
+func (p Policy) Delay(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialInterval) * math.Pow(multiplier, float64(retry))
+	if p.MaxInterval > 0 && d > float64(p.MaxInterval) {
+		d = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err to stop any further retries regardless of the policy. The wrapped error is
+// returned by Do.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds or the policy stops retrying, returning the last error. If ctx is
+// cancelled while waiting for the next attempt the last error is returned.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is like Do but returns the value of the last attempt.
+func DoValue[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		v, err := fn(ctx)
+		if err == nil {
+			return v, nil
+		}
+		permanent := &permanentError{}
+		if errors.As(err, &permanent) {
+			if err == error(permanent) {
+				err = permanent.err
+			}
+			return v, err
+		}
+		if attempt >= policy.MaxAttempts || (policy.Retryable != nil && !policy.Retryable(err)) {
+			return v, err
+		}
+		delay := policy.Delay(attempt - 1)
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			return v, err
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy() Policy {
+	policy := DefaultPolicy()
+	policy.InitialInterval = time.Millisecond
+	policy.Jitter = 0
+	return policy
+}
+
+func TestDo(t *testing.T) {
+	retries := []int{}
+	policy := testPolicy()
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		retries = append(retries, attempt)
+	}
+	attempts := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, []int{1, 2}, retries)
+}
+
+func TestDoMaxAttempts(t *testing.T) {
+	policy := testPolicy()
+	policy.MaxAttempts = 3
+	attempts := 0
+	value, err := DoValue(context.Background(), policy, func(ctx context.Context) (int, error) {
+		attempts++
+		return attempts, fmt.Errorf("attempt %d", attempts)
+	})
+	require.EqualError(t, err, "attempt 3")
+	require.Equal(t, 3, value)
+}
+
+func TestDoNotRetryable(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	policy := testPolicy()
+	policy.Retryable = func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	}
+	attempts := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		attempts++
+		return errPermanent
+	})
+	require.ErrorIs(t, err, errPermanent)
+	require.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = Do(context.Background(), testPolicy(), func(ctx context.Context) error {
+		attempts++
+		return Permanent(errPermanent)
+	})
+	require.Equal(t, errPermanent, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestDoMaxElapsedTime(t *testing.T) {
+	policy := testPolicy()
+	policy.MaxAttempts = 100
+	policy.InitialInterval = 20 * time.Millisecond
+	policy.Multiplier = 1
+	policy.MaxElapsedTime = 50 * time.Millisecond
+	attempts := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		attempts++
+		return errors.New("transient")
+	})
+	require.Error(t, err)
+	require.Greater(t, attempts, 1)
+	require.LessOrEqual(t, attempts, 3)
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := testPolicy()
+	policy.InitialInterval = time.Hour
+	attempts := 0
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return errors.New("transient")
+	})
+	require.EqualError(t, err, "transient")
+	require.Equal(t, 1, attempts)
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Multiplier:      2,
+	}
+	require.Equal(t, time.Second, policy.Delay(0))
+	require.Equal(t, 4*time.Second, policy.Delay(2))
+	require.Equal(t, 5*time.Second, policy.Delay(3))
+}
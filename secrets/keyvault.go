@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const keyVaultAPIVersion = "7.4"
+
+type KeyVaultConfig struct {
+	// Credential used to authenticate with Key Vault.
+	Credential azcore.TokenCredential
+	// DNS suffix of the vaults, differs between sovereign clouds.
+	DNSSuffix string
+	// Transport used for requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+func DefaultKeyVaultConfig() KeyVaultConfig {
+	return KeyVaultConfig{
+		Credential: nil,
+		DNSSuffix:  "vault.azure.net",
+		Transport:  nil,
+	}
+}
+
+// KeyVault resolves secrets from Azure Key Vault, the path is the vault name followed by the
+// secret name and optionally the secret version, for example my-vault/db-password. The latest
+// version is resolved if no version is set.
+type KeyVault struct {
+	cfg    KeyVaultConfig
+	client *http.Client
+}
+
+func NewKeyVault(cfg KeyVaultConfig) (*KeyVault, error) {
+	if cfg.Credential == nil {
+		return nil, errors.New("credential cannot be nil")
+	}
+	if cfg.DNSSuffix == "" {
+		return nil, errors.New("dns suffix cannot be empty")
+	}
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &KeyVault{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: &bearerTransport{
+				cred:  cfg.Credential,
+				scope: fmt.Sprintf("https://%s/.default", cfg.DNSSuffix),
+				base:  transport,
+			},
+		},
+	}, nil
+}
+
+func (k *KeyVault) Resolve(ctx context.Context, path string) (string, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("key vault path %s has to be vault/name or vault/name/version", path)
+	}
+	u := url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("%s.%s", parts[0], k.cfg.DNSSuffix),
+		Path:     "/secrets/" + strings.Join(parts[1:], "/"),
+		RawQuery: url.Values{"api-version": []string{keyVaultAPIVersion}}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body := struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return "", fmt.Errorf("key vault returned status code %d: %s %s", resp.StatusCode, body.Error.Code, body.Error.Message)
+	}
+	body := struct {
+		Value string `json:"value"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("could not decode key vault response: %w", err)
+	}
+	return body.Value, nil
+}
+
+// bearerTransport attaches a bearer token for the scope to every request.
+type bearerTransport struct {
+	cred  azcore.TokenCredential
+	scope string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.cred.GetToken(req.Context(), policy.TokenRequestOptions{Scopes: []string{t.scope}})
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: opts.Scopes[0], ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Original-Host", req.URL.Host)
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestKeyVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer https://vault.azure.net/.default", r.Header.Get("Authorization"))
+		require.Equal(t, "my-vault.vault.azure.net", r.Header.Get("X-Original-Host"))
+		require.Equal(t, keyVaultAPIVersion, r.URL.Query().Get("api-version"))
+		switch r.URL.Path {
+		case "/secrets/db-password":
+			w.Write([]byte(`{"value":"latest"}`))
+		case "/secrets/db-password/abc":
+			w.Write([]byte(`{"value":"versioned"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+		}
+	}))
+	defer srv.Close()
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	cfg := DefaultKeyVaultConfig()
+	cfg.Credential = fakeCredential{}
+	cfg.Transport = rewriteTransport{target: target}
+	kv, err := NewKeyVault(cfg)
+	require.NoError(t, err)
+
+	storeCfg := DefaultConfig()
+	storeCfg.Resolvers["keyvault"] = kv
+	store := NewStore(storeCfg)
+	value, err := store.Resolve(context.Background(), "secretref://keyvault/my-vault/db-password")
+	require.NoError(t, err)
+	require.Equal(t, "latest", value)
+	value, err = store.Resolve(context.Background(), "secretref://keyvault/my-vault/db-password/abc")
+	require.NoError(t, err)
+	require.Equal(t, "versioned", value)
+	_, err = store.Resolve(context.Background(), "secretref://keyvault/my-vault/missing")
+	require.EqualError(t, err, "could not resolve secretref://keyvault/my-vault/missing: key vault returned status code 404: SecretNotFound not found")
+	_, err = store.Resolve(context.Background(), "secretref://keyvault/my-vault")
+	require.EqualError(t, err, "could not resolve secretref://keyvault/my-vault: key vault path my-vault has to be vault/name or vault/name/version")
+}
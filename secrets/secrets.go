@@ -0,0 +1,296 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefPrefix is the prefix of config values which reference a secret, for example
+// secretref://env/DB_PASSWORD, secretref://file/var/run/secrets/db/password or
+// secretref://keyvault/my-vault/db-password.
+const RefPrefix = "secretref://"
+
+// Resolver returns the value of a secret from a single provider.
+type Resolver interface {
+	// Resolve returns the secret for the path of the reference, which is the part after the
+	// provider name.
+	Resolve(ctx context.Context, path string) (string, error)
+}
+
+// ResolverFunc allows a function to be used as a Resolver.
+type ResolverFunc func(ctx context.Context, path string) (string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, path string) (string, error) {
+	return f(ctx, path)
+}
+
+// Env resolves secrets from environment variables.
+type Env struct{}
+
+func (Env) Resolve(ctx context.Context, path string) (string, error) {
+	value, ok := os.LookupEnv(path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", path)
+	}
+	return value, nil
+}
+
+// File resolves secrets from files, such as Kubernetes secrets mounted as volumes. A single
+// trailing newline is removed from the content.
+type File struct {
+	// Directory paths are relative to, paths are absolute if empty.
+	Dir string
+}
+
+func (f File) Resolve(ctx context.Context, path string) (string, error) {
+	if f.Dir == "" {
+		path = "/" + path
+	} else {
+		path = filepath.Join(f.Dir, filepath.Clean("/"+path))
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// IsRef returns true if the value is a secret reference.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+func parseRef(ref string) (string, string, error) {
+	provider, path, ok := strings.Cut(strings.TrimPrefix(ref, RefPrefix), "/")
+	if !ok || provider == "" || path == "" {
+		return "", "", fmt.Errorf("invalid secret reference %s", ref)
+	}
+	return provider, path, nil
+}
+
+type Config struct {
+	// Resolvers by provider name, the provider name is the host of the reference.
+	Resolvers map[string]Resolver
+	// Interval between re-resolving secrets returned by Store.Secret, to pick up rotated secrets.
+	Interval time.Duration
+	// Called when re-resolving a secret fails, the previous value is kept.
+	OnError func(ref string, err error)
+	// Called when a secret has changed.
+	OnChange func(ref string)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Resolvers: map[string]Resolver{
+			"env":  Env{},
+			"file": File{},
+		},
+		Interval: 5 * time.Minute,
+		OnError:  nil,
+		OnChange: nil,
+	}
+}
+
+// Secret is a resolved secret which is updated when the secret is rotated.
+type Secret struct {
+	ref string
+
+	mu    sync.RWMutex
+	value string
+}
+
+// Value returns the latest resolved value.
+func (s *Secret) Value() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// Store resolves secret references and periodically re-resolves secrets to pick up rotations.
+type Store struct {
+	cfg Config
+
+	mu      sync.Mutex
+	secrets map[string]*Secret
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewStore(cfg Config) *Store {
+	return &Store{
+		cfg:     cfg,
+		secrets: map[string]*Secret{},
+		done:    make(chan struct{}),
+	}
+}
+
+// Resolve returns the secret for a reference, values which are not references are returned as is.
+func (s *Store) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+	provider, path, err := parseRef(value)
+	if err != nil {
+		return "", err
+	}
+	resolver, ok := s.cfg.Resolvers[provider]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %s", provider)
+	}
+	secret, err := resolver.Resolve(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s: %w", value, err)
+	}
+	return secret, nil
+}
+
+// Secret resolves the value and returns a secret which is kept up to date while the store is
+// started. Values which are not references never change.
+func (s *Store) Secret(ctx context.Context, value string) (*Secret, error) {
+	s.mu.Lock()
+	secret, ok := s.secrets[value]
+	s.mu.Unlock()
+	if ok {
+		return secret, nil
+	}
+	resolved, err := s.Resolve(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	secret = &Secret{ref: value, value: resolved}
+	if !IsRef(value) {
+		return secret, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.secrets[value]; ok {
+		return existing, nil
+	}
+	s.secrets[value] = secret
+	return secret, nil
+}
+
+// Refresh re-resolves all secrets returned by Secret, errors are joined and the previous values
+// are kept for secrets which fail.
+func (s *Store) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	secrets := make([]*Secret, 0, len(s.secrets))
+	for _, secret := range s.secrets {
+		secrets = append(secrets, secret)
+	}
+	s.mu.Unlock()
+
+	errs := []error{}
+	for _, secret := range secrets {
+		value, err := s.Resolve(ctx, secret.ref)
+		if err != nil {
+			if s.cfg.OnError != nil {
+				s.cfg.OnError(secret.ref, err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		secret.mu.Lock()
+		changed := secret.value != value
+		secret.value = value
+		secret.mu.Unlock()
+		if changed && s.cfg.OnChange != nil {
+			s.cfg.OnChange(secret.ref)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ResolveStruct replaces all string fields of the struct pointed to by dst which contain a
+// reference with the secret, nested structs and pointers to structs are resolved recursively.
+func (s *Store) ResolveStruct(ctx context.Context, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("destination has to be a pointer to a struct")
+	}
+	return s.resolveValue(ctx, v.Elem())
+}
+
+func (s *Store) resolveValue(ctx context.Context, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return s.resolveValue(ctx, v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			err := s.resolveValue(ctx, v.Field(i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if !IsRef(v.String()) {
+			return nil
+		}
+		secret, err := s.Resolve(ctx, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(secret)
+	}
+	return nil
+}
+
+// Start re-resolves secrets every interval until Stop is called or ctx is cancelled.
+func (s *Store) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return errors.New("store has already been started")
+	}
+	if s.cfg.Interval <= 0 {
+		s.mu.Unlock()
+		return errors.New("interval has to be larger than zero")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Errors are reported through OnError and the previous values are kept.
+			s.Refresh(ctx)
+		}
+	}
+}
+
+func (s *Store) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
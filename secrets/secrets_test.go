@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreResolve(t *testing.T) {
+	t.Setenv("SECRETS_TEST_PASSWORD", "env-secret")
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "password"), []byte("file-secret\n"), 0o600)
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	cfg.Resolvers["mounted"] = File{Dir: dir}
+	store := NewStore(cfg)
+
+	tests := []struct {
+		value    string
+		expected string
+		err      string
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "secretref://env/SECRETS_TEST_PASSWORD", expected: "env-secret"},
+		{value: "secretref://file" + filepath.Join(dir, "password"), expected: "file-secret"},
+		{value: "secretref://mounted/password", expected: "file-secret"},
+		{value: "secretref://mounted/../password", expected: "file-secret"},
+		{value: "secretref://env/SECRETS_TEST_MISSING", err: "could not resolve secretref://env/SECRETS_TEST_MISSING: environment variable SECRETS_TEST_MISSING is not set"},
+		{value: "secretref://vault/foo", err: "unknown secret provider vault"},
+		{value: "secretref://env", err: "invalid secret reference secretref://env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			value, err := store.Resolve(context.Background(), tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestStoreResolveStruct(t *testing.T) {
+	t.Setenv("SECRETS_TEST_PASSWORD", "env-secret")
+	type database struct {
+		Password string
+	}
+	cfg := struct {
+		Name     string
+		Database database
+		Replica  *database
+		Port     int
+	}{
+		Name:     "app",
+		Database: database{Password: "secretref://env/SECRETS_TEST_PASSWORD"},
+		Replica:  &database{Password: "secretref://env/SECRETS_TEST_PASSWORD"},
+		Port:     5432,
+	}
+	store := NewStore(DefaultConfig())
+	err := store.ResolveStruct(context.Background(), &cfg)
+	require.NoError(t, err)
+	require.Equal(t, "app", cfg.Name)
+	require.Equal(t, "env-secret", cfg.Database.Password)
+	require.Equal(t, "env-secret", cfg.Replica.Password)
+
+	err = store.ResolveStruct(context.Background(), cfg)
+	require.EqualError(t, err, "destination has to be a pointer to a struct")
+}
+
+func TestStoreRefresh(t *testing.T) {
+	value := "v1"
+	fail := false
+	changed := []string{}
+	failed := []string{}
+	cfg := DefaultConfig()
+	cfg.Resolvers["test"] = ResolverFunc(func(ctx context.Context, path string) (string, error) {
+		if fail {
+			return "", errors.New("unavailable")
+		}
+		return value, nil
+	})
+	cfg.OnChange = func(ref string) {
+		changed = append(changed, ref)
+	}
+	cfg.OnError = func(ref string, err error) {
+		failed = append(failed, ref)
+	}
+	store := NewStore(cfg)
+
+	secret, err := store.Secret(context.Background(), "secretref://test/foo")
+	require.NoError(t, err)
+	require.Equal(t, "v1", secret.Value())
+	same, err := store.Secret(context.Background(), "secretref://test/foo")
+	require.NoError(t, err)
+	require.Same(t, secret, same)
+
+	value = "v2"
+	err = store.Refresh(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "v2", secret.Value())
+	require.Equal(t, []string{"secretref://test/foo"}, changed)
+
+	fail = true
+	err = store.Refresh(context.Background())
+	require.EqualError(t, err, "could not resolve secretref://test/foo: unavailable")
+	require.Equal(t, "v2", secret.Value())
+	require.Equal(t, []string{"secretref://test/foo"}, failed)
+}
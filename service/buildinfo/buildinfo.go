@@ -0,0 +1,96 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Variables are set at build time with ldflags, for example:
+// -ldflags "-X github.com/xenitab/pkg/service/buildinfo.version=v1.0.0"
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information set with ldflags, falling back to the information embedded
+// by the Go toolchain for values which are not set.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return withDefaults(info)
+	}
+	if info.Version == "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		}
+	}
+	return withDefaults(info)
+}
+
+func withDefaults(info Info) Info {
+	if info.Version == "" {
+		info.Version = "unknown"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// Log logs the build information, meant to be called once at startup.
+func Log(log logr.Logger) {
+	info := Get()
+	log.Info("build info", "version", info.Version, "commit", info.Commit, "date", info.Date, "goVersion", info.GoVersion)
+}
+
+// Register registers the app_build_info gauge, which is always 1 and has the build information as labels.
+func Register(reg prometheus.Registerer) error {
+	info := Get()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "app_build_info",
+		Help: "Build information of the application, the value is always 1.",
+	}, []string{"version", "commit", "date", "go_version"})
+	gauge.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion).Set(1)
+	return reg.Register(gauge)
+}
+
+// Handler returns a handler which responds with the build information as JSON. It can be used
+// with gin through gin.WrapH.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Get())
+	})
+}
@@ -0,0 +1,68 @@
+package buildinfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tonglil/buflogr"
+)
+
+func setBuildInfo(t *testing.T, v, c, d string) {
+	t.Helper()
+	prevVersion, prevCommit, prevDate := version, commit, date
+	version, commit, date = v, c, d
+	t.Cleanup(func() {
+		version, commit, date = prevVersion, prevCommit, prevDate
+	})
+}
+
+func TestGet(t *testing.T) {
+	setBuildInfo(t, "v1.0.0", "abc123", "2023-04-01T00:00:00Z")
+	require.Equal(t, Info{
+		Version:   "v1.0.0",
+		Commit:    "abc123",
+		Date:      "2023-04-01T00:00:00Z",
+		GoVersion: runtime.Version(),
+	}, Get())
+}
+
+func TestLog(t *testing.T) {
+	setBuildInfo(t, "v1.0.0", "abc123", "2023-04-01T00:00:00Z")
+	var buf bytes.Buffer
+	Log(buflogr.NewWithBuffer(&buf))
+	require.Equal(t, "INFO build info version v1.0.0 commit abc123 date 2023-04-01T00:00:00Z goVersion "+runtime.Version()+"\n", buf.String())
+}
+
+func TestRegister(t *testing.T) {
+	setBuildInfo(t, "v1.0.0", "abc123", "2023-04-01T00:00:00Z")
+	reg := prometheus.NewRegistry()
+	err := Register(reg)
+	require.NoError(t, err)
+	expected := `
+# HELP app_build_info Build information of the application, the value is always 1.
+# TYPE app_build_info gauge
+app_build_info{commit="abc123",date="2023-04-01T00:00:00Z",go_version="` + runtime.Version() + `",version="v1.0.0"} 1
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "app_build_info")
+	require.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	setBuildInfo(t, "v1.0.0", "abc123", "")
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	info := Info{}
+	err := json.Unmarshal(rec.Body.Bytes(), &info)
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.0", info.Version)
+	require.Equal(t, "abc123", info.Commit)
+}
@@ -0,0 +1,105 @@
+package debugserver
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/xenitab/pkg/service/internal/httpserver"
+)
+
+// startTime approximates the start of the process for the reported uptime.
+var startTime = time.Now()
+
+type Config struct {
+	// Address to listen on, should be bound to localhost as the endpoints expose internal state.
+	Address string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Address: "127.0.0.1:6060",
+	}
+}
+
+// Server serves pprof profiles on /debug/pprof/, expvar variables on /debug/vars and runtime
+// statistics on /debug/runtime.
+type Server struct {
+	srv *httpserver.Server
+}
+
+func New(cfg Config) *Server {
+	return &Server{
+		srv: httpserver.New(cfg.Address, Handler()),
+	}
+}
+
+// Start serves the debug endpoints until Stop is called or ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	return s.srv.Start(ctx)
+}
+
+// Stop gracefully shuts down the server.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Stop(ctx)
+}
+
+// Addr returns the address the server is listening on, nil is returned if the server has not been started.
+func (s *Server) Addr() net.Addr {
+	return s.srv.Addr()
+}
+
+// Handler returns the handler serving the debug endpoints, for use with an existing server.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(readRuntimeStats())
+	})
+	return mux
+}
+
+type runtimeStats struct {
+	GoVersion     string  `json:"go_version"`
+	Uptime        string  `json:"uptime"`
+	NumCPU        int     `json:"num_cpu"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumGoroutine  int     `json:"num_goroutine"`
+	HeapAlloc     uint64  `json:"heap_alloc_bytes"`
+	HeapInuse     uint64  `json:"heap_inuse_bytes"`
+	HeapObjects   uint64  `json:"heap_objects"`
+	Sys           uint64  `json:"sys_bytes"`
+	NumGC         uint32  `json:"num_gc"`
+	PauseTotal    string  `json:"gc_pause_total"`
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+}
+
+func readRuntimeStats() runtimeStats {
+	mem := runtime.MemStats{}
+	runtime.ReadMemStats(&mem)
+	return runtimeStats{
+		GoVersion:     runtime.Version(),
+		Uptime:        time.Since(startTime).Round(time.Second).String(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumGoroutine:  runtime.NumGoroutine(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		PauseTotal:    time.Duration(mem.PauseTotalNs).String(),
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+}
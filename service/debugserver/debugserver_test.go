@@ -0,0 +1,45 @@
+package debugserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Address = "127.0.0.1:0"
+	srv := New(cfg)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Start(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		return srv.Addr() != nil
+	}, time.Second, 10*time.Millisecond)
+	baseURL := "http://" + srv.Addr().String()
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/vars"} {
+		resp, err := http.Get(baseURL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+
+	resp, err := http.Get(baseURL + "/debug/runtime")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	stats := runtimeStats{}
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	require.NoError(t, err)
+	require.NotZero(t, stats.NumGoroutine)
+	require.NotEmpty(t, stats.GoVersion)
+
+	err = srv.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server wraps a http.Server with a blocking Start and a graceful Stop.
+type Server struct {
+	srv *http.Server
+
+	mu   sync.Mutex
+	addr net.Addr
+}
+
+func New(addr string, handler http.Handler) *Server {
+	return &Server{
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start serves requests until Stop is called or ctx is cancelled. Cancelling ctx closes the
+// server without waiting for active requests to complete.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.addr = ln.Addr()
+	s.mu.Unlock()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.srv.Serve(ln)
+	}()
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		s.srv.Close()
+		<-errCh
+		return nil
+	}
+}
+
+// Stop gracefully shuts down the server, waiting for active requests until ctx is cancelled.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// Addr returns the address the server is listening on, nil is returned if the server has not been started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
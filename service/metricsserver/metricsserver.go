@@ -0,0 +1,57 @@
+package metricsserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xenitab/pkg/service/internal/httpserver"
+)
+
+type Config struct {
+	// Address to listen on.
+	Address string
+	// Path metrics are served on.
+	Path string
+	// Gatherer to collect metrics from.
+	Gatherer prometheus.Gatherer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Address:  ":9090",
+		Path:     "/metrics",
+		Gatherer: prometheus.DefaultGatherer,
+	}
+}
+
+// Server serves Prometheus metrics separately from the application server, so that metrics can
+// still be scraped while the application server is draining.
+type Server struct {
+	srv *httpserver.Server
+}
+
+func New(cfg Config) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, promhttp.HandlerFor(cfg.Gatherer, promhttp.HandlerOpts{}))
+	return &Server{
+		srv: httpserver.New(cfg.Address, mux),
+	}
+}
+
+// Start serves metrics until Stop is called or ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	return s.srv.Start(ctx)
+}
+
+// Stop gracefully shuts down the server.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Stop(ctx)
+}
+
+// Addr returns the address the server is listening on, nil is returned if the server has not been started.
+func (s *Server) Addr() net.Addr {
+	return s.srv.Addr()
+}
@@ -0,0 +1,47 @@
+package metricsserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "test_total",
+		Help: "Test counter.",
+	})
+	reg.MustRegister(counter)
+	counter.Inc()
+
+	cfg := DefaultConfig()
+	cfg.Address = "127.0.0.1:0"
+	cfg.Gatherer = reg
+	srv := New(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Start(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return srv.Addr() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	resp, err := http.Get("http://" + srv.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "test_total 1")
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
@@ -0,0 +1,56 @@
+// Package service contains the interfaces shared by the components of a service.
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reloader is implemented by components which can apply a new configuration without restarting,
+// such as config.Watcher.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// ReloaderFunc is an adapter to allow the use of ordinary functions as a Reloader.
+type ReloaderFunc func(ctx context.Context) error
+
+func (f ReloaderFunc) Reload(ctx context.Context) error {
+	return f(ctx)
+}
+
+// ReloadOnSignal calls the reloaders in order every time the process receives SIGHUP, until ctx
+// is cancelled. An error from one reloader does not stop the others, all errors are passed to
+// onError if it is not nil.
+func ReloadOnSignal(ctx context.Context, onError func(error), reloaders ...Reloader) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			err := Reload(ctx, reloaders...)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Reload calls all reloaders in order and returns the joined errors.
+func Reload(ctx context.Context, reloaders ...Reloader) error {
+	errs := []error{}
+	for _, r := range reloaders {
+		err := r.Reload(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
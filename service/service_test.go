@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	calls := 0
+	ok := ReloaderFunc(func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	failing := ReloaderFunc(func(ctx context.Context) error {
+		return errors.New("reload failed")
+	})
+
+	err := Reload(context.Background(), ok, failing, ok)
+	require.EqualError(t, err, "reload failed")
+	require.Equal(t, 2, calls)
+
+	err = Reload(context.Background())
+	require.NoError(t, err)
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	reloadCh := make(chan struct{}, 1)
+	errCh := make(chan error, 1)
+	ok := ReloaderFunc(func(ctx context.Context) error {
+		reloadCh <- struct{}{}
+		return nil
+	})
+	failing := ReloaderFunc(func(ctx context.Context) error {
+		return errors.New("reload failed")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ReloadOnSignal(ctx, func(err error) { errCh <- err }, ok, failing)
+	}()
+	// Wait for the signal handler to be registered.
+	time.Sleep(50 * time.Millisecond)
+
+	err := syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	require.NoError(t, err)
+	<-reloadCh
+	require.EqualError(t, <-errCh, "reload failed")
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,155 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+type Config struct {
+	// Path of the notification socket, read from NOTIFY_SOCKET by default. Notifications are
+	// disabled if empty, for example when not running under systemd.
+	Socket string
+	// Interval between watchdog heartbeats, half of WATCHDOG_USEC by default. Heartbeats are
+	// disabled if zero.
+	WatchdogInterval time.Duration
+	// Checked before each heartbeat, the heartbeat is skipped if an error is returned so that
+	// systemd restarts a service which has hung.
+	Healthy func(ctx context.Context) error
+}
+
+func DefaultConfig() Config {
+	interval, _ := WatchdogInterval()
+	return Config{
+		Socket:           os.Getenv("NOTIFY_SOCKET"),
+		WatchdogInterval: interval,
+		Healthy:          nil,
+	}
+}
+
+// WatchdogInterval returns half of the watchdog timeout configured by systemd, false is returned
+// if the watchdog is not enabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	pid := os.Getenv("WATCHDOG_PID")
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond / 2, true
+}
+
+// Notify sends the state to the notification socket, nothing is sent if socket is empty.
+func Notify(socket, state string) error {
+	if socket == "" {
+		return nil
+	}
+	// Abstract sockets are prefixed with @ in the environment variable.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// Notifier reports readiness to systemd when started, sends watchdog heartbeats while running and
+// reports that the service is stopping when stopped. It does nothing when not running under
+// systemd, so it can be added to services regardless of where they are deployed.
+type Notifier struct {
+	cfg Config
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(cfg Config) *Notifier {
+	return &Notifier{
+		cfg:  cfg,
+		done: make(chan struct{}),
+	}
+}
+
+// Enabled returns true if notifications are sent.
+func (n *Notifier) Enabled() bool {
+	return n.cfg.Socket != ""
+}
+
+// Start notifies systemd that the service is ready and sends watchdog heartbeats until Stop is
+// called or ctx is cancelled. Start should be called after the other components have started.
+func (n *Notifier) Start(ctx context.Context) error {
+	n.mu.Lock()
+	if n.cancel != nil {
+		n.mu.Unlock()
+		return errors.New("notifier has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	n.cancel = cancel
+	n.mu.Unlock()
+	defer close(n.done)
+
+	err := Notify(n.cfg.Socket, StateReady)
+	if err != nil {
+		return err
+	}
+	if !n.Enabled() || n.cfg.WatchdogInterval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(n.cfg.WatchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if n.cfg.Healthy != nil && n.cfg.Healthy(ctx) != nil {
+				continue
+			}
+			err := Notify(n.cfg.Socket, StateWatchdog)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Stop notifies systemd that the service is stopping and stops sending heartbeats.
+func (n *Notifier) Stop(ctx context.Context) error {
+	err := Notify(n.cfg.Socket, StateStopping)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	cancel := n.cancel
+	n.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
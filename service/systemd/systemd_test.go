@@ -0,0 +1,104 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	states := make(chan string, 100)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			states <- string(buf[:n])
+		}
+	}()
+	return path, states
+}
+
+func TestNotifier(t *testing.T) {
+	socket, states := listen(t)
+	cfg := DefaultConfig()
+	cfg.Socket = socket
+	cfg.WatchdogInterval = 10 * time.Millisecond
+	healthy := atomic.Bool{}
+	healthy.Store(true)
+	cfg.Healthy = func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+	n := New(cfg)
+	require.True(t, n.Enabled())
+	errCh := make(chan error)
+	go func() {
+		errCh <- n.Start(context.Background())
+	}()
+
+	require.Equal(t, StateReady, <-states)
+	require.Equal(t, StateWatchdog, <-states)
+
+	healthy.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	for len(states) > 0 {
+		<-states
+	}
+	time.Sleep(30 * time.Millisecond)
+	require.Empty(t, states)
+
+	err := n.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, StateStopping, <-states)
+}
+
+func TestNotifierDisabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Socket = ""
+	n := New(cfg)
+	require.False(t, n.Enabled())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := n.Start(ctx)
+	require.NoError(t, err)
+	err = n.Stop(context.Background())
+	require.NoError(t, err)
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "2000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	interval, ok := WatchdogInterval()
+	require.True(t, ok)
+	require.Equal(t, time.Second, interval)
+
+	t.Setenv("WATCHDOG_PID", "1")
+	_, ok = WatchdogInterval()
+	require.False(t, ok)
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+	_, ok = WatchdogInterval()
+	require.False(t, ok)
+}
@@ -0,0 +1,173 @@
+package waitfor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/xenitab/pkg/retry"
+)
+
+type Config struct {
+	// Maximum duration to wait for all dependencies, waits until ctx is cancelled if zero.
+	Timeout time.Duration
+	// Timeout of each probe attempt.
+	AttemptTimeout time.Duration
+	// Delay before the first retry, doubled after each retry up to MaxInterval.
+	InitialInterval time.Duration
+	// Upper bound for the delay between retries.
+	MaxInterval time.Duration
+	// Called when a probe fails and is about to be retried, useful for logging.
+	OnRetry func(name string, attempt int, err error)
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:         2 * time.Minute,
+		AttemptTimeout:  5 * time.Second,
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		OnRetry:         nil,
+	}
+}
+
+// Check probes a dependency, the probe should return nil once the dependency is available.
+type Check struct {
+	// Name of the dependency, used in errors.
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+// TCP returns a check which succeeds when a connection to the address can be opened.
+func TCP(address string) Check {
+	return Check{
+		Name: "tcp://" + address,
+		Probe: func(ctx context.Context) error {
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// HTTP returns a check which succeeds when a GET request to the URL responds with one of the
+// status codes, any 2xx status code is accepted if none are given.
+func HTTP(url string, statusCodes ...int) Check {
+	return Check{
+		Name: url,
+		Probe: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if len(statusCodes) == 0 {
+				if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+					return nil
+				}
+				return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+			}
+			for _, statusCode := range statusCodes {
+				if resp.StatusCode == statusCode {
+					return nil
+				}
+			}
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		},
+	}
+}
+
+// DNS returns a check which succeeds when the host resolves to at least one address.
+func DNS(host string) Check {
+	return Check{
+		Name: "dns://" + host,
+		Probe: func(ctx context.Context) error {
+			addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return err
+			}
+			if len(addrs) == 0 {
+				return fmt.Errorf("%s did not resolve to any address", host)
+			}
+			return nil
+		},
+	}
+}
+
+// Wait probes all checks concurrently with backoff until they succeed. An error is returned for
+// the checks which have not succeeded when the timeout passes or ctx is cancelled.
+func Wait(ctx context.Context, cfg Config, checks ...Check) error {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	errs := make([]error, len(checks))
+	wg := sync.WaitGroup{}
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			err := wait(ctx, cfg, check)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s is not available: %w", check.Name, err)
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func wait(ctx context.Context, cfg Config, check Check) error {
+	policy := retry.Policy{
+		MaxAttempts:     math.MaxInt,
+		InitialInterval: cfg.InitialInterval,
+		MaxInterval:     cfg.MaxInterval,
+		Multiplier:      2,
+		// Jitter spreads out the probes of replicas starting at the same time.
+		Jitter: 0.2,
+		OnRetry: func(attempt int, err error, _ time.Duration) {
+			if cfg.OnRetry != nil {
+				cfg.OnRetry(check.Name, attempt, err)
+			}
+		},
+	}
+	return retry.Do(ctx, policy, func(ctx context.Context) error {
+		return probe(ctx, cfg, check)
+	})
+}
+
+func probe(ctx context.Context, cfg Config, check Check) error {
+	if cfg.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.AttemptTimeout)
+		defer cancel()
+	}
+	return check.Probe(ctx)
+}
+
+// WaitForTCP waits until a connection to the address can be opened.
+func WaitForTCP(ctx context.Context, cfg Config, address string) error {
+	return Wait(ctx, cfg, TCP(address))
+}
+
+// WaitForHTTP waits until a GET request to the URL responds with one of the status codes.
+func WaitForHTTP(ctx context.Context, cfg Config, url string, statusCodes ...int) error {
+	return Wait(ctx, cfg, HTTP(url, statusCodes...))
+}
+
+// WaitForDNS waits until the host resolves.
+func WaitForDNS(ctx context.Context, cfg Config, host string) error {
+	return Wait(ctx, cfg, DNS(host))
+}
@@ -0,0 +1,97 @@
+package waitfor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Timeout = time.Second
+	cfg.AttemptTimeout = 100 * time.Millisecond
+	cfg.InitialInterval = time.Millisecond
+	cfg.MaxInterval = 10 * time.Millisecond
+	return cfg
+}
+
+func TestWaitForTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, WaitForTCP(context.Background(), testConfig(), addr))
+
+	l.Close()
+	cfg := testConfig()
+	cfg.Timeout = 50 * time.Millisecond
+	err = WaitForTCP(context.Background(), cfg, addr)
+	require.ErrorContains(t, err, "tcp://"+addr+" is not available")
+}
+
+func TestWaitForHTTP(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	retries := []int{}
+	cfg := testConfig()
+	cfg.OnRetry = func(name string, attempt int, err error) {
+		require.Equal(t, srv.URL, name)
+		retries = append(retries, attempt)
+	}
+	require.NoError(t, WaitForHTTP(context.Background(), cfg, srv.URL, http.StatusOK, http.StatusUnauthorized))
+	require.Equal(t, []int{1, 2}, retries)
+
+	cfg = testConfig()
+	cfg.Timeout = 50 * time.Millisecond
+	err := WaitForHTTP(context.Background(), cfg, srv.URL)
+	require.ErrorContains(t, err, "unexpected status code 401")
+}
+
+func TestWaitForDNS(t *testing.T) {
+	require.NoError(t, WaitForDNS(context.Background(), testConfig(), "localhost"))
+}
+
+func TestWait(t *testing.T) {
+	var ready atomic.Bool
+	checks := []Check{
+		{
+			Name: "eventually",
+			Probe: func(ctx context.Context) error {
+				if !ready.Load() {
+					return errors.New("not ready")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "never",
+			Probe: func(ctx context.Context) error {
+				return errors.New("down")
+			},
+		},
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ready.Store(true)
+	}()
+	cfg := testConfig()
+	cfg.Timeout = 100 * time.Millisecond
+	err := Wait(context.Background(), cfg, checks...)
+	require.EqualError(t, err, "never is not available: down")
+
+	require.NoError(t, Wait(context.Background(), cfg, checks[0]))
+}
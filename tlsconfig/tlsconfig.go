@@ -0,0 +1,327 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Policy selects the minimum TLS version and cipher suites.
+type Policy string
+
+const (
+	// PolicyModern only allows TLS 1.3.
+	PolicyModern Policy = "modern"
+	// PolicyIntermediate allows TLS 1.2 with forward secret AEAD cipher suites and TLS 1.3.
+	PolicyIntermediate Policy = "intermediate"
+)
+
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+type Config struct {
+	// Paths to the PEM encoded certificate and key, no certificate is presented if empty.
+	CertFile string
+	KeyFile  string
+	// Path to the PEM encoded CA bundle used to verify peers, the system pool is used if empty.
+	CAFile string
+	// Minimum TLS version and cipher suites.
+	Policy Policy
+	// Client certificate policy for servers.
+	ClientAuth tls.ClientAuthType
+	// Called when reloading the files fails, the previous certificates are kept.
+	OnError func(error)
+	// Registry to register the certificate expiry metric with, not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		CertFile:   "",
+		KeyFile:    "",
+		CAFile:     "",
+		Policy:     PolicyIntermediate,
+		ClientAuth: tls.NoClientCert,
+		OnError:    nil,
+		Registerer: nil,
+	}
+}
+
+// Reloader loads certificates from files and reloads them when the files change, so that rotated
+// certificates are used for new connections without restarting.
+type Reloader struct {
+	cfg    Config
+	expiry *prometheus.GaugeVec
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	caPool *x509.CertPool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New loads the configured files and returns a reloader, an error is returned if the files
+// cannot be loaded.
+func New(cfg Config) (*Reloader, error) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("cert file and key file have to be set together")
+	}
+	if cfg.Policy != PolicyModern && cfg.Policy != PolicyIntermediate {
+		return nil, fmt.Errorf("unknown policy %s", cfg.Policy)
+	}
+	r := &Reloader{
+		cfg:  cfg,
+		done: make(chan struct{}),
+	}
+	if cfg.Registerer != nil {
+		expiry, err := register(cfg.Registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tls_certificate_expiry_timestamp_seconds",
+			Help: "Time the loaded certificate expires as a unix timestamp.",
+		}, []string{"file"}))
+		if err != nil {
+			return nil, err
+		}
+		r.expiry = expiry
+	}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the files, the previous certificates are kept if an error is returned.
+func (r *Reloader) Reload() error {
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("could not load certificate: %w", err)
+		}
+		if c.Leaf == nil {
+			leaf, err := x509.ParseCertificate(c.Certificate[0])
+			if err != nil {
+				return fmt.Errorf("could not parse certificate: %w", err)
+			}
+			c.Leaf = leaf
+		}
+		cert = &c
+	}
+	var caPool *x509.CertPool
+	if r.cfg.CAFile != "" {
+		b, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("could not load CA bundle: %w", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(b) {
+			return fmt.Errorf("CA bundle %s does not contain any certificates", r.cfg.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.caPool = caPool
+	r.mu.Unlock()
+	if r.expiry != nil && cert != nil {
+		r.expiry.WithLabelValues(r.cfg.CertFile).Set(float64(cert.Leaf.NotAfter.Unix()))
+	}
+	return nil
+}
+
+// Certificate returns the currently loaded certificate, nil if no certificate is configured.
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+func (r *Reloader) pool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.caPool
+}
+
+func (r *Reloader) base() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	switch r.cfg.Policy {
+	case PolicyModern:
+		cfg.MinVersion = tls.VersionTLS13
+	case PolicyIntermediate:
+		cfg.CipherSuites = intermediateCipherSuites
+	}
+	return cfg
+}
+
+// ServerConfig returns a config for servers which presents the latest certificate and verifies
+// client certificates against the latest CA bundle.
+func (r *Reloader) ServerConfig() *tls.Config {
+	cfg := r.base()
+	cfg.ClientAuth = r.cfg.ClientAuth
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert := r.Certificate()
+		if cert == nil {
+			return nil, errors.New("no certificate is configured")
+		}
+		return cert, nil
+	}
+	if r.cfg.CAFile != "" {
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			clientCfg := cfg.Clone()
+			clientCfg.GetConfigForClient = nil
+			clientCfg.ClientCAs = r.pool()
+			return clientCfg, nil
+		}
+	}
+	return cfg
+}
+
+// ClientConfig returns a config for clients which presents the latest certificate when requested
+// and verifies servers against the latest CA bundle. Servers have to be addressed by a DNS name
+// when CAFile is set.
+func (r *Reloader) ClientConfig() *tls.Config {
+	cfg := r.base()
+	cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert := r.Certificate()
+		if cert == nil {
+			// An empty certificate is sent when no certificate is configured.
+			return &tls.Certificate{}, nil
+		}
+		return cert, nil
+	}
+	if r.cfg.CAFile != "" {
+		// RootCAs cannot be changed after the config is used, so the verification is done in
+		// VerifyConnection against the latest CA bundle instead. The server name is the one sent
+		// in SNI, which is empty for IP addresses, so the connection is refused rather than
+		// skipping the hostname check.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if cs.ServerName == "" {
+				return errors.New("server name is required to verify the server certificate")
+			}
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+			opts := x509.VerifyOptions{
+				DNSName:       cs.ServerName,
+				Roots:         r.pool(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+	return cfg
+}
+
+// Start watches the directories of the configured files and reloads them on changes until Stop is
+// called or ctx is cancelled. Directories are watched as Kubernetes replaces mounted secrets by
+// swapping a symlink.
+func (r *Reloader) Start(ctx context.Context) error {
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.mu.Unlock()
+		return errors.New("reloader has already been started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.mu.Unlock()
+	defer close(r.done)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	dirs := map[string]bool{}
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.CAFile} {
+		if path == "" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		err := watcher.Add(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			err := r.Reload()
+			if err != nil && r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if r.cfg.OnError != nil {
+				r.cfg.OnError(err)
+			}
+		}
+	}
+}
+
+func (r *Reloader) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered. Other registration errors, such as a collector with the same name
+// but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
@@ -0,0 +1,197 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	for name, b := range files {
+		err := os.WriteFile(filepath.Join(dir, name), b, 0o600)
+		require.NoError(t, err)
+	}
+}
+
+func TestReloaderMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, 2, time.Now().Add(time.Hour))
+	writeFiles(t, dir, map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": ca.pem})
+
+	cfg := DefaultConfig()
+	cfg.CertFile = filepath.Join(dir, "tls.crt")
+	cfg.KeyFile = filepath.Join(dir, "tls.key")
+	cfg.CAFile = filepath.Join(dir, "ca.crt")
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	r, err := New(cfg)
+	require.NoError(t, err)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", r.ServerConfig())
+	require.NoError(t, err)
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	clientCfg := r.ClientConfig()
+	clientCfg.ServerName = "localhost"
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), conn.ConnectionState().Version)
+	conn.Close()
+
+	// Servers not signed by the CA are rejected.
+	other := newTestCA(t)
+	otherCfg := clientCfg.Clone()
+	otherCfg.VerifyConnection = nil
+	otherCfg.InsecureSkipVerify = false
+	otherCfg.RootCAs = x509.NewCertPool()
+	otherCfg.RootCAs.AddCert(other.cert)
+	_, err = tls.Dial("tcp", lis.Addr().String(), otherCfg)
+	require.Error(t, err)
+
+	// Servers with a certificate for another name are rejected.
+	mismatchCfg := clientCfg.Clone()
+	mismatchCfg.ServerName = "example.com"
+	_, err = tls.Dial("tcp", lis.Addr().String(), mismatchCfg)
+	require.ErrorContains(t, err, "example.com")
+
+	// The hostname check is not skipped when no server name is sent, such as for IP addresses.
+	ipCfg := clientCfg.Clone()
+	ipCfg.ServerName = ""
+	_, err = tls.Dial("tcp", lis.Addr().String(), ipCfg)
+	require.ErrorContains(t, err, "server name is required")
+}
+
+func TestReloaderReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	firstExpiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := ca.issue(t, 2, firstExpiry)
+	writeFiles(t, dir, map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM})
+
+	reg := prometheus.NewRegistry()
+	cfg := DefaultConfig()
+	cfg.CertFile = filepath.Join(dir, "tls.crt")
+	cfg.KeyFile = filepath.Join(dir, "tls.key")
+	cfg.Registerer = reg
+	r, err := New(cfg)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), r.Certificate().Leaf.SerialNumber.Int64())
+	require.Equal(t, float64(firstExpiry.Unix()), testutil.ToFloat64(r.expiry.WithLabelValues(cfg.CertFile)))
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- r.Start(context.Background())
+	}()
+	// Wait for the watcher to be added before changing the files.
+	time.Sleep(50 * time.Millisecond)
+
+	secondExpiry := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM = ca.issue(t, 3, secondExpiry)
+	writeFiles(t, dir, map[string][]byte{"tls.key": keyPEM, "tls.crt": certPEM})
+	require.Eventually(t, func() bool {
+		return r.Certificate().Leaf.SerialNumber.Int64() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(secondExpiry.Unix()), testutil.ToFloat64(r.expiry.WithLabelValues(cfg.CertFile)))
+
+	require.NoError(t, r.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CertFile = "tls.crt"
+	_, err := New(cfg)
+	require.EqualError(t, err, "cert file and key file have to be set together")
+
+	cfg = DefaultConfig()
+	cfg.Policy = "old"
+	_, err = New(cfg)
+	require.EqualError(t, err, "unknown policy old")
+
+	cfg = DefaultConfig()
+	cfg.CAFile = filepath.Join(t.TempDir(), "missing.crt")
+	_, err = New(cfg)
+	require.ErrorContains(t, err, "could not load CA bundle")
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "tls_certificate_expiry_timestamp_seconds", Help: "Other metric."}))
+	cfg = DefaultConfig()
+	cfg.Registerer = reg
+	_, err = New(cfg)
+	require.ErrorContains(t, err, "could not register metrics")
+}
@@ -0,0 +1,171 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Struct validates the fields of a struct using validate tags and returns all violations. Rules
+// are separated by commas:
+//
+//	required       the field cannot be the zero value, slices and maps cannot be empty
+//	url            strings have to be absolute URLs
+//	cidr           strings have to be in CIDR notation
+//	oneof=a b      the field has to be one of the space separated values
+//	min=1s,max=1m  numbers and durations have to be within the inclusive range
+//
+// Rules other than required are applied to each element of slices. Nested structs are validated
+// recursively, with the field paths joined by dots.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return fmt.Errorf("cannot validate nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot validate %T, expected a struct", v)
+	}
+	errs, err := validateStruct(rv, "")
+	if err != nil {
+		return err
+	}
+	return All(errs...)
+}
+
+func validateStruct(rv reflect.Value, prefix string) ([]error, error) {
+	errs := []error{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		path := prefix + sf.Name
+		fv := rv.Field(i)
+
+		tag := sf.Tag.Get("validate")
+		if tag != "" && tag != "-" {
+			fieldErrs, err := validateField(fv, path, tag)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, fieldErrs...)
+		}
+
+		nested := fv
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type() != reflect.TypeOf(time.Time{}) {
+			nestedErrs, err := validateStruct(nested, path+".")
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, nestedErrs...)
+		}
+	}
+	return errs, nil
+}
+
+func validateField(fv reflect.Value, path, tag string) ([]error, error) {
+	errs := []error{}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			if fv.IsZero() || ((fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map) && fv.Len() == 0) {
+				errs = append(errs, &FieldError{Field: path, Message: "is required"})
+				// The remaining rules would only repeat the violation.
+				return errs, nil
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Slice {
+			for i := 0; i < fv.Len(); i++ {
+				err := applyRule(fv.Index(i), fmt.Sprintf("%s[%d]", path, i), name, arg)
+				if err != nil {
+					if _, ok := err.(*FieldError); !ok {
+						return nil, err
+					}
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		err := applyRule(fv, path, name, arg)
+		if err != nil {
+			if _, ok := err.(*FieldError); !ok {
+				return nil, err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+// applyRule returns a *FieldError for violations and any other error for invalid tags.
+func applyRule(fv reflect.Value, path, name, arg string) error {
+	switch name {
+	case "url", "cidr":
+		if fv.Kind() != reflect.String {
+			return fmt.Errorf("rule %s cannot be used with %s of type %s", name, path, fv.Type())
+		}
+		rule := URL()
+		if name == "cidr" {
+			rule = CIDR()
+		}
+		return Field(path, fv.String(), rule)
+	case "oneof":
+		return Field(path, fmt.Sprint(fv.Interface()), OneOf(strings.Fields(arg)...))
+	case "min", "max":
+		return applyBound(fv, path, name, arg)
+	default:
+		return fmt.Errorf("unknown rule %s for %s", name, path)
+	}
+}
+
+func applyBound(fv reflect.Value, path, name, arg string) error {
+	isMin := name == "min"
+	switch {
+	case fv.Type() == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return fmt.Errorf("invalid %s for %s: %w", name, path, err)
+		}
+		return Field(path, time.Duration(fv.Int()), limit(isMin, bound))
+	case fv.CanInt():
+		bound, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s for %s: %w", name, path, err)
+		}
+		return Field(path, fv.Int(), limit(isMin, bound))
+	case fv.CanUint():
+		bound, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s for %s: %w", name, path, err)
+		}
+		return Field(path, fv.Uint(), limit(isMin, bound))
+	case fv.CanFloat():
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s for %s: %w", name, path, err)
+		}
+		return Field(path, fv.Float(), limit(isMin, bound))
+	default:
+		return fmt.Errorf("rule %s cannot be used with %s of type %s", name, path, fv.Type())
+	}
+}
+
+func limit[T ordered](isMin bool, bound T) Rule[T] {
+	if isMin {
+		return Min(bound)
+	}
+	return Max(bound)
+}
@@ -0,0 +1,220 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FieldError is a violation of a rule by a single field.
+type FieldError struct {
+	// Path of the field, such as LogConfig.Logger or TrustedProxies[1].
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// Errors contains all violations found during validation.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := []string{}
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "invalid configuration: " + strings.Join(msgs, ", ")
+}
+
+// Rule validates a single value, returning an error describing the violation.
+type Rule[T any] func(value T) error
+
+// Field applies the rules to the value in order, stopping at the first violation.
+func Field[T any](path string, value T, rules ...Rule[T]) error {
+	for _, rule := range rules {
+		err := rule(value)
+		if err != nil {
+			return &FieldError{Field: path, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// Each applies the rules to every element of the slice, using the index in the field path.
+func Each[T any](path string, values []T, rules ...Rule[T]) error {
+	errs := []error{}
+	for i, v := range values {
+		errs = append(errs, Field(fmt.Sprintf("%s[%d]", path, i), v, rules...))
+	}
+	return All(errs...)
+}
+
+// All combines the results of Field, Each and Struct into a single error containing every
+// violation, nil is returned if there are none. Other errors are reported without a field path.
+func All(errs ...error) error {
+	result := Errors{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var fieldErrs Errors
+		var fieldErr *FieldError
+		switch {
+		case errors.As(err, &fieldErrs):
+			result = append(result, fieldErrs...)
+		case errors.As(err, &fieldErr):
+			result = append(result, fieldErr)
+		default:
+			result = append(result, &FieldError{Message: err.Error()})
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Prefix prepends a path to the fields of the violations in err, useful when validating a nested
+// config with its own Validate method.
+func Prefix(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	result := Errors{}
+	for _, fieldErr := range All(err).(Errors) {
+		field := path
+		if fieldErr.Field != "" {
+			field = path + "." + fieldErr.Field
+		}
+		result = append(result, &FieldError{Field: field, Message: fieldErr.Message})
+	}
+	return result
+}
+
+// Required rejects the zero value.
+func Required[T comparable]() Rule[T] {
+	return func(value T) error {
+		var zero T
+		if value == zero {
+			return errors.New("is required")
+		}
+		return nil
+	}
+}
+
+// URL rejects values which are not absolute URLs. Empty values are accepted, combine with
+// Required to reject them.
+func URL(schemes ...string) Rule[string] {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("has to be an absolute URL, got %q", value)
+		}
+		if len(schemes) > 0 && !contains(schemes, u.Scheme) {
+			return fmt.Errorf("has to use one of the schemes %s, got %s", strings.Join(schemes, ", "), u.Scheme)
+		}
+		return nil
+	}
+}
+
+// CIDR rejects values which are not in CIDR notation. Empty values are accepted.
+func CIDR() Rule[string] {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		_, _, err := net.ParseCIDR(value)
+		if err != nil {
+			return fmt.Errorf("has to be a CIDR, got %q", value)
+		}
+		return nil
+	}
+}
+
+// IPOrCIDR rejects values which are neither an IP address nor in CIDR notation. Empty values
+// are accepted.
+func IPOrCIDR() Rule[string] {
+	return func(value string) error {
+		if value == "" || net.ParseIP(value) != nil {
+			return nil
+		}
+		_, _, err := net.ParseCIDR(value)
+		if err != nil {
+			return fmt.Errorf("has to be an IP address or a CIDR, got %q", value)
+		}
+		return nil
+	}
+}
+
+// OneOf rejects values which are not one of the allowed values.
+func OneOf[T comparable](allowed ...T) Rule[T] {
+	return func(value T) error {
+		if contains(allowed, value) {
+			return nil
+		}
+		strs := []string{}
+		for _, a := range allowed {
+			strs = append(strs, fmt.Sprint(a))
+		}
+		return fmt.Errorf("has to be one of %s, got %v", strings.Join(strs, ", "), value)
+	}
+}
+
+type ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// Range rejects values outside of the inclusive range between min and max.
+func Range[T ordered](min, max T) Rule[T] {
+	return func(value T) error {
+		if value < min || value > max {
+			return fmt.Errorf("has to be between %v and %v, got %v", min, max, value)
+		}
+		return nil
+	}
+}
+
+// Min rejects values smaller than min.
+func Min[T ordered](min T) Rule[T] {
+	return func(value T) error {
+		if value < min {
+			return fmt.Errorf("has to be at least %v, got %v", min, value)
+		}
+		return nil
+	}
+}
+
+// Max rejects values larger than max.
+func Max[T ordered](max T) Rule[T] {
+	return func(value T) error {
+		if value > max {
+			return fmt.Errorf("has to be at most %v, got %v", max, value)
+		}
+		return nil
+	}
+}
+
+// DurationRange rejects durations outside of the inclusive range between min and max.
+func DurationRange(min, max time.Duration) Rule[time.Duration] {
+	return Range(min, max)
+}
+
+func contains[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		msg  string
+	}{
+		{
+			name: "required",
+			err:  Field("Name", "", Required[string]()),
+			msg:  "Name is required",
+		},
+		{
+			name: "url",
+			err:  Field("Issuer", "example.com", URL()),
+			msg:  `Issuer has to be an absolute URL, got "example.com"`,
+		},
+		{
+			name: "url scheme",
+			err:  Field("Issuer", "http://example.com", URL("https")),
+			msg:  "Issuer has to use one of the schemes https, got http",
+		},
+		{
+			name: "cidr",
+			err:  Field("Network", "10.0.0.1", CIDR()),
+			msg:  `Network has to be a CIDR, got "10.0.0.1"`,
+		},
+		{
+			name: "ip or cidr",
+			err:  Field("Proxy", "foo", IPOrCIDR()),
+			msg:  `Proxy has to be an IP address or a CIDR, got "foo"`,
+		},
+		{
+			name: "one of",
+			err:  Field("Level", "trace", OneOf("debug", "info")),
+			msg:  "Level has to be one of debug, info, got trace",
+		},
+		{
+			name: "duration range",
+			err:  Field("Timeout", time.Hour, DurationRange(time.Second, time.Minute)),
+			msg:  "Timeout has to be between 1s and 1m0s, got 1h0m0s",
+		},
+		{
+			name: "first violation",
+			err:  Field("Issuer", "", Required[string](), URL()),
+			msg:  "Issuer is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.err, tt.msg)
+		})
+	}
+
+	require.NoError(t, Field("Issuer", "https://example.com", Required[string](), URL("https")))
+	require.NoError(t, Field("Network", "", CIDR()))
+	require.NoError(t, Field("Proxy", "10.0.0.1", IPOrCIDR()))
+	require.NoError(t, Field("Workers", 5, Range(1, 10)))
+}
+
+func TestAll(t *testing.T) {
+	require.NoError(t, All(nil, Field("Name", "foo", Required[string]())))
+
+	err := All(
+		Field("Name", "", Required[string]()),
+		Each("Proxies", []string{"10.0.0.0/8", "foo"}, IPOrCIDR()),
+		Prefix("Server", Field("Port", 0, Range(1, 65535))),
+		errors.New("custom violation"),
+	)
+	require.EqualError(t, err, `invalid configuration: Name is required, Proxies[1] has to be an IP address or a CIDR, got "foo", Server.Port has to be between 1 and 65535, got 0, custom violation`)
+	fieldErrs := Errors{}
+	require.ErrorAs(t, err, &fieldErrs)
+	require.Len(t, fieldErrs, 4)
+	require.Equal(t, "Server.Port", fieldErrs[2].Field)
+}
+
+type testServer struct {
+	Address string        `validate:"required"`
+	Timeout time.Duration `validate:"min=1s,max=1m"`
+}
+
+type testConfig struct {
+	Issuer   string   `validate:"required,url"`
+	Networks []string `validate:"required,cidr"`
+	Level    string   `validate:"oneof=debug info"`
+	Workers  int      `validate:"min=1"`
+	Server   testServer
+	Backup   *testServer
+	ignored  string `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	cfg := testConfig{
+		Issuer:   "https://example.com",
+		Networks: []string{"10.0.0.0/8"},
+		Level:    "info",
+		Workers:  1,
+		Server:   testServer{Address: ":8080", Timeout: time.Second},
+	}
+	require.NoError(t, Struct(cfg))
+	require.NoError(t, Struct(&cfg))
+
+	cfg = testConfig{
+		Issuer:   "example.com",
+		Networks: []string{"10.0.0.0/8", "10.0.0.1"},
+		Level:    "trace",
+		Server:   testServer{Timeout: time.Hour},
+		Backup:   &testServer{Address: ":8081"},
+	}
+	err := Struct(cfg)
+	fieldErrs := Errors{}
+	require.ErrorAs(t, err, &fieldErrs)
+	fields := []string{}
+	for _, fieldErr := range fieldErrs {
+		fields = append(fields, fieldErr.Field)
+	}
+	require.Equal(t, []string{"Issuer", "Networks[1]", "Level", "Workers", "Server.Address", "Server.Timeout", "Backup.Timeout"}, fields)
+	require.Equal(t, "has to be at most 1m0s, got 1h0m0s", fieldErrs[5].Message)
+}
+
+func TestStructInvalidTag(t *testing.T) {
+	err := Struct(struct {
+		Name string `validate:"email"`
+	}{})
+	require.EqualError(t, err, "unknown rule email for Name")
+	err = Struct(struct {
+		Count int `validate:"url"`
+	}{})
+	require.EqualError(t, err, "rule url cannot be used with Count of type int")
+	err = Struct("foo")
+	require.EqualError(t, err, "cannot validate string, expected a struct")
+}
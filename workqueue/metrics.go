@@ -0,0 +1,81 @@
+package workqueue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	depth         prometheus.Gauge
+	adds          prometheus.Counter
+	retries       prometheus.Counter
+	queueDuration prometheus.Observer
+	workDuration  prometheus.Observer
+}
+
+func newMetrics(reg prometheus.Registerer, name string) (*metrics, error) {
+	depth, err := register(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_depth",
+		Help: "Current number of items waiting to be processed.",
+	}, []string{"name"}))
+	if err != nil {
+		return nil, err
+	}
+	adds, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_adds_total",
+		Help: "Total number of items added to the queue.",
+	}, []string{"name"}))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_retries_total",
+		Help: "Total number of items retried after failing.",
+	}, []string{"name"}))
+	if err != nil {
+		return nil, err
+	}
+	queueDuration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_queue_duration_seconds",
+		Help:    "Time an item waits in the queue before being processed.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"name"}))
+	if err != nil {
+		return nil, err
+	}
+	workDuration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_work_duration_seconds",
+		Help:    "Time spent processing an item.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"name"}))
+	if err != nil {
+		return nil, err
+	}
+	return &metrics{
+		depth:         depth.WithLabelValues(name),
+		adds:          adds.WithLabelValues(name),
+		retries:       retries.WithLabelValues(name),
+		queueDuration: queueDuration.WithLabelValues(name),
+		workDuration:  workDuration.WithLabelValues(name),
+	}, nil
+}
+
+// register registers the collector, reusing the existing collector if an identical collector
+// has already been registered. Other registration errors, such as a collector with the same name
+// but different labels, are returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+	are := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	var zero C
+	return zero, fmt.Errorf("could not register metrics: %w", err)
+}
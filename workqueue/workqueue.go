@@ -0,0 +1,315 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xenitab/pkg/retry"
+)
+
+// ErrStopped is passed to OnDrop for delayed items which had not been added when the queue was
+// stopped.
+var ErrStopped = errors.New("queue has been stopped")
+
+type Config struct {
+	// Name of the queue, used as label in metrics.
+	Name string
+	// Number of items processed concurrently.
+	Workers int
+	// Retry policy for items which fail processing, MaxAttempts includes the first attempt.
+	// MaxElapsedTime is not used, OnRetry is called before an item is added with a delay.
+	Retry retry.Policy
+	// Called when an item is dropped after failing all attempts or with a non retryable error,
+	// or with ErrStopped when the item was still delayed when the queue was stopped.
+	OnDrop func(item interface{}, err error)
+	// Registry to register metrics with, metrics are not recorded if nil.
+	Registerer prometheus.Registerer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Name:       "",
+		Workers:    1,
+		Retry:      retry.DefaultPolicy(),
+		OnDrop:     nil,
+		Registerer: nil,
+	}
+}
+
+// Queue is an at-least-once work queue. An item is never processed by more than one worker at a
+// time, and adding an item which is already waiting to be processed has no effect. Items added
+// while being processed are processed again once the current processing is done.
+type Queue[T comparable] struct {
+	cfg     Config
+	handler func(context.Context, T) error
+	metrics *metrics
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []T
+	dirty      map[T]time.Time
+	processing map[T]struct{}
+	failures   map[T]int
+	timers     map[*time.Timer]T
+	started    bool
+	shutdown   bool
+	done       chan struct{}
+}
+
+// New returns a queue which calls handler for each item. An error is returned if the metrics
+// cannot be registered with cfg.Registerer.
+func New[T comparable](cfg Config, handler func(ctx context.Context, item T) error) (*Queue[T], error) {
+	q := &Queue[T]{
+		cfg:        cfg,
+		handler:    handler,
+		dirty:      map[T]time.Time{},
+		processing: map[T]struct{}{},
+		failures:   map[T]int{},
+		timers:     map[*time.Timer]T{},
+		done:       make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	if cfg.Registerer != nil {
+		m, err := newMetrics(cfg.Registerer, cfg.Name)
+		if err != nil {
+			return nil, err
+		}
+		q.metrics = m
+	}
+	return q, nil
+}
+
+// Add adds the item to the queue unless it is already waiting to be processed. Items added after
+// Stop has been called are ignored.
+func (q *Queue[T]) Add(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.add(item)
+}
+
+// AddAfter adds the item to the queue after the delay has passed. Items which are still delayed
+// when the queue is stopped are dropped and passed to OnDrop with ErrStopped.
+func (q *Queue[T]) AddAfter(item T, d time.Duration) {
+	if d <= 0 {
+		q.Add(item)
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shutdown {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if _, ok := q.timers[timer]; !ok {
+			return
+		}
+		delete(q.timers, timer)
+		q.add(item)
+	})
+	q.timers[timer] = item
+}
+
+// AddRateLimited adds the item after a delay based on the number of times it has failed.
+func (q *Queue[T]) AddRateLimited(item T) {
+	q.mu.Lock()
+	failures := q.failures[item]
+	q.mu.Unlock()
+	if failures == 0 {
+		q.Add(item)
+		return
+	}
+	q.AddAfter(item, q.cfg.Retry.Delay(failures-1))
+}
+
+// Forget resets the number of failures for the item.
+func (q *Queue[T]) Forget(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.failures, item)
+}
+
+// Len returns the number of items waiting to be processed.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+// Start processes items until Stop is called or ctx is cancelled. The context passed to the
+// handler is cancelled when ctx is cancelled, but not when Stop is called.
+func (q *Queue[T]) Start(ctx context.Context) error {
+	q.mu.Lock()
+	if q.started {
+		q.mu.Unlock()
+		return errors.New("queue has already been started")
+	}
+	q.started = true
+	q.mu.Unlock()
+	defer close(q.done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.stop()
+		case <-q.done:
+		}
+	}()
+
+	workers := q.cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, ok := q.get()
+				if !ok {
+					return
+				}
+				q.process(ctx, item)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// Stop stops accepting new items and waits for the queued items to be processed. Delayed items
+// which have not yet been added are dropped and passed to OnDrop with ErrStopped. An error is
+// returned if the queue has not been started, the queue can still be started afterwards.
+func (q *Queue[T]) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	started := q.started
+	q.mu.Unlock()
+	if !started {
+		return errors.New("queue has not been started")
+	}
+	q.stop()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue[T]) stop() {
+	q.mu.Lock()
+	q.shutdown = true
+	dropped := []T{}
+	for timer, item := range q.timers {
+		timer.Stop()
+		delete(q.failures, item)
+		dropped = append(dropped, item)
+	}
+	q.timers = map[*time.Timer]T{}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	if q.cfg.OnDrop != nil {
+		for _, item := range dropped {
+			q.cfg.OnDrop(item, ErrStopped)
+		}
+	}
+}
+
+// add has to be called with the lock held.
+func (q *Queue[T]) add(item T) {
+	if q.shutdown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	q.dirty[item] = time.Now()
+	if q.metrics != nil {
+		q.metrics.adds.Inc()
+	}
+	// Items being processed are queued again when processing is done.
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+	q.queue = append(q.queue, item)
+	q.updateDepth()
+	q.cond.Signal()
+}
+
+// get blocks until an item is available, false is returned when the queue is shut down and empty.
+func (q *Queue[T]) get() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) == 0 && !q.shutdown {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		var zero T
+		return zero, false
+	}
+	item := q.queue[0]
+	q.queue = q.queue[1:]
+	q.updateDepth()
+	if q.metrics != nil {
+		q.metrics.queueDuration.Observe(time.Since(q.dirty[item]).Seconds())
+	}
+	delete(q.dirty, item)
+	q.processing[item] = struct{}{}
+	return item, true
+}
+
+func (q *Queue[T]) process(ctx context.Context, item T) {
+	start := time.Now()
+	err := q.handler(ctx, item)
+	if q.metrics != nil {
+		q.metrics.workDuration.Observe(time.Since(start).Seconds())
+	}
+
+	q.mu.Lock()
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.queue = append(q.queue, item)
+		q.updateDepth()
+		q.cond.Signal()
+	}
+	if err == nil {
+		delete(q.failures, item)
+		q.mu.Unlock()
+		return
+	}
+	q.failures[item]++
+	failures := q.failures[item]
+	retryable := q.cfg.Retry.Retryable == nil || q.cfg.Retry.Retryable(err)
+	if failures >= q.cfg.Retry.MaxAttempts || !retryable || q.shutdown {
+		delete(q.failures, item)
+		q.mu.Unlock()
+		if q.cfg.OnDrop != nil {
+			q.cfg.OnDrop(item, err)
+		}
+		return
+	}
+	q.mu.Unlock()
+	if q.metrics != nil {
+		q.metrics.retries.Inc()
+	}
+	delay := q.cfg.Retry.Delay(failures - 1)
+	if q.cfg.Retry.OnRetry != nil {
+		q.cfg.Retry.OnRetry(failures, err, delay)
+	}
+	q.AddAfter(item, delay)
+}
+
+// updateDepth has to be called with the lock held.
+func (q *Queue[T]) updateDepth() {
+	if q.metrics != nil {
+		q.metrics.depth.Set(float64(len(q.queue)))
+	}
+}
@@ -0,0 +1,313 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Retry.InitialInterval = time.Millisecond
+	cfg.Retry.MaxInterval = 10 * time.Millisecond
+	cfg.Retry.Jitter = 0
+	return cfg
+}
+
+// start starts the queue and waits for it to be started, so that Stop does not race with Start.
+func start[T comparable](t *testing.T, q *Queue[T]) <-chan error {
+	t.Helper()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- q.Start(context.Background())
+	}()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.started
+	}, time.Second, time.Millisecond)
+	return errCh
+}
+
+func TestDeduplication(t *testing.T) {
+	mu := sync.Mutex{}
+	processed := []string{}
+	q, err := New(testConfig(), func(ctx context.Context, item string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, item)
+		return nil
+	})
+	require.NoError(t, err)
+	q.Add("foo")
+	q.Add("bar")
+	q.Add("foo")
+	require.Equal(t, 2, q.Len())
+
+	errCh := start(t, q)
+	err = q.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"foo", "bar"}, processed)
+}
+
+func TestAddWhileProcessing(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mu := sync.Mutex{}
+	count := 0
+	cfg := testConfig()
+	cfg.Workers = 2
+	q, err := New(cfg, func(ctx context.Context, item string) error {
+		mu.Lock()
+		count++
+		first := count == 1
+		mu.Unlock()
+		if first {
+			close(started)
+			<-release
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	q.Add("foo")
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- q.Start(context.Background())
+	}()
+	<-started
+	// The item is queued again once processing is done, never processed concurrently.
+	q.Add("foo")
+	q.Add("foo")
+	require.Equal(t, 0, q.Len())
+	close(release)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return count == 2
+	}, time.Second, time.Millisecond)
+	err = q.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, 2, count)
+}
+
+func TestRetry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mu := sync.Mutex{}
+	attempts := map[string]int{}
+	dropped := make(chan interface{}, 1)
+	cfg := testConfig()
+	cfg.Name = "test"
+	cfg.Registerer = reg
+	cfg.Retry.MaxAttempts = 3
+	var retries atomic.Int32
+	cfg.Retry.OnRetry = func(attempt int, err error, delay time.Duration) {
+		retries.Add(1)
+	}
+	cfg.OnDrop = func(item interface{}, err error) {
+		dropped <- item
+	}
+	q, err := New(cfg, func(ctx context.Context, item string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[item]++
+		if item == "fail" || attempts[item] < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+	q.Add("foo")
+	q.Add("fail")
+
+	require.Equal(t, "fail", <-dropped)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts["foo"] == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 3, attempts["fail"])
+	require.Equal(t, float64(3), testutil.ToFloat64(q.metrics.retries))
+	require.Equal(t, int32(3), retries.Load())
+}
+
+func TestNonRetryable(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	dropped := make(chan error, 1)
+	cfg := testConfig()
+	cfg.Retry.Retryable = func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	}
+	cfg.OnDrop = func(item interface{}, err error) {
+		dropped <- err
+	}
+	q, err := New(cfg, func(ctx context.Context, item int) error {
+		return errPermanent
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+	q.Add(1)
+	require.ErrorIs(t, <-dropped, errPermanent)
+}
+
+func TestAddAfter(t *testing.T) {
+	processed := make(chan time.Time, 1)
+	q, err := New(testConfig(), func(ctx context.Context, item string) error {
+		processed <- time.Now()
+		return nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+	start := time.Now()
+	q.AddAfter("foo", 50*time.Millisecond)
+	require.Equal(t, 0, q.Len())
+	require.GreaterOrEqual(t, (<-processed).Sub(start), 50*time.Millisecond)
+}
+
+func TestAddRateLimited(t *testing.T) {
+	cfg := testConfig()
+	cfg.Retry.InitialInterval = 50 * time.Millisecond
+	q, err := New(cfg, func(ctx context.Context, item string) error {
+		return nil
+	})
+	require.NoError(t, err)
+	q.AddRateLimited("foo")
+	require.Equal(t, 1, q.Len())
+
+	q.failures["bar"] = 1
+	q.AddRateLimited("bar")
+	require.Equal(t, 1, q.Len())
+	require.Eventually(t, func() bool {
+		return q.Len() == 2
+	}, time.Second, time.Millisecond)
+
+	q.Forget("bar")
+	require.Empty(t, q.failures)
+}
+
+type drop struct {
+	item interface{}
+	err  error
+}
+
+func TestStopDrainsQueue(t *testing.T) {
+	mu := sync.Mutex{}
+	processed := 0
+	drops := []drop{}
+	cfg := testConfig()
+	cfg.OnDrop = func(item interface{}, err error) {
+		drops = append(drops, drop{item: item, err: err})
+	}
+	q, err := New(cfg, func(ctx context.Context, item int) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		processed++
+		return nil
+	})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		q.Add(i)
+	}
+	q.AddAfter(100, time.Hour)
+
+	errCh := start(t, q)
+	err = q.Stop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, 10, processed)
+	require.Equal(t, []drop{{item: 100, err: ErrStopped}}, drops)
+
+	// Items added after stopping are ignored.
+	q.Add(11)
+	require.Equal(t, 0, q.Len())
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	processed := make(chan int, 1)
+	q, err := New(testConfig(), func(ctx context.Context, item int) error {
+		processed <- item
+		return nil
+	})
+	require.NoError(t, err)
+	err = q.Stop(context.Background())
+	require.EqualError(t, err, "queue has not been started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+	q.Add(1)
+	require.Equal(t, 1, <-processed)
+}
+
+func TestStopTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	q, err := New(testConfig(), func(ctx context.Context, item int) error {
+		close(started)
+		<-release
+		return nil
+	})
+	require.NoError(t, err)
+	q.Add(1)
+	go q.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = q.Stop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStartTwice(t *testing.T) {
+	q, err := New(testConfig(), func(ctx context.Context, item int) error {
+		return nil
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- q.Start(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.started
+	}, time.Second, time.Millisecond)
+	err = q.Start(ctx)
+	require.EqualError(t, err, "queue has already been started")
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestNewRegistrationError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "workqueue_depth", Help: "Other metric."}))
+	cfg := testConfig()
+	cfg.Registerer = reg
+	_, err := New(cfg, func(ctx context.Context, item string) error {
+		return nil
+	})
+	require.ErrorContains(t, err, "could not register metrics")
+}